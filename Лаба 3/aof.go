@@ -0,0 +1,434 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FsyncPolicy определяет, как часто журнал команд сбрасывается на диск
+type FsyncPolicy int
+
+const (
+	FsyncEverySec FsyncPolicy = iota // Сброс не чаще раза в секунду в фоне
+	FsyncAlways                      // Сброс после каждой команды
+	FsyncNo                          // Сброс на усмотрение операционной системы
+)
+
+// String возвращает название политики сброса
+func (p FsyncPolicy) String() string {
+	switch p {
+	case FsyncAlways:
+		return "always"
+	case FsyncNo:
+		return "no"
+	}
+	return "everysec"
+}
+
+// ParseFsyncPolicy разбирает название политики сброса
+func ParseFsyncPolicy(name string) (FsyncPolicy, error) {
+	switch strings.ToLower(name) {
+	case "always":
+		return FsyncAlways, nil
+	case "everysec":
+		return FsyncEverySec, nil
+	case "no":
+		return FsyncNo, nil
+	}
+	return FsyncEverySec, fmt.Errorf("неизвестная политика сброса журнала: %s", name)
+}
+
+// mutatingCommands - команды, меняющие состояние структур; только они записываются в журнал.
+// CGET тоже меняет порядок вытеснения кэша, поэтому записывается.
+var mutatingCommands = map[string]bool{
+	"MPUSH": true, "MDEL": true, "MREPLACE": true,
+	"SPUSH": true, "SPOP": true, "QPUSH": true, "QPOP": true,
+	"LSADDHEAD": true, "LSADDTAIL": true, "LSDELHEAD": true, "LSDELTAIL": true, "LSDELVALUE": true,
+	"LDADDHEAD": true, "LDADDTAIL": true, "LDDELHEAD": true, "LDDELTAIL": true, "LDDELVALUE": true,
+	"HSET": true, "HDEL": true, "CSET": true, "CGET": true,
+	"TINSERT": true, "TDEL": true, "TSET": true, "TBUILD": true,
+	"AVLINSERT": true, "AVLDEL": true, "RBINSERT": true, "RBDEL": true,
+	"PQPUSH": true, "PQPOP": true, "PQDECREASE": true,
+}
+
+// syncLogDir сбрасывает каталог журнала после перезаписи; подменяется в тестах
+var syncLogDir = syncDir
+
+// stateReplacingCommands - команды, результат которых зависит от данных вне журнала
+// (истории версий, файла сеанса или импортируемого файла). Вместо записи команды
+// журнал перезаписывается.
+var stateReplacingCommands = map[string]bool{"TCHECKOUT": true, "LOADALL": true, "IMPORT": true}
+
+// aofModeCommand начинает первую строку журнала с режимом структур (см. aofMode)
+const aofModeCommand = "AOFMODE"
+
+// aofMode описывает режимы структур, от которых зависит результат команд журнала:
+// вид бинарного дерева (--tree), вид кучи (--heap) и стратегию кэша (--cache-policy)
+func aofMode(cbTree *BinaryTree, cache *Cache, priorityQueue *PriorityQueue) string {
+	tree := "cbt"
+	if cbTree.Ordered {
+		tree = "bst"
+	}
+	return fmt.Sprintf("tree=%s heap=%s cache-policy=%s", tree, priorityQueue.Kind, cache.Policy)
+}
+
+// AppendOnlyLog - журнал команд, дописываемый в конец файла. Состояние восстанавливается
+// повторным выполнением команд, а перезапись сжимает журнал до текущего состояния.
+// Первой строкой журнала записывается режим структур, и журнал, записанный
+// в другом режиме, не воспроизводится.
+type AppendOnlyLog struct {
+	filename   string
+	policy     FsyncPolicy
+	mode       string
+	mu         sync.Mutex
+	file       *os.File
+	dirty      bool          // Есть записи, не сброшенные на диск
+	rewriting  bool          // Выполняется фоновая перезапись
+	rewriteBuf []string      // Команды, записанные во время перезаписи
+	rewriteErr chan error    // Результат фоновой перезаписи
+	stop       chan struct{} // Остановка фонового сброса
+	stopped    chan struct{}
+}
+
+// OpenAppendOnlyLog открывает журнал для дописывания, создавая файл при необходимости.
+// В новый журнал первой строкой записывается mode; пустой mode не записывается и не проверяется.
+func OpenAppendOnlyLog(filename string, policy FsyncPolicy, mode string) (*AppendOnlyLog, error) {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть журнал: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("не удалось открыть журнал: %v", err)
+	}
+	if info.Size() == 0 && mode != "" {
+		if _, err := file.WriteString(aofModeCommand + " " + mode + "\n"); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("ошибка записи в журнал: %v", err)
+		}
+	}
+	log := &AppendOnlyLog{filename: filename, policy: policy, mode: mode, file: file}
+	if policy == FsyncEverySec {
+		log.stop = make(chan struct{})
+		log.stopped = make(chan struct{})
+		go log.syncEverySecond()
+	}
+	return log, nil
+}
+
+// syncEverySecond раз в секунду сбрасывает накопленные записи на диск
+func (l *AppendOnlyLog) syncEverySecond() {
+	defer close(l.stopped)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.Sync()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Replay выполняет все команды журнала по порядку и возвращает их количество.
+// Вывод команд при воспроизведении подавляется. Недописанная последняя строка
+// (например, после сбоя во время записи) отбрасывается и удаляется из файла.
+// Если режим из первой строки отличается от режима журнала, команды не выполняются:
+// в другом режиме они построили бы другие деревья, кучу и порядок вытеснения.
+func (l *AppendOnlyLog) Replay(apply func(command string)) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	data, err := os.ReadFile(l.filename)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось прочитать журнал: %v", err)
+	}
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	if complete < len(data) {
+		if err := l.file.Truncate(int64(complete)); err != nil {
+			return 0, fmt.Errorf("не удалось отбросить недописанную команду: %v", err)
+		}
+		data = data[:complete]
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if mode, ok := strings.CutPrefix(strings.TrimSuffix(lines[0], "\r"), aofModeCommand+" "); ok {
+		if l.mode != "" && mode != l.mode {
+			return 0, fmt.Errorf("журнал записан в режиме %q, а текущий режим %q: укажите те же флаги --tree, --heap и --cache-policy", mode, l.mode)
+		}
+		lines = lines[1:]
+	}
+
+	stdout := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось подавить вывод: %v", err)
+	}
+	os.Stdout = devNull
+	defer func() {
+		os.Stdout = stdout
+		devNull.Close()
+	}()
+
+	count := 0
+	for _, line := range lines {
+		command := strings.TrimSuffix(line, "\r")
+		if command == "" {
+			continue
+		}
+		apply(command)
+		count++
+	}
+	return count, nil
+}
+
+// Append дописывает команду в журнал и сбрасывает его на диск согласно политике
+func (l *AppendOnlyLog) Append(command string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.WriteString(command + "\n"); err != nil {
+		return fmt.Errorf("ошибка записи в журнал: %v", err)
+	}
+	if l.rewriting {
+		l.rewriteBuf = append(l.rewriteBuf, command)
+	}
+	l.dirty = true
+	if l.policy == FsyncAlways {
+		return l.syncLocked()
+	}
+	return nil
+}
+
+// Sync сбрасывает записанные команды на диск
+func (l *AppendOnlyLog) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.syncLocked()
+}
+
+// syncLocked сбрасывает журнал на диск; вызывается под блокировкой
+func (l *AppendOnlyLog) syncLocked() error {
+	if !l.dirty {
+		return nil
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("ошибка сброса журнала на диск: %v", err)
+	}
+	l.dirty = false
+	return nil
+}
+
+// StartRewrite запускает фоновую перезапись журнала командами, воспроизводящими
+// текущее состояние. Команды, дописанные во время перезаписи, переносятся в новый журнал.
+func (l *AppendOnlyLog) StartRewrite(commands []string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rewriting {
+		return fmt.Errorf("перезапись журнала уже выполняется")
+	}
+	l.rewriting = true
+	l.rewriteBuf = nil
+	l.rewriteErr = make(chan error, 1)
+	go func() {
+		l.rewriteErr <- l.rewrite(commands)
+	}()
+	return nil
+}
+
+// WaitRewrite ожидает завершения фоновой перезаписи и возвращает ее результат
+func (l *AppendOnlyLog) WaitRewrite() error {
+	l.mu.Lock()
+	done := l.rewriteErr
+	l.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+	err := <-done
+	l.mu.Lock()
+	l.rewriteErr = nil
+	l.mu.Unlock()
+	return err
+}
+
+// rewrite записывает сжатый журнал во временный файл и атомарно подменяет им текущий
+func (l *AppendOnlyLog) rewrite(commands []string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(l.filename), filepath.Base(l.filename)+".rewrite-*")
+	if err != nil {
+		l.finishRewrite()
+		return fmt.Errorf("не удалось создать временный журнал: %v", err)
+	}
+	return l.writeCompacted(tmp, commands)
+}
+
+// writeCompacted записывает сжатые команды и накопленный за время перезаписи хвост,
+// после чего подменяет журнал. Хвост дописывается под блокировкой, чтобы не потерять команды.
+// Временный файл удаляется, только если он так и не заменил журнал.
+func (l *AppendOnlyLog) writeCompacted(tmp *os.File, commands []string) error {
+	installed := false
+	defer func() {
+		if !installed {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if err := tmp.Chmod(0644); err != nil {
+		l.finishRewrite()
+		return fmt.Errorf("не удалось задать права временного журнала: %v", err)
+	}
+	if l.mode != "" {
+		commands = append([]string{aofModeCommand + " " + l.mode}, commands...)
+	}
+	if _, err := tmp.WriteString(joinCommands(commands)); err != nil {
+		l.finishRewrite()
+		return fmt.Errorf("ошибка записи временного журнала: %v", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tail := l.rewriteBuf
+	l.rewriting = false
+	l.rewriteBuf = nil
+	if _, err := tmp.WriteString(joinCommands(tail)); err != nil {
+		return fmt.Errorf("ошибка записи временного журнала: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("ошибка сброса временного журнала: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), l.filename); err != nil {
+		return fmt.Errorf("не удалось заменить журнал: %v", err)
+	}
+	// После переименования старый файл уже отвязан от имени, поэтому журналом
+	// становится временный файл, даже если сброс каталога не удастся.
+	// Позиция записи временного файла уже в конце.
+	installed = true
+	l.file.Close()
+	l.file = tmp
+	l.dirty = false
+	if err := syncLogDir(filepath.Dir(l.filename)); err != nil {
+		return fmt.Errorf("ошибка сброса каталога на диск: %v", err)
+	}
+	return nil
+}
+
+// finishRewrite снимает признак перезаписи после ошибки
+func (l *AppendOnlyLog) finishRewrite() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rewriting = false
+	l.rewriteBuf = nil
+}
+
+// Close дожидается перезаписи, сбрасывает журнал на диск и закрывает его
+func (l *AppendOnlyLog) Close() error {
+	rewriteErr := l.WaitRewrite()
+	if l.stop != nil {
+		close(l.stop)
+		<-l.stopped
+		l.stop = nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var err error
+	if l.policy != FsyncNo {
+		err = l.syncLocked()
+	}
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	if rewriteErr != nil {
+		return rewriteErr
+	}
+	return err
+}
+
+// joinCommands объединяет команды в строки журнала
+func joinCommands(commands []string) string {
+	var builder strings.Builder
+	for _, command := range commands {
+		builder.WriteString(command)
+		builder.WriteByte('\n')
+	}
+	return builder.String()
+}
+
+// compactCommands возвращает минимальный набор команд, воспроизводящий текущее
+// состояние структур. Счетчики попаданий кэша и история версий дерева не сохраняются.
+// Бинарное дерево восстанавливается командой TBUILD в точности той же формы,
+// остальные деревья заполняются в порядке обхода по уровням.
+func compactCommands(array *Array, stack *Stack, queue *Queue, singlyList *SinglyLinkedList, doublyList *DoublyLinkedList, hashTable *HashTable, cbTree *BinaryTree, stringTree *StringTree, avlTree *AVLTree, rbTree *RedBlackTree, cache *Cache, priorityQueue *PriorityQueue) []string {
+	var commands []string
+	add := func(parts ...string) {
+		commands = append(commands, strings.Join(parts, " "))
+	}
+
+	for i := 0; i < array.size; i++ {
+		add("MPUSH", strconv.Itoa(i), array.data[i])
+	}
+	var stackValues []string
+	for node := stack.Top; node != nil; node = node.Next {
+		stackValues = append(stackValues, node.Data)
+	}
+	for _, value := range slices.Backward(stackValues) {
+		add("SPUSH", value)
+	}
+	for node := queue.Front; node != nil; node = node.Next {
+		add("QPUSH", node.Data)
+	}
+	for node := singlyList.Head; node != nil; node = node.Next {
+		add("LSADDTAIL", node.Data)
+	}
+	for node := doublyList.Head; node != nil; node = node.Next {
+		add("LDADDTAIL", node.Data)
+	}
+	// HSET добавляет ключ в начало цепочки, поэтому цепочка записывается с конца
+	for _, head := range hashTable.Table {
+		var chain []*HashNode
+		for node := head; node != nil; node = node.Next {
+			chain = append(chain, node)
+		}
+		for _, node := range slices.Backward(chain) {
+			add("HSET", node.Key, node.Value)
+		}
+	}
+
+	if cbTree.Root != nil {
+		add("TBUILD", "level", cbTree.LevelOrderString())
+	}
+	// Первая вставка с нечисловым ключом или нагрузкой переключает команды T на строковое дерево
+	for node := range stringTree.nodes() {
+		if node.HasPayload {
+			add("TINSERT", node.Key, node.Payload)
+		} else {
+			add("TINSERT", node.Key)
+		}
+	}
+	for _, value := range avlTree.levelOrderValues() {
+		add("AVLINSERT", strconv.Itoa(value))
+	}
+	for _, value := range rbTree.levelOrderValues() {
+		add("RBINSERT", strconv.Itoa(value))
+	}
+
+	// Элементы кэша добавляются от первого кандидата на вытеснение к последнему,
+	// для LFU частота восстанавливается повторными обращениями
+	for _, key := range cache.Keys() {
+		value, _ := cache.Peek(key)
+		add("CSET", key, value)
+		for i := 1; i < cache.Frequency(key); i++ {
+			add("CGET", key)
+		}
+	}
+	// Вставка в порядке хранения в куче не вызывает перестановок и сохраняет порядок элементов
+	for _, item := range priorityQueue.Items() {
+		add("PQPUSH", item.Value, strconv.Itoa(item.Priority))
+	}
+	return commands
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestParseFsyncPolicy проверяет разбор политик сброса журнала
+func TestParseFsyncPolicy(t *testing.T) {
+	for _, policy := range []FsyncPolicy{FsyncAlways, FsyncEverySec, FsyncNo} {
+		parsed, err := ParseFsyncPolicy(strings.ToUpper(policy.String()))
+		if err != nil || parsed != policy {
+			t.Errorf("ParseFsyncPolicy(%s) = %v, %v", policy, parsed, err)
+		}
+	}
+	if _, err := ParseFsyncPolicy("sometimes"); err == nil {
+		t.Errorf("Ожидалась ошибка для неизвестной политики")
+	}
+}
+
+// replayCommands открывает журнал и возвращает записанные в нем команды
+func replayCommands(t *testing.T, filename string) []string {
+	t.Helper()
+	log, err := OpenAppendOnlyLog(filename, FsyncNo, "")
+	if err != nil {
+		t.Fatalf("OpenAppendOnlyLog вернул ошибку: %v", err)
+	}
+	defer log.Close()
+	var commands []string
+	count, err := log.Replay(func(command string) {
+		commands = append(commands, command)
+	})
+	if err != nil {
+		t.Fatalf("Replay вернул ошибку: %v", err)
+	}
+	if count != len(commands) {
+		t.Errorf("Replay вернул %d, выполнено %d команд", count, len(commands))
+	}
+	return commands
+}
+
+// TestAppendOnlyLogReplay проверяет запись и воспроизведение команд при всех политиках сброса
+func TestAppendOnlyLogReplay(t *testing.T) {
+	for _, policy := range []FsyncPolicy{FsyncAlways, FsyncEverySec, FsyncNo} {
+		filename := filepath.Join(t.TempDir(), "commands.aof")
+		log, err := OpenAppendOnlyLog(filename, policy, "")
+		if err != nil {
+			t.Fatalf("OpenAppendOnlyLog вернул ошибку: %v", err)
+		}
+		expected := []string{"SPUSH a", "HSET key value", "TINSERT 5"}
+		for _, command := range expected {
+			if err := log.Append(command); err != nil {
+				t.Fatalf("Append вернул ошибку: %v", err)
+			}
+		}
+		if err := log.Close(); err != nil {
+			t.Fatalf("Close вернул ошибку: %v", err)
+		}
+		if actual := replayCommands(t, filename); !slices.Equal(actual, expected) {
+			t.Errorf("%s: воспроизведены команды %v, ожидалось %v", policy, actual, expected)
+		}
+	}
+}
+
+// TestAppendOnlyLogTornWrite проверяет, что недописанная команда отбрасывается
+func TestAppendOnlyLogTornWrite(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "commands.aof")
+	os.WriteFile(filename, []byte("SPUSH a\nSPUSH b\nSPU"), 0644)
+
+	if actual := replayCommands(t, filename); !slices.Equal(actual, []string{"SPUSH a", "SPUSH b"}) {
+		t.Errorf("Воспроизведены команды %v", actual)
+	}
+	data, _ := os.ReadFile(filename)
+	if string(data) != "SPUSH a\nSPUSH b\n" {
+		t.Errorf("Недописанная команда не удалена из файла: %q", data)
+	}
+}
+
+// TestAppendOnlyLogReplaySilent проверяет, что вывод команд при воспроизведении подавляется
+func TestAppendOnlyLogReplaySilent(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "commands.aof")
+	os.WriteFile(filename, []byte("SPOP\n"), 0644)
+	log, _ := OpenAppendOnlyLog(filename, FsyncNo, "")
+	defer log.Close()
+	output := captureStdout(func() {
+		log.Replay(func(command string) {
+			fmt.Println("вывод команды", command)
+		})
+	})
+	if output != "" {
+		t.Errorf("Ожидался пустой вывод, получено: %q", output)
+	}
+}
+
+// TestAppendOnlyLogRewrite проверяет, что перезапись заменяет журнал сжатыми командами
+// и сохраняет команды, дописанные после ее запуска
+func TestAppendOnlyLogRewrite(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "commands.aof")
+	log, _ := OpenAppendOnlyLog(filename, FsyncAlways, "")
+	for _, command := range []string{"SPUSH a", "SPUSH b", "SPOP", "SPOP", "SPUSH c"} {
+		log.Append(command)
+	}
+	if err := log.StartRewrite([]string{"SPUSH c"}); err != nil {
+		t.Fatalf("StartRewrite вернул ошибку: %v", err)
+	}
+	log.Append("SPUSH d")
+	if err := log.WaitRewrite(); err != nil {
+		t.Fatalf("Перезапись завершилась ошибкой: %v", err)
+	}
+	log.Append("SPUSH e")
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close вернул ошибку: %v", err)
+	}
+
+	expected := []string{"SPUSH c", "SPUSH d", "SPUSH e"}
+	if actual := replayCommands(t, filename); !slices.Equal(actual, expected) {
+		t.Errorf("После перезаписи команды %v, ожидалось %v", actual, expected)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(filename))
+	if len(entries) != 1 {
+		t.Errorf("Ожидался один файл в каталоге, найдено %d", len(entries))
+	}
+}
+
+// TestAppendOnlyLogRewriteDirSyncError проверяет, что после переименования журнал
+// пишется в новый файл, даже если сброс каталога вернул ошибку
+func TestAppendOnlyLogRewriteDirSyncError(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "commands.aof")
+	log, _ := OpenAppendOnlyLog(filename, FsyncAlways, "")
+	log.Append("SPUSH a")
+	syncLogDir = func(string) error { return fmt.Errorf("сбой диска") }
+	defer func() { syncLogDir = syncDir }()
+
+	log.StartRewrite([]string{"SPUSH b"})
+	if err := log.WaitRewrite(); err == nil || !strings.Contains(err.Error(), "сбой диска") {
+		t.Errorf("Ожидалась ошибка сброса каталога, получено %v", err)
+	}
+	log.Append("SPUSH c")
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close вернул ошибку: %v", err)
+	}
+
+	expected := []string{"SPUSH b", "SPUSH c"}
+	if actual := replayCommands(t, filename); !slices.Equal(actual, expected) {
+		t.Errorf("После перезаписи команды %v, ожидалось %v", actual, expected)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(filename))
+	if len(entries) != 1 {
+		t.Errorf("Ожидался один файл в каталоге, найдено %d", len(entries))
+	}
+}
+
+// TestAppendOnlyLogMode проверяет запись режима структур и отказ воспроизводить журнал другого режима
+func TestAppendOnlyLogMode(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "commands.aof")
+	mode := aofMode(NewBinarySearchTree(), NewCache(10, PolicyLFU), NewPriorityQueue(MaxHeap))
+	if mode != "tree=bst heap=max cache-policy=lfu" {
+		t.Errorf("aofMode() = %q", mode)
+	}
+	log, _ := OpenAppendOnlyLog(filename, FsyncNo, mode)
+	log.Append("TINSERT 5")
+	log.StartRewrite([]string{"TINSERT 7"})
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close вернул ошибку: %v", err)
+	}
+	if data, _ := os.ReadFile(filename); string(data) != "AOFMODE "+mode+"\nTINSERT 7\n" {
+		t.Errorf("Неверное содержимое журнала после перезаписи: %q", data)
+	}
+
+	same, _ := OpenAppendOnlyLog(filename, FsyncNo, mode)
+	var commands []string
+	if count, err := same.Replay(func(command string) { commands = append(commands, command) }); err != nil || count != 1 || !slices.Equal(commands, []string{"TINSERT 7"}) {
+		t.Errorf("Replay в том же режиме: %v, %d, %v", commands, count, err)
+	}
+	same.Close()
+
+	other, _ := OpenAppendOnlyLog(filename, FsyncNo, aofMode(NewBinaryTree(), NewCache(10, PolicyLFU), NewPriorityQueue(MaxHeap)))
+	defer other.Close()
+	applied := false
+	if _, err := other.Replay(func(string) { applied = true }); err == nil || applied {
+		t.Errorf("Ожидался отказ воспроизводить журнал другого режима: %v, выполнены команды: %v", err, applied)
+	}
+}
+
+// TestCompactCommands проверяет, что сжатые команды воспроизводят состояние всех структур
+func TestCompactCommands(t *testing.T) {
+	type state struct {
+		array      *Array
+		stack      *Stack
+		queue      *Queue
+		singlyList *SinglyLinkedList
+		doublyList *DoublyLinkedList
+		hashTable  *HashTable
+		cbTree     *BinaryTree
+		stringTree *StringTree
+		avlTree    *AVLTree
+		rbTree     *RedBlackTree
+		cache      *Cache
+		pq         *PriorityQueue
+		history    *PersistentTree
+	}
+	newState := func() *state {
+		return &state{NewArray(10), NewStack(), NewQueue(), NewSinglyLinkedList(), NewDoublyLinkedList(),
+			NewHashTable(10), NewBinarySearchTree(), &StringTree{Ordered: true}, NewAVLTree(), NewRedBlackTree(),
+			NewCache(3, PolicyLFU), NewPriorityQueue(MinHeap), NewPersistentTree()}
+	}
+	run := func(s *state, commands []string) {
+		captureStdout(func() {
+			for _, command := range commands {
+				processQuery(command, s.array, s.stack, s.queue, s.singlyList, s.doublyList, s.hashTable, s.cbTree, s.stringTree, s.history, s.avlTree, s.rbTree, s.cache, s.pq, "")
+			}
+		})
+	}
+	compact := func(s *state) []string {
+		return compactCommands(s.array, s.stack, s.queue, s.singlyList, s.doublyList, s.hashTable, s.cbTree, s.stringTree, s.avlTree, s.rbTree, s.cache, s.pq)
+	}
+	dump := func(s *state) string {
+		var parts []string
+		for _, structure := range []Snapshotter{s.array, s.stack, s.queue, s.singlyList, s.doublyList, s.hashTable, s.cbTree} {
+			data, _ := structure.SerializeSnapshot()
+			parts = append(parts, fmt.Sprint(data))
+		}
+		avl, _ := s.avlTree.SerializeText()
+		rb, _ := s.rbTree.SerializeText()
+		keyed, _ := s.stringTree.SerializeText()
+		parts = append(parts, avl, rb, keyed, fmt.Sprint(s.cache.Keys(), s.pq.Items()))
+		for _, key := range s.cache.Keys() {
+			parts = append(parts, fmt.Sprint(key, s.cache.Frequency(key)))
+		}
+		return strings.Join(parts, "\n")
+	}
+
+	original := newState()
+	run(original, []string{
+		"MPUSH 0 a", "MPUSH 0 b", "MPUSH 1 c", "MDEL 0",
+		"SPUSH 1", "SPUSH 2", "SPUSH 3", "SPOP",
+		"QPUSH x", "QPUSH y", "QPOP", "QPUSH z",
+		"LSADDHEAD s1", "LSADDTAIL s2", "LDADDHEAD d1", "LDADDHEAD d0", "LDDELTAIL",
+		"HSET a 1", "HSET k 2", "HSET u 3", "HSET a 4", "HDEL k",
+		"TINSERT 50", "TINSERT 30", "TINSERT 70", "TINSERT 60", "TDEL 50",
+		"AVLINSERT 1", "AVLINSERT 2", "AVLINSERT 3", "AVLINSERT 4", "AVLDEL 2",
+		"RBINSERT 10", "RBINSERT 20", "RBINSERT 30", "RBDEL 10",
+		"CSET k1 v1", "CSET k2 v2", "CGET k1", "CGET k1", "CSET k3 v3", "CSET k4 v4",
+		"PQPUSH a 5", "PQPUSH b 1", "PQPUSH c 3", "PQPOP", "PQDECREASE a 0",
+	})
+	commands := compact(original)
+
+	restored := newState()
+	run(restored, commands)
+	if dump(restored) != dump(original) {
+		t.Errorf("Состояние после сжатых команд отличается:\n%s\nожидалось:\n%s", dump(restored), dump(original))
+	}
+	if !slices.Equal(compact(restored), commands) {
+		t.Errorf("Повторное сжатие дало другой набор команд")
+	}
+
+	// Неполное дерево из TBUILD восстанавливается в той же форме
+	built := newState()
+	built.cbTree = NewBinaryTree()
+	run(built, []string{"TBUILD level [1,null,2,3]"})
+	restoredBuilt := newState()
+	restoredBuilt.cbTree = NewBinaryTree()
+	run(restoredBuilt, compact(built))
+	if shape := restoredBuilt.cbTree.LevelOrderString(); shape != "[1,null,2,3]" {
+		t.Errorf("Дерево из TBUILD восстановлено в форме %s", shape)
+	}
+
+	// Дерево со строковыми ключами восстанавливается вместе с нагрузкой
+	keyed := newState()
+	run(keyed, []string{"TINSERT m", "TINSERT c payload", "TINSERT x"})
+	restoredKeyed := newState()
+	run(restoredKeyed, compact(keyed))
+	if dump(restoredKeyed) != dump(keyed) {
+		t.Errorf("Строковое дерево восстановлено неверно")
+	}
+}
@@ -2,10 +2,10 @@ package main
 
 import (
 	"bufio"
-	"encoding/binary"
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 )
 
 // Array представляет структуру массива
@@ -86,7 +86,7 @@ func (a *Array) Length() int {
 
 // SaveToFile сохраняет массив в файл
 func (a *Array) SaveToFile(filename string) error {
-	file, err := os.Create(filename)
+	file, err := createSnapshotFile(filename)
 	if err != nil {
 		return fmt.Errorf("не удалось создать файл: %v", err)
 	}
@@ -98,12 +98,12 @@ func (a *Array) SaveToFile(filename string) error {
 			return fmt.Errorf("ошибка записи в файл: %v", err)
 		}
 	}
-	return nil
+	return file.Commit()
 }
 
 // LoadFromFile загружает массив из файла
 func (a *Array) LoadFromFile(filename string) error {
-	file, err := os.Open(filename)
+	file, err := openSnapshotFile(filename)
 	if err != nil {
 		return fmt.Errorf("не удалось открыть файл: %v", err)
 	}
@@ -163,52 +163,169 @@ func (a *Array) DeserializeText(data string) error {
 	if err != nil {
 		return fmt.Errorf("ошибка десериализации из текстового формата: %v", err)
 	}
-	a.size = 0
-	for _, value := range temp {
-		if a.size >= a.maxCapacity {
-			break // Прекращаем заполнение, если массив заполнен
-		}
-		a.data[a.size] = value
-		a.size++
+	if len(temp) > a.maxCapacity {
+		return fmt.Errorf("количество элементов %d превышает емкость массива %d", len(temp), a.maxCapacity)
 	}
+	values := make([]string, a.maxCapacity)
+	copy(values, temp)
+	a.data = values
+	a.size = len(temp)
 	return nil
 }
 
 // SerializeBinary сериализует массив в бинарном формате
 func (a *Array) SerializeBinary() ([]byte, error) {
-	var result []byte
-	for i := 0; i < a.size; i++ {
-		// Преобразуем строку в байты и добавляем её длину перед данными
-		strBytes := []byte(a.data[i])
-		length := uint32(len(strBytes))
-		lengthBytes := make([]byte, 4)
-		binary.LittleEndian.PutUint32(lengthBytes, length)
-		result = append(result, lengthBytes...)
-		result = append(result, strBytes...)
+	var buffer bytes.Buffer
+	if err := a.EncodeTo(&buffer); err != nil {
+		return nil, err
 	}
-	return result, nil
+	return buffer.Bytes(), nil
 }
 
-// DeserializeBinary десериализует массив из бинарного формата
+// DeserializeBinary десериализует массив из бинарного формата.
+// Данные без заголовка, их тип не проверяется; снимок с проверкой типа читает DeserializeSnapshot.
 func (a *Array) DeserializeBinary(data []byte) error {
-	a.size = 0
-	offset := 0
-	for offset < len(data) && a.size < a.maxCapacity {
-		// Читаем длину строки
-		if offset+4 > len(data) {
-			return fmt.Errorf("недостаточно данных для чтения длины строки")
+	return a.DecodeFrom(bytes.NewReader(data))
+}
+
+// EncodeTo записывает массив в w в бинарном формате поэлементно
+func (a *Array) EncodeTo(w io.Writer) error {
+	return encodeStrings(w, func(yield func(string) bool) {
+		for i := 0; i < a.size; i++ {
+			if !yield(a.data[i]) {
+				return
+			}
 		}
-		length := binary.LittleEndian.Uint32(data[offset : offset+4])
-		offset += 4
+	})
+}
 
-		// Читаем строку
-		if offset+int(length) > len(data) {
-			return fmt.Errorf("недостаточно данных для чтения строки")
+// DecodeFrom читает массив из r в бинарном формате поэлементно.
+// Данные с числом элементов больше емкости отклоняются; при ошибке массив не меняется.
+func (a *Array) DecodeFrom(r io.Reader) error {
+	data := make([]string, a.maxCapacity)
+	size := 0
+	overflow := false
+	err := decodeStrings(r, func(value string) bool {
+		if size == a.maxCapacity {
+			overflow = true
+			return false
 		}
-		strBytes := data[offset : offset+int(length)]
-		a.data[a.size] = string(strBytes)
-		a.size++
-		offset += int(length)
+		data[size] = value
+		size++
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if overflow {
+		return fmt.Errorf("количество элементов превышает емкость массива %d", a.maxCapacity)
+	}
+	a.data = data
+	a.size = size
+	return nil
+}
+
+// MarshalBinary реализует encoding.BinaryMarshaler
+func (a *Array) MarshalBinary() ([]byte, error) {
+	return a.SerializeBinary()
+}
+
+// UnmarshalBinary реализует encoding.BinaryUnmarshaler
+func (a *Array) UnmarshalBinary(data []byte) error {
+	return a.DeserializeBinary(data)
+}
+
+// MarshalText реализует encoding.TextMarshaler; текстовый формат - JSON
+func (a *Array) MarshalText() ([]byte, error) {
+	text, err := a.SerializeText()
+	return []byte(text), err
+}
+
+// UnmarshalText реализует encoding.TextUnmarshaler
+func (a *Array) UnmarshalText(data []byte) error {
+	return a.DeserializeText(string(data))
+}
+
+// MarshalJSON реализует json.Marshaler
+func (a *Array) MarshalJSON() ([]byte, error) {
+	return a.MarshalText()
+}
+
+// UnmarshalJSON реализует json.Unmarshaler
+func (a *Array) UnmarshalJSON(data []byte) error {
+	return a.UnmarshalText(data)
+}
+
+// portable возвращает переносимое представление массива: массив строк, как в SerializeText
+func (a *Array) portable() any {
+	return portableStrings(func(yield func(string) bool) {
+		for i := 0; i < a.size; i++ {
+			if !yield(a.data[i]) {
+				return
+			}
+		}
+	})
+}
+
+// setPortable восстанавливает массив из переносимого представления; при ошибке массив не меняется
+func (a *Array) setPortable(value any) error {
+	values, err := stringsFromPortable(value)
+	if err != nil {
+		return err
+	}
+	if len(values) > a.maxCapacity {
+		return fmt.Errorf("количество элементов %d превышает емкость массива %d", len(values), a.maxCapacity)
+	}
+	data := make([]string, a.maxCapacity)
+	copy(data, values)
+	a.data = data
+	a.size = len(values)
+	return nil
+}
+
+// SerializeMsgPack сериализует массив в MessagePack
+func (a *Array) SerializeMsgPack() ([]byte, error) {
+	return serializeMsgPack(a)
+}
+
+// DeserializeMsgPack десериализует массив из MessagePack
+func (a *Array) DeserializeMsgPack(data []byte) error {
+	return deserializeMsgPack(a, data)
+}
+
+// SerializeCBOR сериализует массив в CBOR
+func (a *Array) SerializeCBOR() ([]byte, error) {
+	return serializeCBOR(a)
+}
+
+// DeserializeCBOR десериализует массив из CBOR
+func (a *Array) DeserializeCBOR(data []byte) error {
+	return deserializeCBOR(a, data)
+}
+
+// SerializeSnapshot сериализует массив в снимок с заголовком, типом и контрольной суммой
+func (a *Array) SerializeSnapshot() ([]byte, error) {
+	payload, err := a.SerializeBinary()
+	if err != nil {
+		return nil, err
+	}
+	return encodeSnapshot(SnapshotArray, a.Length(), payload), nil
+}
+
+// DeserializeSnapshot восстанавливает массив из снимка. Снимок другой структуры,
+// другой версии или с неверной контрольной суммой отклоняется без изменения данных.
+func (a *Array) DeserializeSnapshot(data []byte) error {
+	count, payload, err := decodeSnapshot(data, SnapshotArray)
+	if err != nil {
+		return err
+	}
+	temp := NewArray(max(a.maxCapacity, count))
+	if err := temp.DeserializeBinary(payload); err != nil {
+		return err
+	}
+	if err := checkSnapshotCount(SnapshotArray, temp.Length(), count); err != nil {
+		return err
 	}
+	*a = *temp
 	return nil
 }
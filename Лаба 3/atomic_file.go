@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// keepBackups включает сохранение предыдущей версии файла с суффиксом .bak при каждой записи
+var keepBackups = false
+
+// backupSuffix - суффикс резервной копии последней удачной версии файла
+const backupSuffix = ".bak"
+
+// AtomicFile - файл, который заменяет целевой только после успешной записи.
+// Данные пишутся во временный файл в том же каталоге; Commit сбрасывает его на диск,
+// переименовывает поверх целевого и сбрасывает каталог. Close без Commit удаляет
+// временный файл, поэтому целевой файл никогда не остается записанным наполовину.
+// Если задан codec, данные собираются в памяти и при Commit записываются
+// сжатыми и зашифрованными (см. createSnapshotFile).
+type AtomicFile struct {
+	*os.File
+	target    string
+	committed bool
+	codec     *SnapshotCodec
+	buffer    bytes.Buffer
+}
+
+// Write записывает данные во временный файл или в буфер для сжатия
+func (f *AtomicFile) Write(data []byte) (int, error) {
+	if f.codec != nil {
+		return f.buffer.Write(data)
+	}
+	return f.File.Write(data)
+}
+
+// WriteString записывает строку во временный файл или в буфер для сжатия
+func (f *AtomicFile) WriteString(text string) (int, error) {
+	if f.codec != nil {
+		return f.buffer.WriteString(text)
+	}
+	return f.File.WriteString(text)
+}
+
+// createAtomicFile создает временный файл для атомарной записи в filename
+func createAtomicFile(filename string) (*AtomicFile, error) {
+	file, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	// Права как у os.Create, а не 0600 временного файла
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return &AtomicFile{File: file, target: filename}, nil
+}
+
+// Commit завершает запись: сбрасывает данные, при необходимости сохраняет
+// предыдущую версию в .bak и атомарно заменяет целевой файл
+func (f *AtomicFile) Commit() error {
+	return f.commit(keepBackups)
+}
+
+// commit завершает запись; backup задает сохранение предыдущей версии
+func (f *AtomicFile) commit(backup bool) error {
+	if f.committed {
+		return nil
+	}
+	if f.codec != nil {
+		data, err := f.codec.Encode(f.buffer.Bytes())
+		if err != nil {
+			return err
+		}
+		if _, err := f.File.Write(data); err != nil {
+			return fmt.Errorf("ошибка записи во временный файл: %v", err)
+		}
+	}
+	if err := f.File.Sync(); err != nil {
+		return fmt.Errorf("ошибка сброса данных на диск: %v", err)
+	}
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.Name())
+		f.committed = true
+		return fmt.Errorf("ошибка закрытия временного файла: %v", err)
+	}
+	f.committed = true
+	if backup {
+		if err := rotateBackup(f.target); err != nil {
+			os.Remove(f.Name())
+			return fmt.Errorf("не удалось сохранить резервную копию: %v", err)
+		}
+	}
+	if err := os.Rename(f.Name(), f.target); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("не удалось заменить файл: %v", err)
+	}
+	if err := syncDir(filepath.Dir(f.target)); err != nil {
+		return fmt.Errorf("ошибка сброса каталога на диск: %v", err)
+	}
+	return nil
+}
+
+// Close отменяет незавершенную запись и удаляет временный файл
+func (f *AtomicFile) Close() error {
+	if f.committed {
+		return nil
+	}
+	f.committed = true
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// rotateBackup делает текущую версию файла резервной копией. Целевой файл
+// остается на месте до переименования, поэтому в любой момент есть целая версия.
+func rotateBackup(filename string) error {
+	backup := filename + backupSuffix
+	if _, err := os.Stat(filename); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := os.Remove(backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Link(filename, backup); err == nil {
+		return nil
+	}
+	// Файловая система без жестких ссылок: копия записывается так же атомарно
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	file, err := createAtomicFile(backup)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	return file.commit(false)
+}
+
+// syncDir сбрасывает на диск запись каталога, чтобы переименование пережило сбой питания
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil && !errors.Is(err, os.ErrInvalid) {
+		return err
+	}
+	return nil
+}
+
+// writeFileAtomic атомарно записывает данные в файл
+func writeFileAtomic(filename string, data []byte) error {
+	file, err := createAtomicFile(filename)
+	if err != nil {
+		return fmt.Errorf("не удалось создать временный файл: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("ошибка записи во временный файл: %v", err)
+	}
+	return file.Commit()
+}
+
+// loadExisting загружает структуру через loadWithRecovery. Если нет ни файла,
+// ни резервной копии, структура остается пустой, как при первом запуске;
+// любая другая ошибка загрузки возвращается.
+func loadExisting(filename string, load func(string) error) error {
+	_, err := os.Stat(filename)
+	_, backupErr := os.Stat(filename + backupSuffix)
+	if os.IsNotExist(err) && os.IsNotExist(backupErr) {
+		return nil
+	}
+	return loadWithRecovery(filename, load)
+}
+
+// loadWithRecovery загружает структуру из файла, а если загрузка не удалась,
+// восстанавливает ее из резервной копии .bak последней удачной версии
+func loadWithRecovery(filename string, load func(string) error) error {
+	err := load(filename)
+	if err == nil {
+		return nil
+	}
+	backup := filename + backupSuffix
+	if _, statErr := os.Stat(backup); statErr != nil {
+		return err
+	}
+	if backupErr := load(backup); backupErr != nil {
+		return fmt.Errorf("%v; резервная копия тоже не загружена: %v", err, backupErr)
+	}
+	fmt.Printf("Файл %s не загружен (%v), восстановлена резервная копия %s\n", filename, err, backup)
+	return nil
+}
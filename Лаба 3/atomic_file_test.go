@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestAtomicFileCommit проверяет, что запись появляется в целевом файле только после Commit
+func TestAtomicFileCommit(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "data.txt")
+	os.WriteFile(filename, []byte("old\n"), 0644)
+
+	file, err := createAtomicFile(filename)
+	if err != nil {
+		t.Fatalf("createAtomicFile вернул ошибку: %v", err)
+	}
+	file.WriteString("new\n")
+	if data, _ := os.ReadFile(filename); string(data) != "old\n" {
+		t.Errorf("Целевой файл изменен до Commit: %q", data)
+	}
+	if err := file.Commit(); err != nil {
+		t.Fatalf("Commit вернул ошибку: %v", err)
+	}
+	file.Close()
+
+	if data, _ := os.ReadFile(filename); string(data) != "new\n" {
+		t.Errorf("Ожидалось содержимое \"new\\n\", получено %q", data)
+	}
+	info, _ := os.Stat(filename)
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("Ожидались права 0644, получено %v", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(filename)); len(entries) != 1 {
+		t.Errorf("Временный файл не удален: найдено %d файлов", len(entries))
+	}
+}
+
+// TestAtomicFileAbort проверяет, что прерванная запись не затрагивает целевой файл
+func TestAtomicFileAbort(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "data.txt")
+	os.WriteFile(filename, []byte("old\n"), 0644)
+
+	file, _ := createAtomicFile(filename)
+	file.WriteString("половина")
+	file.Close()
+
+	if data, _ := os.ReadFile(filename); string(data) != "old\n" {
+		t.Errorf("Целевой файл изменен прерванной записью: %q", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(filename)); len(entries) != 1 {
+		t.Errorf("Временный файл не удален: найдено %d файлов", len(entries))
+	}
+}
+
+// TestSaveToFileAtomic проверяет, что сохранение структур не оставляет временных файлов,
+// а ошибка создания файла не создает целевой файл
+func TestSaveToFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	stack := NewStack()
+	stack.Push("a")
+	tree := NewBinaryTree()
+	tree.Insert(1)
+	savers := map[string]func(string) error{
+		"stack.txt": stack.SaveToFile,
+		"tree.txt":  tree.SaveToFile,
+		"empty.txt": NewBinaryTree().SaveToFile,
+		"pq.txt":    NewPriorityQueue(MinHeap).SaveToFile,
+	}
+	for name, save := range savers {
+		if err := save(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s: SaveToFile вернул ошибку: %v", name, err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != len(savers) {
+		t.Errorf("Ожидалось %d файлов, найдено %d", len(savers), len(entries))
+	}
+
+	missing := filepath.Join(dir, "missing", "data.txt")
+	if err := stack.SaveToFile(missing); err == nil {
+		t.Errorf("Ожидалась ошибка для несуществующего каталога")
+	}
+}
+
+// TestBackupRotation проверяет сохранение предыдущей версии в .bak
+func TestBackupRotation(t *testing.T) {
+	keepBackups = true
+	defer func() { keepBackups = false }()
+	filename := filepath.Join(t.TempDir(), "data.txt")
+
+	for _, content := range []string{"first\n", "second\n", "third\n"} {
+		if err := writeFileAtomic(filename, []byte(content)); err != nil {
+			t.Fatalf("writeFileAtomic вернул ошибку: %v", err)
+		}
+	}
+	if data, _ := os.ReadFile(filename); string(data) != "third\n" {
+		t.Errorf("Неверное содержимое файла: %q", data)
+	}
+	if data, _ := os.ReadFile(filename + backupSuffix); string(data) != "second\n" {
+		t.Errorf("Неверное содержимое резервной копии: %q", data)
+	}
+}
+
+// TestLoadWithRecovery проверяет восстановление из резервной копии при ошибке загрузки
+func TestLoadWithRecovery(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tree.txt")
+	os.WriteFile(filename, []byte("1\nне число\n"), 0644)
+
+	tree := NewBinaryTree()
+	if err := loadWithRecovery(filename, tree.LoadFromFile); err == nil {
+		t.Errorf("Ожидалась ошибка без резервной копии")
+	}
+
+	os.WriteFile(filename+backupSuffix, []byte("5\n3\n"), 0644)
+	output := captureStdout(func() {
+		if err := loadWithRecovery(filename, tree.LoadFromFile); err != nil {
+			t.Errorf("Ожидалось восстановление из резервной копии: %v", err)
+		}
+	})
+	if !strings.Contains(output, "восстановлена резервная копия") {
+		t.Errorf("Нет сообщения о восстановлении: %q", output)
+	}
+	if values := tree.LevelOrderString(); values != "[5,3]" {
+		t.Errorf("Ожидалось дерево [5,3], получено %s", values)
+	}
+
+	os.WriteFile(filename+backupSuffix, []byte("x\n"), 0644)
+	if err := loadWithRecovery(filename, tree.LoadFromFile); err == nil {
+		t.Errorf("Ожидалась ошибка при поврежденной резервной копии")
+	}
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strconv"
+)
+
+// AVLNode представляет узел AVL-дерева
+type AVLNode struct {
+	Digit  int
+	Height int
+	Size   int `json:"-"` // Размер поддерева для порядковых статистик
+	Left   *AVLNode
+	Right  *AVLNode
+}
+
+// AVLTree представляет самобалансирующееся AVL-дерево
+type AVLTree struct {
+	Root           *AVLNode
+	LeftRotations  int `json:"-"` // Количество левых поворотов, для тестов
+	RightRotations int `json:"-"` // Количество правых поворотов, для тестов
+}
+
+// NewAVLTree создает новое AVL-дерево
+func NewAVLTree() *AVLTree {
+	return &AVLTree{}
+}
+
+// avlHeight возвращает высоту узла (0 для nil)
+func avlHeight(node *AVLNode) int {
+	if node == nil {
+		return 0
+	}
+	return node.Height
+}
+
+// updateHeight пересчитывает высоту и размер узла по потомкам
+func (node *AVLNode) updateHeight() {
+	node.Size = node.Left.size() + node.Right.size() + 1
+	left, right := avlHeight(node.Left), avlHeight(node.Right)
+	if left > right {
+		node.Height = left + 1
+	} else {
+		node.Height = right + 1
+	}
+}
+
+// balanceFactor возвращает разность высот левого и правого поддеревьев
+func (node *AVLNode) balanceFactor() int {
+	return avlHeight(node.Left) - avlHeight(node.Right)
+}
+
+// rotateLeft выполняет левый поворот вокруг узла
+func (t *AVLTree) rotateLeft(node *AVLNode) *AVLNode {
+	t.LeftRotations++
+	pivot := node.Right
+	node.Right = pivot.Left
+	pivot.Left = node
+	node.updateHeight()
+	pivot.updateHeight()
+	return pivot
+}
+
+// rotateRight выполняет правый поворот вокруг узла
+func (t *AVLTree) rotateRight(node *AVLNode) *AVLNode {
+	t.RightRotations++
+	pivot := node.Left
+	node.Left = pivot.Right
+	pivot.Right = node
+	node.updateHeight()
+	pivot.updateHeight()
+	return pivot
+}
+
+// rebalance восстанавливает баланс узла после вставки или удаления
+func (t *AVLTree) rebalance(node *AVLNode) *AVLNode {
+	node.updateHeight()
+	switch balance := node.balanceFactor(); {
+	case balance > 1:
+		if node.Left.balanceFactor() < 0 {
+			node.Left = t.rotateLeft(node.Left)
+		}
+		return t.rotateRight(node)
+	case balance < -1:
+		if node.Right.balanceFactor() > 0 {
+			node.Right = t.rotateRight(node.Right)
+		}
+		return t.rotateLeft(node)
+	}
+	return node
+}
+
+// Insert добавляет значение в дерево. Повторяющиеся значения не добавляются.
+func (t *AVLTree) Insert(digit int) {
+	t.Root = t.insert(t.Root, digit)
+}
+
+// insert вспомогательная функция для рекурсивной вставки
+func (t *AVLTree) insert(node *AVLNode, digit int) *AVLNode {
+	if node == nil {
+		return &AVLNode{Digit: digit, Height: 1, Size: 1}
+	}
+	switch {
+	case digit < node.Digit:
+		node.Left = t.insert(node.Left, digit)
+	case digit > node.Digit:
+		node.Right = t.insert(node.Right, digit)
+	default:
+		return node
+	}
+	return t.rebalance(node)
+}
+
+// Delete удаляет значение из дерева и сообщает, было ли оно найдено
+func (t *AVLTree) Delete(digit int) bool {
+	if !t.FindValue(digit) {
+		return false
+	}
+	t.Root = t.delete(t.Root, digit)
+	return true
+}
+
+// delete вспомогательная функция для рекурсивного удаления
+func (t *AVLTree) delete(node *AVLNode, digit int) *AVLNode {
+	if node == nil {
+		return nil
+	}
+	switch {
+	case digit < node.Digit:
+		node.Left = t.delete(node.Left, digit)
+	case digit > node.Digit:
+		node.Right = t.delete(node.Right, digit)
+	default:
+		if node.Left == nil {
+			return node.Right
+		}
+		if node.Right == nil {
+			return node.Left
+		}
+		successor := node.Right
+		for successor.Left != nil {
+			successor = successor.Left
+		}
+		node.Digit = successor.Digit
+		node.Right = t.delete(node.Right, successor.Digit)
+	}
+	return t.rebalance(node)
+}
+
+// FindValue ищет значение в дереве за O(log n)
+func (t *AVLTree) FindValue(value int) bool {
+	current := t.Root
+	for current != nil {
+		switch {
+		case value < current.Digit:
+			current = current.Left
+		case value > current.Digit:
+			current = current.Right
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// Height возвращает высоту дерева
+func (t *AVLTree) Height() int {
+	return avlHeight(t.Root)
+}
+
+// CheckInvariants проверяет порядок ключей, корректность высот и баланс узлов
+func (t *AVLTree) CheckInvariants() error {
+	_, err := t.checkNode(t.Root, nil, nil)
+	return err
+}
+
+// checkNode рекурсивно проверяет инварианты поддерева и возвращает его высоту
+func (t *AVLTree) checkNode(node *AVLNode, low, high *int) (int, error) {
+	if node == nil {
+		return 0, nil
+	}
+	if (low != nil && node.Digit <= *low) || (high != nil && node.Digit >= *high) {
+		return 0, fmt.Errorf("нарушен порядок ключей в узле %d", node.Digit)
+	}
+	left, err := t.checkNode(node.Left, low, &node.Digit)
+	if err != nil {
+		return 0, err
+	}
+	right, err := t.checkNode(node.Right, &node.Digit, high)
+	if err != nil {
+		return 0, err
+	}
+	height := left + 1
+	if right > left {
+		height = right + 1
+	}
+	if node.Height != height {
+		return 0, fmt.Errorf("неверная высота узла %d: %d вместо %d", node.Digit, node.Height, height)
+	}
+	if left-right > 1 || right-left > 1 {
+		return 0, fmt.Errorf("нарушен баланс в узле %d", node.Digit)
+	}
+	if node.Size != node.Left.size()+node.Right.size()+1 {
+		return 0, fmt.Errorf("неверный размер поддерева узла %d", node.Digit)
+	}
+	return height, nil
+}
+
+// updateAVLSizes пересчитывает размеры поддеревьев, не сохраняемые в JSON
+func updateAVLSizes(node *AVLNode) int {
+	if node == nil {
+		return 0
+	}
+	node.Size = updateAVLSizes(node.Left) + updateAVLSizes(node.Right) + 1
+	return node.Size
+}
+
+// Display печатает дерево, повернутое на 90 градусов
+func (t *AVLTree) Display() {
+	if t.Root == nil {
+		fmt.Println("Дерево пустое.")
+		return
+	}
+	t.printNode(t.Root, 0)
+}
+
+// printNode вспомогательная функция для печати дерева
+func (t *AVLTree) printNode(node *AVLNode, level int) {
+	if node != nil {
+		t.printNode(node.Right, level+1)
+		for i := 0; i < level; i++ {
+			fmt.Print("   ")
+		}
+		fmt.Println(node.Digit)
+		t.printNode(node.Left, level+1)
+	}
+}
+
+// Clear удаляет все узлы из дерева
+func (t *AVLTree) Clear() {
+	t.Root = nil
+}
+
+// levelOrder возвращает итератор по значениям узлов в порядке обхода по уровням
+func (t *AVLTree) levelOrder() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		if t.Root == nil {
+			return
+		}
+		queue := []*AVLNode{t.Root}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			if !yield(current.Digit) {
+				return
+			}
+			if current.Left != nil {
+				queue = append(queue, current.Left)
+			}
+			if current.Right != nil {
+				queue = append(queue, current.Right)
+			}
+		}
+	}
+}
+
+// levelOrderValues возвращает значения узлов в порядке обхода по уровням
+func (t *AVLTree) levelOrderValues() []int {
+	return slices.Collect(t.levelOrder())
+}
+
+// LoadFromFile загружает дерево из файла
+func (t *AVLTree) LoadFromFile(file string) error {
+	t.Clear()
+	f, err := openSnapshotFile(file)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть файл: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		value, err := strconv.Atoi(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("недопустимое значение в файле: %v", err)
+		}
+		t.Insert(value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения файла: %v", err)
+	}
+	return nil
+}
+
+// SaveToFile сохраняет дерево в файл по уровням
+func (t *AVLTree) SaveToFile(file string) error {
+	f, err := createSnapshotFile(file)
+	if err != nil {
+		return fmt.Errorf("не удалось создать файл: %v", err)
+	}
+	defer f.Close()
+
+	for value := range t.levelOrder() {
+		if _, err := f.WriteString(fmt.Sprintf("%d\n", value)); err != nil {
+			return fmt.Errorf("ошибка записи в файл: %v", err)
+		}
+	}
+	return f.Commit()
+}
+
+// avlTreeJSON - поля дерева без методов; через этот тип SerializeText и
+// DeserializeText обходят MarshalJSON и UnmarshalJSON самого дерева
+type avlTreeJSON AVLTree
+
+// SerializeText сериализует дерево в текстовый формат (JSON)
+func (t *AVLTree) SerializeText() (string, error) {
+	data, err := json.Marshal((*avlTreeJSON)(t))
+	if err != nil {
+		return "", fmt.Errorf("ошибка сериализации в текстовый формат: %v", err)
+	}
+	return string(data), nil
+}
+
+// DeserializeText десериализует дерево из текстового формата (JSON) и проверяет инварианты
+func (t *AVLTree) DeserializeText(data string) error {
+	var temp AVLTree
+	if err := json.Unmarshal([]byte(data), (*avlTreeJSON)(&temp)); err != nil {
+		return fmt.Errorf("ошибка десериализации из текстового формата: %v", err)
+	}
+	updateAVLSizes(temp.Root)
+	if err := temp.CheckInvariants(); err != nil {
+		return fmt.Errorf("некорректное AVL-дерево: %v", err)
+	}
+	t.Root = temp.Root
+	return nil
+}
+
+// SerializeBinary сериализует дерево в бинарный формат (значения по уровням, 4 байта со знаком).
+// Значение, не помещающееся в 4 байта, возвращает ошибку, а не записывается усеченным.
+func (t *AVLTree) SerializeBinary() ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := t.EncodeTo(&buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// DeserializeBinary десериализует дерево из бинарного формата.
+// Повторяющиеся значения отклоняются; при ошибке дерево не меняется.
+func (t *AVLTree) DeserializeBinary(data []byte) error {
+	return t.DecodeFrom(bytes.NewReader(data))
+}
+
+// EncodeTo записывает дерево в w в бинарном формате по одному узлу
+func (t *AVLTree) EncodeTo(w io.Writer) error {
+	return encodeInt32s(w, t.levelOrder())
+}
+
+// DecodeFrom читает дерево из r в бинарном формате, вставляя значения по мере чтения.
+// Повторяющиеся значения отклоняются; при ошибке дерево не меняется.
+func (t *AVLTree) DecodeFrom(r io.Reader) error {
+	temp := NewAVLTree()
+	err := decodeInt32s(r, func(value int) error {
+		if temp.FindValue(value) {
+			return fmt.Errorf("значение %d повторяется", value)
+		}
+		temp.Insert(value)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	t.Root = temp.Root
+	return nil
+}
+
+// MarshalBinary реализует encoding.BinaryMarshaler
+func (t *AVLTree) MarshalBinary() ([]byte, error) {
+	return t.SerializeBinary()
+}
+
+// UnmarshalBinary реализует encoding.BinaryUnmarshaler
+func (t *AVLTree) UnmarshalBinary(data []byte) error {
+	return t.DeserializeBinary(data)
+}
+
+// MarshalText реализует encoding.TextMarshaler; текстовый формат - JSON
+func (t *AVLTree) MarshalText() ([]byte, error) {
+	text, err := t.SerializeText()
+	return []byte(text), err
+}
+
+// UnmarshalText реализует encoding.TextUnmarshaler
+func (t *AVLTree) UnmarshalText(data []byte) error {
+	return t.DeserializeText(string(data))
+}
+
+// MarshalJSON реализует json.Marshaler
+func (t *AVLTree) MarshalJSON() ([]byte, error) {
+	return t.MarshalText()
+}
+
+// UnmarshalJSON реализует json.Unmarshaler
+func (t *AVLTree) UnmarshalJSON(data []byte) error {
+	return t.UnmarshalText(data)
+}
+
+// portable возвращает переносимое представление дерева: значения в порядке обхода по уровням
+func (t *AVLTree) portable() any {
+	return portableInts(t.levelOrderValues())
+}
+
+// setPortable восстанавливает дерево из переносимого представления.
+// Повторяющиеся значения отклоняются; при ошибке дерево не меняется.
+func (t *AVLTree) setPortable(value any) error {
+	values, err := intsFromPortable(value)
+	if err != nil {
+		return err
+	}
+	temp := NewAVLTree()
+	for _, value := range values {
+		if temp.FindValue(value) {
+			return fmt.Errorf("значение %d повторяется", value)
+		}
+		temp.Insert(value)
+	}
+	t.Root = temp.Root
+	return nil
+}
+
+// SerializeMsgPack сериализует дерево в MessagePack
+func (t *AVLTree) SerializeMsgPack() ([]byte, error) {
+	return serializeMsgPack(t)
+}
+
+// DeserializeMsgPack десериализует дерево из MessagePack
+func (t *AVLTree) DeserializeMsgPack(data []byte) error {
+	return deserializeMsgPack(t, data)
+}
+
+// SerializeCBOR сериализует дерево в CBOR
+func (t *AVLTree) SerializeCBOR() ([]byte, error) {
+	return serializeCBOR(t)
+}
+
+// DeserializeCBOR десериализует дерево из CBOR
+func (t *AVLTree) DeserializeCBOR(data []byte) error {
+	return deserializeCBOR(t, data)
+}
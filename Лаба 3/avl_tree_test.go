@@ -0,0 +1,191 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// avlInOrder собирает значения AVL-дерева в порядке возрастания
+func avlInOrder(node *AVLNode, values []int) []int {
+	if node == nil {
+		return values
+	}
+	values = avlInOrder(node.Left, values)
+	values = append(values, node.Digit)
+	return avlInOrder(node.Right, values)
+}
+
+// TestAVLRotations проверяет, что каждый из четырех случаев дисбаланса выполняет нужные повороты
+func TestAVLRotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		values      []int
+		left, right int
+		root        int
+	}{
+		{"RR", []int{1, 2, 3}, 1, 0, 2},
+		{"LL", []int{3, 2, 1}, 0, 1, 2},
+		{"LR", []int{3, 1, 2}, 1, 1, 2},
+		{"RL", []int{1, 3, 2}, 1, 1, 2},
+	}
+	for _, tt := range tests {
+		tree := NewAVLTree()
+		for _, v := range tt.values {
+			tree.Insert(v)
+		}
+		if tree.LeftRotations != tt.left || tree.RightRotations != tt.right {
+			t.Errorf("%s: rotations = %d/%d; want %d/%d", tt.name, tree.LeftRotations, tree.RightRotations, tt.left, tt.right)
+		}
+		if tree.Root.Digit != tt.root || tree.Height() != 2 {
+			t.Errorf("%s: root = %d, height = %d; want %d, 2", tt.name, tree.Root.Digit, tree.Height(), tt.root)
+		}
+	}
+}
+
+// TestAVLSortedInput проверяет, что отсортированный ввод не вырождает дерево
+func TestAVLSortedInput(t *testing.T) {
+	tree := NewAVLTree()
+	for i := 1; i <= 1023; i++ {
+		tree.Insert(i)
+	}
+	if tree.Height() != 10 {
+		t.Errorf("Height() = %d; want 10 for 1023 sorted values", tree.Height())
+	}
+	if err := tree.CheckInvariants(); err != nil {
+		t.Errorf("CheckInvariants() = %v", err)
+	}
+}
+
+// TestAVLInsertDeleteRandom проверяет инварианты после случайных вставок и удалений
+func TestAVLInsertDeleteRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tree := NewAVLTree()
+	present := map[int]bool{}
+	for i := 0; i < 2000; i++ {
+		v := rng.Intn(500)
+		if rng.Intn(3) == 0 {
+			if tree.Delete(v) != present[v] {
+				t.Fatalf("Delete(%d) disagrees with reference set", v)
+			}
+			delete(present, v)
+		} else {
+			tree.Insert(v)
+			present[v] = true
+		}
+		if err := tree.CheckInvariants(); err != nil {
+			t.Fatalf("step %d: CheckInvariants() = %v", i, err)
+		}
+	}
+
+	var expected []int
+	for v := range present {
+		expected = append(expected, v)
+	}
+	sort.Ints(expected)
+	if got := avlInOrder(tree.Root, nil); !reflect.DeepEqual(got, expected) {
+		t.Errorf("in-order values differ from reference set")
+	}
+	for v := 0; v < 500; v++ {
+		if tree.FindValue(v) != present[v] {
+			t.Errorf("FindValue(%d) = %v; want %v", v, !present[v], present[v])
+		}
+	}
+}
+
+// TestAVLCheckInvariantsDetectsErrors проверяет обнаружение нарушенных инвариантов
+func TestAVLCheckInvariantsDetectsErrors(t *testing.T) {
+	unordered := &AVLTree{Root: &AVLNode{Digit: 5, Height: 2, Left: &AVLNode{Digit: 7, Height: 1}}}
+	if unordered.CheckInvariants() == nil {
+		t.Errorf("CheckInvariants() = nil; want order error")
+	}
+	wrongHeight := &AVLTree{Root: &AVLNode{Digit: 5, Height: 3}}
+	if wrongHeight.CheckInvariants() == nil {
+		t.Errorf("CheckInvariants() = nil; want height error")
+	}
+	unbalanced := &AVLTree{Root: &AVLNode{Digit: 1, Height: 3, Right: &AVLNode{Digit: 2, Height: 2, Right: &AVLNode{Digit: 3, Height: 1}}}}
+	if unbalanced.CheckInvariants() == nil {
+		t.Errorf("CheckInvariants() = nil; want balance error")
+	}
+}
+
+// TestAVLSaveLoadSerialize проверяет сохранение, загрузку и сериализацию AVL-дерева
+func TestAVLSaveLoadSerialize(t *testing.T) {
+	tree := NewAVLTree()
+	for _, v := range []int{50, 20, 70, -10, 30, 60, 80, 25} {
+		tree.Insert(v)
+	}
+	expected := avlInOrder(tree.Root, nil)
+
+	filename := "test_avl.txt"
+	if err := tree.SaveToFile(filename); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	defer os.Remove(filename)
+	loaded := NewAVLTree()
+	if err := loaded.LoadFromFile(filename); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if got := avlInOrder(loaded.Root, nil); !reflect.DeepEqual(got, expected) {
+		t.Errorf("LoadFromFile() values = %v; want %v", got, expected)
+	}
+	if err := loaded.LoadFromFile("nonexistent_avl.txt"); err == nil {
+		t.Errorf("LoadFromFile() error = nil; want error for missing file")
+	}
+
+	text, err := tree.SerializeText()
+	if err != nil {
+		t.Fatalf("SerializeText() error = %v", err)
+	}
+	fromText := NewAVLTree()
+	if err := fromText.DeserializeText(text); err != nil {
+		t.Fatalf("DeserializeText() error = %v", err)
+	}
+	if got := avlInOrder(fromText.Root, nil); !reflect.DeepEqual(got, expected) {
+		t.Errorf("DeserializeText() values = %v; want %v", got, expected)
+	}
+	if err := fromText.DeserializeText(`{"Root":{"Digit":1,"Height":5}}`); err == nil {
+		t.Errorf("DeserializeText() error = nil; want invariant error")
+	}
+	if err := fromText.DeserializeText(`{"Root":`); err == nil {
+		t.Errorf("DeserializeText() error = nil; want syntax error")
+	}
+
+	data, err := tree.SerializeBinary()
+	if err != nil {
+		t.Fatalf("SerializeBinary() error = %v", err)
+	}
+	fromBinary := NewAVLTree()
+	if err := fromBinary.DeserializeBinary(data); err != nil {
+		t.Fatalf("DeserializeBinary() error = %v", err)
+	}
+	if got := avlInOrder(fromBinary.Root, nil); !reflect.DeepEqual(got, expected) {
+		t.Errorf("DeserializeBinary() values = %v; want %v", got, expected)
+	}
+	if err := fromBinary.DeserializeBinary([]byte{1, 2, 3}); err == nil {
+		t.Errorf("DeserializeBinary() error = nil; want error for truncated data")
+	}
+
+	large := NewAVLTree()
+	large.Insert(1)
+	large.Insert(1 << 40)
+	if _, err := large.SerializeBinary(); err == nil {
+		t.Errorf("SerializeBinary() error = nil; want error for value out of int32 range")
+	}
+}
+
+// TestAVLDisplay проверяет печать AVL-дерева
+func TestAVLDisplay(t *testing.T) {
+	tree := NewAVLTree()
+	if output := captureStdout(tree.Display); output != "Дерево пустое.\n" {
+		t.Errorf("Display() = %q", output)
+	}
+	tree.Insert(2)
+	tree.Insert(1)
+	tree.Insert(3)
+	if output := captureStdout(tree.Display); output != "   3\n2\n   1\n" {
+		t.Errorf("Display() = %q", output)
+	}
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math/bits"
 	"os"
 	"strconv"
 )
@@ -12,6 +15,7 @@ import (
 // TreeNode представляет узел в бинарном дереве
 type TreeNode struct {
 	Digit int
+	Size  int `json:"-"` // Размер поддерева, поддерживается в упорядоченном режиме
 	Left  *TreeNode
 	Right *TreeNode
 }
@@ -67,9 +71,18 @@ func (q *QueueTree) IsEmpty() bool {
 	return q.Front == nil
 }
 
-// BinaryTree представляет структуру бинарного дерева
+// BinaryTree представляет структуру бинарного дерева.
+// По умолчанию узлы заполняются по уровням (полное дерево),
+// в упорядоченном режиме дерево является деревом поиска.
 type BinaryTree struct {
-	Root *TreeNode
+	Root    *TreeNode
+	Ordered bool `json:",omitempty"`
+
+	// Результат проверки полноты для корня shapeRoot. Вставка и удаление
+	// по уровням сохраняют полноту, поэтому положительный результат остается
+	// верным до замены корня; отрицательный сбрасывается при каждом изменении.
+	shapeRoot     *TreeNode
+	shapeComplete bool
 }
 
 // NewBinaryTree создает новое бинарное дерево
@@ -77,8 +90,18 @@ func NewBinaryTree() *BinaryTree {
 	return &BinaryTree{}
 }
 
+// NewBinarySearchTree создает новое бинарное дерево поиска
+func NewBinarySearchTree() *BinaryTree {
+	return &BinaryTree{Ordered: true}
+}
+
 // Insert добавляет новый узел в бинарное дерево
 func (bt *BinaryTree) Insert(digit int) {
+	if bt.Ordered {
+		bt.insertOrdered(digit)
+		return
+	}
+	bt.shapeChanged()
 	newNode := &TreeNode{Digit: digit}
 	if bt.Root == nil {
 		bt.Root = newNode
@@ -140,11 +163,50 @@ func (bt *BinaryTree) IsComplete() bool {
 	return true
 }
 
+// insertOrdered вставляет значение с сохранением порядка дерева поиска.
+// Повторяющиеся значения не добавляются.
+func (bt *BinaryTree) insertOrdered(digit int) {
+	bt.ensureSizes()
+	var path []*TreeNode
+	link := &bt.Root
+	for *link != nil {
+		path = append(path, *link)
+		switch {
+		case digit < (*link).Digit:
+			link = &(*link).Left
+		case digit > (*link).Digit:
+			link = &(*link).Right
+		default:
+			return
+		}
+	}
+	*link = &TreeNode{Digit: digit, Size: 1}
+	for _, node := range path {
+		node.Size++
+	}
+}
+
 // FindValue ищет значение в бинарном дереве
 func (bt *BinaryTree) FindValue(value int) bool {
+	if bt.Ordered {
+		return bt.searchOrdered(value) != nil
+	}
 	return bt.findValue(bt.Root, value)
 }
 
+// searchOrdered ищет узел в дереве поиска за O(h)
+func (bt *BinaryTree) searchOrdered(value int) *TreeNode {
+	current := bt.Root
+	for current != nil && current.Digit != value {
+		if value < current.Digit {
+			current = current.Left
+		} else {
+			current = current.Right
+		}
+	}
+	return current
+}
+
 // findValue вспомогательная функция для поиска значения в бинарном дереве
 func (bt *BinaryTree) findValue(current *TreeNode, value int) bool {
 	if current == nil {
@@ -168,18 +230,309 @@ func (bt *BinaryTree) FindIndex(index int) {
 		return
 	}
 
+	value, err := bt.At(index)
+	if err != nil {
+		fmt.Println("Значение не найдено.")
+		return
+	}
+	fmt.Println("Значение:", value)
+}
+
+// At возвращает значение по индексу в порядке обхода по уровням.
+// В полном дереве узел находится по двоичной записи номера index+1: старшая
+// единица соответствует корню, каждый следующий бит - переходу влево (0) или
+// вправо (1). Дерево, построенное TBUILD или загруженное из файла, может быть
+// неполным, и в нем, как и в упорядоченном режиме, выполняется обход по уровням.
+// Полнота проверяется один раз и запоминается до замены корня, поэтому в
+// полном дереве доступ по индексу занимает O(log n), в остальных случаях O(n).
+func (bt *BinaryTree) At(index int) (int, error) {
+	node, err := bt.nodeAt(index)
+	if err != nil {
+		return 0, err
+	}
+	return node.Digit, nil
+}
+
+// Set заменяет значение по индексу в порядке обхода по уровням.
+// В упорядоченном режиме новое значение должно сохранять порядок дерева поиска.
+func (bt *BinaryTree) Set(index int, value int) error {
+	node, err := bt.nodeAt(index)
+	if err != nil {
+		return err
+	}
+	if bt.Ordered && node.Digit != value {
+		if prev, err := bt.Predecessor(node.Digit); err == nil && value <= prev {
+			return fmt.Errorf("значение %d нарушает порядок дерева поиска", value)
+		}
+		if next, err := bt.Successor(node.Digit); err == nil && value >= next {
+			return fmt.Errorf("значение %d нарушает порядок дерева поиска", value)
+		}
+	}
+	node.Digit = value
+	return nil
+}
+
+// nodeAt возвращает узел по индексу в порядке обхода по уровням
+func (bt *BinaryTree) nodeAt(index int) (*TreeNode, error) {
+	if index < 0 {
+		return nil, fmt.Errorf("неверный индекс: %d", index)
+	}
+	if bt.Root == nil {
+		return nil, fmt.Errorf("дерево пустое")
+	}
+
+	if !bt.Ordered && bt.knownComplete() {
+		position := uint(index) + 1
+		bit := bits.Len(position) - 2
+		current := bt.Root
+		for ; bit >= 0 && current != nil; bit-- {
+			if position&(1<<bit) == 0 {
+				current = current.Left
+			} else {
+				current = current.Right
+			}
+		}
+		if current == nil {
+			return nil, fmt.Errorf("индекс %d вне диапазона", index)
+		}
+		return current, nil
+	}
+
 	queue := NewQueueTree()
 	queue.Enqueue(bt.Root)
-	currentIndex := 0
+	for currentIndex := 0; !queue.IsEmpty(); currentIndex++ {
+		current := queue.Dequeue()
+		if currentIndex == index {
+			return current, nil
+		}
+		if current.Left != nil {
+			queue.Enqueue(current.Left)
+		}
+		if current.Right != nil {
+			queue.Enqueue(current.Right)
+		}
+	}
+	return nil, fmt.Errorf("индекс %d вне диапазона", index)
+}
+
+// knownComplete сообщает, является ли дерево полным, проверяя его только
+// после замены корня или изменения неполного дерева
+func (bt *BinaryTree) knownComplete() bool {
+	if bt.shapeRoot != bt.Root {
+		bt.shapeRoot, bt.shapeComplete = bt.Root, bt.IsComplete()
+	}
+	return bt.shapeComplete
+}
+
+// shapeChanged забывает результат проверки неполного дерева перед изменением его формы
+func (bt *BinaryTree) shapeChanged() {
+	if !bt.shapeComplete {
+		bt.shapeRoot = nil
+	}
+}
+
+// Delete удаляет значение из дерева и сообщает, было ли оно найдено.
+// В режиме по уровням значение заменяется самым глубоким правым узлом,
+// поэтому полное дерево остается полным; в упорядоченном режиме выполняется
+// удаление из дерева поиска.
+func (bt *BinaryTree) Delete(value int) bool {
+	if bt.Ordered {
+		return bt.deleteOrdered(value)
+	}
+	return bt.deleteLevelOrder(value)
+}
 
+// deleteLevelOrder удаляет значение с заменой на последний узел в порядке обхода по уровням
+func (bt *BinaryTree) deleteLevelOrder(value int) bool {
+	if bt.Root == nil {
+		return false
+	}
+	bt.shapeChanged()
+
+	var target, last, lastParent *TreeNode
+	queue := NewQueueTree()
+	queue.Enqueue(bt.Root)
 	for !queue.IsEmpty() {
 		current := queue.Dequeue()
-		if currentIndex == index {
-			fmt.Println("Значение:", current.Digit)
-			return
+		if target == nil && current.Digit == value {
+			target = current
+		}
+		if current.Left != nil {
+			lastParent = current
+			queue.Enqueue(current.Left)
+		}
+		if current.Right != nil {
+			lastParent = current
+			queue.Enqueue(current.Right)
+		}
+		last = current
+	}
+	if target == nil {
+		return false
+	}
+
+	target.Digit = last.Digit
+	switch {
+	case lastParent == nil:
+		bt.Root = nil
+	case lastParent.Right == last:
+		lastParent.Right = nil
+	default:
+		lastParent.Left = nil
+	}
+	return true
+}
+
+// deleteOrdered удаляет значение из дерева поиска
+func (bt *BinaryTree) deleteOrdered(value int) bool {
+	bt.ensureSizes()
+	var path []*TreeNode // Узлы, размер поддерева которых уменьшится
+	link := &bt.Root
+	for *link != nil && (*link).Digit != value {
+		path = append(path, *link)
+		if value < (*link).Digit {
+			link = &(*link).Left
+		} else {
+			link = &(*link).Right
+		}
+	}
+	node := *link
+	if node == nil {
+		return false
+	}
+
+	switch {
+	case node.Left == nil:
+		*link = node.Right
+	case node.Right == nil:
+		*link = node.Left
+	default:
+		// Заменяем значение наименьшим из правого поддерева и удаляем его узел
+		path = append(path, node)
+		successorLink := &node.Right
+		for (*successorLink).Left != nil {
+			path = append(path, *successorLink)
+			successorLink = &(*successorLink).Left
+		}
+		successor := *successorLink
+		node.Digit = successor.Digit
+		*successorLink = successor.Right
+	}
+	for _, ancestor := range path {
+		ancestor.Size--
+	}
+	return true
+}
+
+// Min возвращает наименьшее значение в дереве
+func (bt *BinaryTree) Min() (int, error) {
+	if bt.Root == nil {
+		return 0, fmt.Errorf("дерево пустое")
+	}
+	if bt.Ordered {
+		current := bt.Root
+		for current.Left != nil {
+			current = current.Left
+		}
+		return current.Digit, nil
+	}
+	values := bt.levelOrderValues()
+	smallest := values[0]
+	for _, v := range values[1:] {
+		if v < smallest {
+			smallest = v
+		}
+	}
+	return smallest, nil
+}
+
+// Max возвращает наибольшее значение в дереве
+func (bt *BinaryTree) Max() (int, error) {
+	if bt.Root == nil {
+		return 0, fmt.Errorf("дерево пустое")
+	}
+	if bt.Ordered {
+		current := bt.Root
+		for current.Right != nil {
+			current = current.Right
 		}
-		currentIndex++
+		return current.Digit, nil
+	}
+	values := bt.levelOrderValues()
+	largest := values[0]
+	for _, v := range values[1:] {
+		if v > largest {
+			largest = v
+		}
+	}
+	return largest, nil
+}
+
+// Successor возвращает наименьшее значение в дереве, большее value
+func (bt *BinaryTree) Successor(value int) (int, error) {
+	found := false
+	result := 0
+	if bt.Ordered {
+		current := bt.Root
+		for current != nil {
+			if current.Digit > value {
+				result, found = current.Digit, true
+				current = current.Left
+			} else {
+				current = current.Right
+			}
+		}
+	} else {
+		for _, v := range bt.levelOrderValues() {
+			if v > value && (!found || v < result) {
+				result, found = v, true
+			}
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("нет значения больше %d", value)
+	}
+	return result, nil
+}
+
+// Predecessor возвращает наибольшее значение в дереве, меньшее value
+func (bt *BinaryTree) Predecessor(value int) (int, error) {
+	found := false
+	result := 0
+	if bt.Ordered {
+		current := bt.Root
+		for current != nil {
+			if current.Digit < value {
+				result, found = current.Digit, true
+				current = current.Right
+			} else {
+				current = current.Left
+			}
+		}
+	} else {
+		for _, v := range bt.levelOrderValues() {
+			if v < value && (!found || v > result) {
+				result, found = v, true
+			}
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("нет значения меньше %d", value)
+	}
+	return result, nil
+}
 
+// levelOrderValues возвращает значения узлов в порядке обхода по уровням
+func (bt *BinaryTree) levelOrderValues() []int {
+	var values []int
+	if bt.Root == nil {
+		return values
+	}
+	queue := NewQueueTree()
+	queue.Enqueue(bt.Root)
+	for !queue.IsEmpty() {
+		current := queue.Dequeue()
+		values = append(values, current.Digit)
 		if current.Left != nil {
 			queue.Enqueue(current.Left)
 		}
@@ -187,7 +540,7 @@ func (bt *BinaryTree) FindIndex(index int) {
 			queue.Enqueue(current.Right)
 		}
 	}
-	fmt.Println("Значение не найдено.")
+	return values
 }
 
 // Display печатает бинарное дерево
@@ -196,18 +549,18 @@ func (bt *BinaryTree) Display() {
 		fmt.Println("Дерево пустое.")
 		return
 	}
-	bt.printCBT(bt.Root, 0)
+	bt.printCBT(os.Stdout, bt.Root, 0)
 }
 
 // printCBT вспомогательная функция для печати бинарного дерева
-func (bt *BinaryTree) printCBT(current *TreeNode, level int) {
+func (bt *BinaryTree) printCBT(w io.Writer, current *TreeNode, level int) {
 	if current != nil {
-		bt.printCBT(current.Right, level+1)
+		bt.printCBT(w, current.Right, level+1)
 		for i := 0; i < level; i++ {
-			fmt.Print("   ")
+			fmt.Fprint(w, "   ")
 		}
-		fmt.Println(current.Digit)
-		bt.printCBT(current.Left, level+1)
+		fmt.Fprintln(w, current.Digit)
+		bt.printCBT(w, current.Left, level+1)
 	}
 }
 
@@ -227,158 +580,394 @@ func (bt *BinaryTree) clear(node *TreeNode) {
 	}
 }
 
-// LoadFromFile загружает бинарное дерево из файла
+// LoadFromFile загружает бинарное дерево из файла: значения по уровням, по одному
+// в строке; null обозначает пропуск в неполном дереве (см. levelOrderLines)
 func (bt *BinaryTree) LoadFromFile(file string) error {
 	bt.Clear()
-	f, err := os.Open(file)
+	f, err := openSnapshotFile(file)
 	if err != nil {
 		return fmt.Errorf("не удалось открыть файл: %v", err)
 	}
 	defer f.Close()
 
+	var builder levelOrderBuilder
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
-		value, err := strconv.Atoi(scanner.Text())
-		if err != nil {
+		var value *int
+		if scanner.Text() != "null" {
+			digit, err := strconv.Atoi(scanner.Text())
+			if err != nil {
+				return fmt.Errorf("недопустимое значение в файле: %v", err)
+			}
+			value = &digit
+		}
+		if err := builder.add(value); err != nil {
 			return fmt.Errorf("недопустимое значение в файле: %v", err)
 		}
-		bt.Insert(value)
 	}
 
 	if err := scanner.Err(); err != nil {
 		return fmt.Errorf("ошибка чтения файла: %v", err)
 	}
+	bt.Root = builder.tree(bt.Ordered).Root
 	return nil
 }
 
 // SaveToFile сохраняет бинарное дерево в файл
 func (bt *BinaryTree) SaveToFile(file string) error {
-	f, err := os.Create(file)
+	f, err := createSnapshotFile(file)
 	if err != nil {
 		return fmt.Errorf("не удалось создать файл: %v", err)
 	}
 	defer f.Close()
 
-	if bt.Root == nil {
-		return nil // Пустое дерево, файл будет пустым
-	}
-
-	queue := NewQueueTree()
-	queue.Enqueue(bt.Root)
-	for !queue.IsEmpty() {
-		current := queue.Dequeue()
-
-		if _, err := f.WriteString(fmt.Sprintf("%d\n", current.Digit)); err != nil {
+	for line := range bt.levelOrderLines() {
+		if _, err := f.WriteString(line + "\n"); err != nil {
 			return fmt.Errorf("ошибка записи в файл: %v", err)
 		}
-
-		if current.Left != nil {
-			queue.Enqueue(current.Left)
-		}
-		if current.Right != nil {
-			queue.Enqueue(current.Right)
-		}
 	}
-	return nil
+	return f.Commit()
 }
 
+// binaryTreeJSON - поля BinaryTree без методов; через этот тип SerializeText и
+// DeserializeText обходят MarshalJSON и UnmarshalJSON самого дерева
+type binaryTreeJSON BinaryTree
+
 // SerializeText сериализует бинарное дерево в текстовый формат (JSON)
 func (bt *BinaryTree) SerializeText() (string, error) {
-	data, err := json.Marshal(bt)
+	data, err := json.Marshal((*binaryTreeJSON)(bt))
 	if err != nil {
 		return "", fmt.Errorf("ошибка сериализации в текстовый формат: %v", err)
 	}
 	return string(data), nil
 }
 
-// DeserializeText десериализует бинарное дерево из текстового формата (JSON)
+// DeserializeText десериализует бинарное дерево из текстового формата (JSON).
+// Упорядоченное дерево, нарушающее порядок дерева поиска, отклоняется;
+// при ошибке дерево не меняется.
 func (bt *BinaryTree) DeserializeText(data string) error {
-	err := json.Unmarshal([]byte(data), bt)
+	var temp BinaryTree
+	err := json.Unmarshal([]byte(data), (*binaryTreeJSON)(&temp))
 	if err != nil {
 		return fmt.Errorf("ошибка десериализации из текстового формата: %v", err)
 	}
+	if temp.Ordered && !temp.IsBST() {
+		return fmt.Errorf("упорядоченное дерево нарушает порядок дерева поиска")
+	}
+	if temp.Ordered {
+		temp.updateSizes()
+	}
+	*bt = temp
 	return nil
 }
 
-// SerializeBinary сериализует бинарное дерево в бинарный формат
-func (bt *BinaryTree) SerializeBinary() ([]byte, error) {
-	var result []byte
-	queue := NewQueueTree()
-	queue.Enqueue(bt.Root)
-
-	for !queue.IsEmpty() {
-		current := queue.Dequeue()
-		if current == nil {
-			result = append(result, 0) // Маркер для nil
-			continue
-		}
+// Версия бинарного формата дерева и флаги заголовка
+const (
+	binaryTreeFormatVersion = 1
+	binaryTreeFlagOrdered   = 1 << 0
+)
 
-		// Записываем значение узла
-		valueBytes := make([]byte, 4)
-		binary.LittleEndian.PutUint32(valueBytes, uint32(current.Digit))
-		result = append(result, valueBytes...)
+// Флаги наличия потомков узла в бинарном формате
+const (
+	binaryTreeHasLeft  = 1 << 0
+	binaryTreeHasRight = 1 << 1
+)
 
-		queue.Enqueue(current.Left)
-		queue.Enqueue(current.Right)
+// SerializeBinary сериализует бинарное дерево в бинарный формат.
+// Формат: байт версии, байт флагов (упорядоченный режим), количество узлов
+// (беззнаковый varint), затем узлы в порядке обхода по уровням: значение
+// (знаковый varint) и байт флагов наличия левого и правого потомков.
+// Такая запись однозначно восстанавливает дерево любой формы.
+func (bt *BinaryTree) SerializeBinary() ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := bt.EncodeTo(&buffer); err != nil {
+		return nil, err
 	}
-
-	return result, nil
+	return buffer.Bytes(), nil
 }
 
-// DeserializeBinary десериализует бинарное дерево из бинарного формата
+// DeserializeBinary десериализует бинарное дерево из бинарного формата.
+// Пустые данные соответствуют пустому дереву.
+// Данные без заголовка, их тип не проверяется; снимок с проверкой типа читает DeserializeSnapshot.
 func (bt *BinaryTree) DeserializeBinary(data []byte) error {
-	bt.Root = nil
-	queue := NewQueueTree()
-	index := 0
+	return bt.DecodeFrom(bytes.NewReader(data))
+}
+
+// EncodeTo записывает дерево в w в бинарном формате по одному узлу
+func (bt *BinaryTree) EncodeTo(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	var flags byte
+	if bt.Ordered {
+		flags |= binaryTreeFlagOrdered
+	}
+	buffer := []byte{binaryTreeFormatVersion, flags}
+	buffer = binary.AppendUvarint(buffer, uint64(bt.Size()))
+	if _, err := writer.Write(buffer); err != nil {
+		return fmt.Errorf("ошибка записи: %v", err)
+	}
 
-	if len(data) == 0 {
+	var err error
+	bt.forEachNode(func(node *TreeNode) {
+		if err != nil {
+			return
+		}
+		buffer = binary.AppendVarint(buffer[:0], int64(node.Digit))
+		var children byte
+		if node.Left != nil {
+			children |= binaryTreeHasLeft
+		}
+		if node.Right != nil {
+			children |= binaryTreeHasRight
+		}
+		buffer = append(buffer, children)
+		_, err = writer.Write(buffer)
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка записи: %v", err)
+	}
+	return nil
+}
+
+// DecodeFrom читает дерево из r в бинарном формате по одному узлу до конца потока.
+// Пустой поток соответствует пустому дереву; при ошибке дерево не меняется.
+// Узлы создаются по мере чтения, поэтому заявленное количество узлов
+// не влияет на объем выделяемой памяти.
+func (bt *BinaryTree) DecodeFrom(r io.Reader) error {
+	reader := bufio.NewReader(r)
+	version, err := reader.ReadByte()
+	if err == io.EOF {
+		bt.Root = nil
 		return nil
 	}
+	if err != nil {
+		return fmt.Errorf("ошибка чтения: %v", err)
+	}
+	flags, err := reader.ReadByte()
+	if err != nil {
+		return fmt.Errorf("недостаточно данных для чтения заголовка")
+	}
+	if version != binaryTreeFormatVersion {
+		return fmt.Errorf("неподдерживаемая версия формата: %d", version)
+	}
+	if flags&^binaryTreeFlagOrdered != 0 {
+		return fmt.Errorf("неизвестные флаги заголовка: %#x", flags)
+	}
+	count, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return fmt.Errorf("некорректное количество узлов")
+	}
 
-	// Читаем корневой узел
-	if index+4 > len(data) {
-		return fmt.Errorf("недостаточно данных для чтения корневого узла")
+	var root *TreeNode
+	pending := NewQueueTree() // Узлы, ожидающие чтения: их значения еще не заполнены
+	if count > 0 {
+		root = &TreeNode{}
+		pending.Enqueue(root)
+	}
+	for read := uint64(0); read < count; read++ {
+		current := pending.Dequeue()
+		if current == nil {
+			return fmt.Errorf("флаги потомков описывают %d узлов вместо %d", read, count)
+		}
+		value, err := binary.ReadVarint(reader)
+		if err != nil {
+			return fmt.Errorf("недостаточно данных для чтения узла %d", read)
+		}
+		children, err := reader.ReadByte()
+		if err != nil {
+			return fmt.Errorf("недостаточно данных для чтения флагов узла %d", read)
+		}
+		if children&^(binaryTreeHasLeft|binaryTreeHasRight) != 0 {
+			return fmt.Errorf("некорректные флаги потомков узла %d: %#x", read, children)
+		}
+		current.Digit = int(value)
+		if children&binaryTreeHasLeft != 0 {
+			current.Left = &TreeNode{}
+			pending.Enqueue(current.Left)
+		}
+		if children&binaryTreeHasRight != 0 {
+			current.Right = &TreeNode{}
+			pending.Enqueue(current.Right)
+		}
+	}
+	if !pending.IsEmpty() {
+		return fmt.Errorf("флаги потомков описывают больше %d узлов", count)
+	}
+	if _, err := reader.ReadByte(); err != io.EOF {
+		return fmt.Errorf("лишние данные после дерева")
 	}
-	value := int(binary.LittleEndian.Uint32(data[index : index+4]))
-	index += 4
-	bt.Root = &TreeNode{Digit: value}
-	queue.Enqueue(bt.Root)
 
-	for !queue.IsEmpty() && index < len(data) {
-		current := queue.Dequeue()
+	restored := &BinaryTree{Root: root, Ordered: flags&binaryTreeFlagOrdered != 0}
+	if restored.Ordered && !restored.IsBST() {
+		return fmt.Errorf("упорядоченное дерево нарушает порядок дерева поиска")
+	}
+	if restored.Ordered {
+		restored.updateSizes()
+	}
+	*bt = *restored
+	return nil
+}
 
-		// Левый дочерний узел
-		if index >= len(data) {
-			break
+// MarshalBinary реализует encoding.BinaryMarshaler
+func (bt *BinaryTree) MarshalBinary() ([]byte, error) {
+	return bt.SerializeBinary()
+}
+
+// UnmarshalBinary реализует encoding.BinaryUnmarshaler
+func (bt *BinaryTree) UnmarshalBinary(data []byte) error {
+	return bt.DeserializeBinary(data)
+}
+
+// MarshalText реализует encoding.TextMarshaler; текстовый формат - JSON
+func (bt *BinaryTree) MarshalText() ([]byte, error) {
+	text, err := bt.SerializeText()
+	return []byte(text), err
+}
+
+// UnmarshalText реализует encoding.TextUnmarshaler
+func (bt *BinaryTree) UnmarshalText(data []byte) error {
+	return bt.DeserializeText(string(data))
+}
+
+// MarshalJSON реализует json.Marshaler
+func (bt *BinaryTree) MarshalJSON() ([]byte, error) {
+	return bt.MarshalText()
+}
+
+// UnmarshalJSON реализует json.Unmarshaler
+func (bt *BinaryTree) UnmarshalJSON(data []byte) error {
+	return bt.UnmarshalText(data)
+}
+
+// portable возвращает переносимое представление дерева с той же формой, что
+// и текстовый формат: {"Root": узел, "Ordered": true}, где узел - словарь
+// {"Digit": число, "Left": узел или null, "Right": узел или null}
+func (bt *BinaryTree) portable() any {
+	var node func(current *TreeNode) any
+	node = func(current *TreeNode) any {
+		if current == nil {
+			return nil
 		}
-		if data[index] == 0 {
-			index++
-		} else {
-			if index+4 > len(data) {
-				return fmt.Errorf("недостаточно данных для чтения левого узла")
+		return map[string]any{"Digit": int64(current.Digit), "Left": node(current.Left), "Right": node(current.Right)}
+	}
+	data := map[string]any{"Root": node(bt.Root)}
+	if bt.Ordered {
+		data["Ordered"] = true
+	}
+	return data
+}
+
+// setPortable восстанавливает дерево из переносимого представления. Неизвестные
+// поля и упорядоченное дерево, нарушающее порядок дерева поиска, отклоняются;
+// при ошибке дерево не меняется.
+func (bt *BinaryTree) setPortable(value any) error {
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("ожидался словарь дерева, получено: %s", portableKind(value))
+	}
+	var temp BinaryTree
+	for key, field := range fields {
+		switch key {
+		case "Root":
+		case "Ordered":
+			if temp.Ordered, ok = field.(bool); !ok {
+				return fmt.Errorf("поле Ordered: ожидалось логическое значение, получено: %s", portableKind(field))
 			}
-			value := int(binary.LittleEndian.Uint32(data[index : index+4]))
-			index += 4
-			current.Left = &TreeNode{Digit: value}
-			queue.Enqueue(current.Left)
+		default:
+			return fmt.Errorf("неизвестное поле дерева %q", key)
 		}
-
-		// Правый дочерний узел
-		if index >= len(data) {
-			break
+	}
+	var node func(value any) (*TreeNode, error)
+	node = func(value any) (*TreeNode, error) {
+		if value == nil {
+			return nil, nil
 		}
-		if data[index] == 0 {
-			index++
-		} else {
-			if index+4 > len(data) {
-				return fmt.Errorf("недостаточно данных для чтения правого узла")
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("ожидался узел, получено: %s", portableKind(value))
+		}
+		digit, ok := fields["Digit"].(int64)
+		if !ok {
+			return nil, fmt.Errorf("поле Digit узла: ожидалось целое число, получено: %s", portableKind(fields["Digit"]))
+		}
+		current := &TreeNode{Digit: int(digit)}
+		for key, child := range fields {
+			var err error
+			switch key {
+			case "Digit":
+			case "Left":
+				current.Left, err = node(child)
+			case "Right":
+				current.Right, err = node(child)
+			default:
+				err = fmt.Errorf("неизвестное поле узла %q", key)
+			}
+			if err != nil {
+				return nil, err
 			}
-			value := int(binary.LittleEndian.Uint32(data[index : index+4]))
-			index += 4
-			current.Right = &TreeNode{Digit: value}
-			queue.Enqueue(current.Right)
 		}
+		return current, nil
+	}
+	root, err := node(fields["Root"])
+	if err != nil {
+		return err
 	}
+	temp.Root = root
+	if temp.Ordered && !temp.IsBST() {
+		return fmt.Errorf("упорядоченное дерево нарушает порядок дерева поиска")
+	}
+	if temp.Ordered {
+		temp.updateSizes()
+	}
+	*bt = temp
+	return nil
+}
 
+// SerializeMsgPack сериализует бинарное дерево в MessagePack
+func (bt *BinaryTree) SerializeMsgPack() ([]byte, error) {
+	return serializeMsgPack(bt)
+}
+
+// DeserializeMsgPack десериализует бинарное дерево из MessagePack
+func (bt *BinaryTree) DeserializeMsgPack(data []byte) error {
+	return deserializeMsgPack(bt, data)
+}
+
+// SerializeCBOR сериализует бинарное дерево в CBOR
+func (bt *BinaryTree) SerializeCBOR() ([]byte, error) {
+	return serializeCBOR(bt)
+}
+
+// DeserializeCBOR десериализует бинарное дерево из CBOR
+func (bt *BinaryTree) DeserializeCBOR(data []byte) error {
+	return deserializeCBOR(bt, data)
+}
+
+// SerializeSnapshot сериализует бинарное дерево в снимок с заголовком, типом и контрольной суммой
+func (bt *BinaryTree) SerializeSnapshot() ([]byte, error) {
+	payload, err := bt.SerializeBinary()
+	if err != nil {
+		return nil, err
+	}
+	return encodeSnapshot(SnapshotBinaryTree, bt.Size(), payload), nil
+}
+
+// DeserializeSnapshot восстанавливает бинарное дерево из снимка. Снимок другой структуры,
+// другой версии или с неверной контрольной суммой отклоняется без изменения данных.
+func (bt *BinaryTree) DeserializeSnapshot(data []byte) error {
+	count, payload, err := decodeSnapshot(data, SnapshotBinaryTree)
+	if err != nil {
+		return err
+	}
+	temp := NewBinaryTree()
+	if err := temp.DeserializeBinary(payload); err != nil {
+		return err
+	}
+	if err := checkSnapshotCount(SnapshotBinaryTree, temp.Size(), count); err != nil {
+		return err
+	}
+	*bt = *temp
 	return nil
 }
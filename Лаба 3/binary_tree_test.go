@@ -2,7 +2,10 @@ package main
 
 import (
 	"bufio"
+	"math"
+	"math/rand"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -213,6 +216,46 @@ func TestSaveToFile(t *testing.T) {
 	}
 }
 
+// TestSaveLoadIncompleteTree проверяет, что неполное дерево из TBUILD сохраняет форму в файле
+func TestSaveLoadIncompleteTree(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tree.txt")
+	for _, shape := range []string{"[1,null,2,null,3]", "[1,2,3,4,5,6]", "[5,3,8,null,4]"} {
+		built, _ := ParseLevelOrder(shape)
+		if err := built.SaveToFile(filename); err != nil {
+			t.Fatalf("SaveToFile(%s) error = %v", shape, err)
+		}
+		loaded := NewBinaryTree()
+		if err := loaded.LoadFromFile(filename); err != nil || loaded.LevelOrderString() != shape {
+			t.Errorf("LoadFromFile(%s) = %s, %v", shape, loaded.LevelOrderString(), err)
+		}
+	}
+	// Полное дерево записывается без пропусков, как раньше
+	if data, _ := os.ReadFile(filename); string(data) != "5\n3\n8\nnull\n4\n" {
+		t.Errorf("Содержимое файла неполного дерева: %q", data)
+	}
+	complete, _ := ParseLevelOrder("[1,2,3]")
+	complete.SaveToFile(filename)
+	if data, _ := os.ReadFile(filename); string(data) != "1\n2\n3\n" {
+		t.Errorf("Содержимое файла полного дерева: %q", data)
+	}
+
+	// Дерево поиска восстанавливается вставкой и записывается без пропусков
+	bst := NewBinarySearchTree()
+	for _, value := range []int{5, 3, 8, 4} {
+		bst.Insert(value)
+	}
+	bst.SaveToFile(filename)
+	loaded := NewBinarySearchTree()
+	if err := loaded.LoadFromFile(filename); err != nil || loaded.LevelOrderString() != "[5,3,8,null,4]" || loaded.Root.Size != 4 {
+		t.Errorf("LoadFromFile() дерева поиска = %s, %v", loaded.LevelOrderString(), err)
+	}
+
+	os.WriteFile(filename, []byte("1\nnull\nnull\n2\n"), 0644)
+	if err := NewBinaryTree().LoadFromFile(filename); err == nil {
+		t.Errorf("LoadFromFile() error = nil; want error for value without parent")
+	}
+}
+
 // TestLoadFromFile проверяет загрузку бинарного дерева из файла
 func TestLoadFromFile(t *testing.T) {
 	bt := NewBinaryTree()
@@ -374,11 +417,13 @@ func TestSerializeBinary(t *testing.T) {
 		t.Errorf("SerializeBinary() error = %v; want nil", err)
 	}
 
-	// Ожидаемый результат: 10 (4 байта) + 5 (4 байта) + 15 (4 байта)
+	// Ожидаемый результат: версия, флаги, количество узлов,
+	// затем значения (знаковый varint) с флагами потомков
 	expected := []byte{
-		10, 0, 0, 0, // 10
-		5, 0, 0, 0, // 5
-		15, 0, 0, 0, // 15
+		1, 0, 3, // заголовок
+		20, 3, // 10, есть оба потомка
+		10, 0, // 5
+		30, 0, // 15
 	}
 
 	if len(serialized) != len(expected) {
@@ -399,8 +444,8 @@ func TestSerializeBinary(t *testing.T) {
 		t.Errorf("SerializeBinary() error = %v; want nil", err)
 	}
 
-	if len(serialized) != 0 {
-		t.Errorf("SerializeBinary() = %v; want empty byte slice", serialized)
+	if len(serialized) != 3 || serialized[2] != 0 {
+		t.Errorf("SerializeBinary() = %v; want header with zero nodes", serialized)
 	}
 }
 
@@ -465,3 +510,384 @@ func treesEqual(node1, node2 *TreeNode) bool {
 	}
 	return treesEqual(node1.Left, node2.Left) && treesEqual(node1.Right, node2.Right)
 }
+
+// TestBinarySearchTreeInsert проверяет упорядоченную вставку
+func TestBinarySearchTreeInsert(t *testing.T) {
+	bt := NewBinarySearchTree()
+	for _, v := range []int{10, 5, 15, 3, 7, 12, 7} {
+		bt.Insert(v)
+	}
+
+	if bt.Root.Digit != 10 || bt.Root.Left.Digit != 5 || bt.Root.Right.Digit != 15 {
+		t.Errorf("Insert() = %v; want root 10 with children 5 and 15", bt.Root)
+	}
+	if bt.Root.Left.Left.Digit != 3 || bt.Root.Left.Right.Digit != 7 || bt.Root.Right.Left.Digit != 12 {
+		t.Errorf("Insert() placed grandchildren incorrectly")
+	}
+	if bt.Root.Left.Right.Left != nil || bt.Root.Left.Right.Right != nil {
+		t.Errorf("Insert() duplicate 7 should be ignored")
+	}
+
+	for _, v := range []int{10, 5, 15, 3, 7, 12} {
+		if !bt.FindValue(v) {
+			t.Errorf("FindValue(%d) = false; want true", v)
+		}
+	}
+	if bt.FindValue(8) {
+		t.Errorf("FindValue(8) = true; want false")
+	}
+}
+
+// TestMinMaxSuccessorPredecessor проверяет TMIN, TMAX, TSUCC и TPRED в обоих режимах
+func TestMinMaxSuccessorPredecessor(t *testing.T) {
+	for _, bt := range []*BinaryTree{NewBinaryTree(), NewBinarySearchTree()} {
+		if _, err := bt.Min(); err == nil {
+			t.Errorf("Min() on empty tree error = nil; want error")
+		}
+		if _, err := bt.Max(); err == nil {
+			t.Errorf("Max() on empty tree error = nil; want error")
+		}
+
+		for _, v := range []int{10, 5, 15, 3, 7, 12, 20} {
+			bt.Insert(v)
+		}
+
+		if v, err := bt.Min(); err != nil || v != 3 {
+			t.Errorf("Ordered=%v: Min() = %d, %v; want 3", bt.Ordered, v, err)
+		}
+		if v, err := bt.Max(); err != nil || v != 20 {
+			t.Errorf("Ordered=%v: Max() = %d, %v; want 20", bt.Ordered, v, err)
+		}
+		if v, err := bt.Successor(7); err != nil || v != 10 {
+			t.Errorf("Ordered=%v: Successor(7) = %d, %v; want 10", bt.Ordered, v, err)
+		}
+		if v, err := bt.Successor(11); err != nil || v != 12 {
+			t.Errorf("Ordered=%v: Successor(11) = %d, %v; want 12", bt.Ordered, v, err)
+		}
+		if _, err := bt.Successor(20); err == nil {
+			t.Errorf("Ordered=%v: Successor(20) error = nil; want error", bt.Ordered)
+		}
+		if v, err := bt.Predecessor(10); err != nil || v != 7 {
+			t.Errorf("Ordered=%v: Predecessor(10) = %d, %v; want 7", bt.Ordered, v, err)
+		}
+		if v, err := bt.Predecessor(13); err != nil || v != 12 {
+			t.Errorf("Ordered=%v: Predecessor(13) = %d, %v; want 12", bt.Ordered, v, err)
+		}
+		if _, err := bt.Predecessor(3); err == nil {
+			t.Errorf("Ordered=%v: Predecessor(3) error = nil; want error", bt.Ordered)
+		}
+	}
+}
+
+// TestBinarySearchTreeSaveLoad проверяет, что сохранение и загрузка сохраняют форму дерева поиска
+func TestBinarySearchTreeSaveLoad(t *testing.T) {
+	bt := NewBinarySearchTree()
+	for _, v := range []int{8, 3, 10, 1, 6, 14, 4} {
+		bt.Insert(v)
+	}
+
+	filename := "test_bst.txt"
+	if err := bt.SaveToFile(filename); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	defer os.Remove(filename)
+
+	loaded := NewBinarySearchTree()
+	if err := loaded.LoadFromFile(filename); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if !treesEqual(bt.Root, loaded.Root) {
+		t.Errorf("LoadFromFile() produced a different tree shape")
+	}
+
+	serialized, err := bt.SerializeText()
+	if err != nil {
+		t.Fatalf("SerializeText() error = %v", err)
+	}
+	restored := NewBinaryTree()
+	if err := restored.DeserializeText(serialized); err != nil {
+		t.Fatalf("DeserializeText() error = %v", err)
+	}
+	if !restored.Ordered || !treesEqual(bt.Root, restored.Root) {
+		t.Errorf("DeserializeText() = %v; want ordered tree", restored)
+	}
+}
+
+// TestDeleteLevelOrder проверяет удаление с сохранением формы полного дерева
+func TestDeleteLevelOrder(t *testing.T) {
+	bt := NewBinaryTree()
+	if bt.Delete(1) {
+		t.Errorf("Delete() on empty tree = true; want false")
+	}
+	for _, v := range []int{1, 2, 3, 4, 5, 6} {
+		bt.Insert(v)
+	}
+
+	if !bt.Delete(2) {
+		t.Errorf("Delete(2) = false; want true")
+	}
+	// Значение 2 заменяется последним узлом 6
+	if got := bt.levelOrderValues(); len(got) != 5 || got[0] != 1 || got[1] != 6 || got[2] != 3 || got[3] != 4 || got[4] != 5 {
+		t.Errorf("Delete(2) level order = %v; want [1 6 3 4 5]", got)
+	}
+	if bt.Root.Right.Left != nil {
+		t.Errorf("Delete(2) should detach the deepest rightmost node")
+	}
+
+	if bt.Delete(42) {
+		t.Errorf("Delete(42) = true; want false")
+	}
+
+	// Удаление последнего узла
+	if !bt.Delete(5) || bt.Root.Left.Right != nil {
+		t.Errorf("Delete(5) should remove the last node itself")
+	}
+
+	single := NewBinaryTree()
+	single.Insert(7)
+	if !single.Delete(7) || single.Root != nil {
+		t.Errorf("Delete(7) = %v; want empty tree", single.Root)
+	}
+}
+
+// TestDeleteOrdered проверяет удаление из дерева поиска
+func TestDeleteOrdered(t *testing.T) {
+	bt := NewBinarySearchTree()
+	for _, v := range []int{50, 30, 70, 20, 40, 60, 80, 65} {
+		bt.Insert(v)
+	}
+
+	// Лист
+	if !bt.Delete(20) || bt.FindValue(20) || bt.Root.Left.Left != nil {
+		t.Errorf("Delete(20) failed to remove a leaf")
+	}
+	// Узел с одним потомком
+	if !bt.Delete(60) || bt.Root.Right.Left.Digit != 65 {
+		t.Errorf("Delete(60) should replace the node with its only child")
+	}
+	// Узел с двумя потомками
+	if !bt.Delete(50) || bt.Root.Digit != 65 {
+		t.Errorf("Delete(50) root = %d; want 65", bt.Root.Digit)
+	}
+	if bt.Delete(50) {
+		t.Errorf("Delete(50) twice = true; want false")
+	}
+
+	for _, v := range []int{30, 40, 65, 70, 80} {
+		if !bt.FindValue(v) {
+			t.Errorf("FindValue(%d) = false after deletions", v)
+		}
+	}
+	for _, v := range []int{30, 40, 65, 70, 80} {
+		bt.Delete(v)
+	}
+	if bt.Root != nil {
+		t.Errorf("Root = %v; want empty tree", bt.Root)
+	}
+}
+
+// TestAt проверяет доступ по индексу в порядке обхода по уровням
+func TestAt(t *testing.T) {
+	bt := NewBinaryTree()
+	if _, err := bt.At(0); err == nil {
+		t.Errorf("At(0) on empty tree error = nil; want error")
+	}
+	for v := 0; v < 100; v++ {
+		bt.Insert(v * 10)
+	}
+	for i := 0; i < 100; i++ {
+		if v, err := bt.At(i); err != nil || v != i*10 {
+			t.Errorf("At(%d) = %d, %v; want %d", i, v, err, i*10)
+		}
+	}
+	if _, err := bt.At(100); err == nil {
+		t.Errorf("At(100) error = nil; want out of range error")
+	}
+	if _, err := bt.At(-1); err == nil {
+		t.Errorf("At(-1) error = nil; want error")
+	}
+
+	bst := NewBinarySearchTree()
+	for _, v := range []int{8, 3, 10, 1, 6, 14} {
+		bst.Insert(v)
+	}
+	for i, expected := range []int{8, 3, 10, 1, 6, 14} {
+		if v, err := bst.At(i); err != nil || v != expected {
+			t.Errorf("ordered At(%d) = %d, %v; want %d", i, v, err, expected)
+		}
+	}
+	if _, err := bst.At(6); err == nil {
+		t.Errorf("ordered At(6) error = nil; want out of range error")
+	}
+}
+
+// TestSet проверяет замену значения по индексу
+func TestSet(t *testing.T) {
+	bt := NewBinaryTree()
+	for v := 1; v <= 5; v++ {
+		bt.Insert(v)
+	}
+	if err := bt.Set(4, 50); err != nil {
+		t.Fatalf("Set(4, 50) error = %v", err)
+	}
+	if bt.Root.Left.Right.Digit != 50 {
+		t.Errorf("Set(4, 50) did not update the fifth node")
+	}
+	if err := bt.Set(5, 1); err == nil {
+		t.Errorf("Set(5, 1) error = nil; want out of range error")
+	}
+
+	bst := NewBinarySearchTree()
+	for _, v := range []int{8, 3, 10, 1, 6} {
+		bst.Insert(v)
+	}
+	if err := bst.Set(1, 7); err == nil {
+		t.Errorf("ordered Set(1, 7) error = nil; want order violation")
+	}
+	if err := bst.Set(1, 0); err == nil {
+		t.Errorf("ordered Set(1, 0) error = nil; want order violation")
+	}
+	if err := bst.Set(1, 2); err != nil || !bst.IsBST() || bst.Root.Left.Digit != 2 {
+		t.Errorf("ordered Set(1, 2) = %v; want order-preserving update", err)
+	}
+}
+
+// TestAtIncompleteTree проверяет доступ по индексу в неполном дереве, построенном TBUILD
+func TestAtIncompleteTree(t *testing.T) {
+	bt, err := ParseLevelOrder("[1,null,2,3]")
+	if err != nil {
+		t.Fatalf("ParseLevelOrder error = %v", err)
+	}
+	for i, expected := range []int{1, 2, 3} {
+		if v, err := bt.At(i); err != nil || v != expected {
+			t.Errorf("At(%d) = %d, %v; want %d", i, v, err, expected)
+		}
+	}
+	if _, err := bt.At(3); err == nil {
+		t.Errorf("At(3) error = nil; want out of range error")
+	}
+	if err := bt.Set(2, 30); err != nil || bt.Root.Right.Left.Digit != 30 {
+		t.Errorf("Set(2, 30) = %v; want update of the third node", err)
+	}
+	if bt.LevelOrderString() != "[1,null,2,30]" {
+		t.Errorf("Set changed the wrong node: %s", bt.LevelOrderString())
+	}
+}
+
+// TestAtShapeChanges проверяет доступ по индексу после изменений формы дерева
+func TestAtShapeChanges(t *testing.T) {
+	bt, _ := ParseLevelOrder("[1,null,2]")
+	if v, err := bt.At(1); err != nil || v != 2 {
+		t.Errorf("At(1) неполного дерева = %d, %v; want 2", v, err)
+	}
+	// Вставка заполняет пропуск, и дерево становится полным
+	bt.Insert(3)
+	for i, expected := range []int{1, 3, 2} {
+		if v, err := bt.At(i); err != nil || v != expected {
+			t.Errorf("At(%d) после вставки = %d, %v; want %d", i, v, err, expected)
+		}
+	}
+	if bt.shapeRoot != bt.Root || !bt.shapeComplete {
+		t.Errorf("Полнота дерева не запомнена после проверки")
+	}
+	bt.Insert(4)
+	bt.Delete(1)
+	for i, expected := range []int{4, 3, 2} {
+		if v, err := bt.At(i); err != nil || v != expected {
+			t.Errorf("At(%d) после удаления = %d, %v; want %d", i, v, err, expected)
+		}
+	}
+	// Замена корня сбрасывает запомненный результат
+	bt.Replace(mustParseLevelOrder(t, "[1,null,2,3]"))
+	if v, err := bt.At(2); err != nil || v != 3 {
+		t.Errorf("At(2) после замены дерева = %d, %v; want 3", v, err)
+	}
+}
+
+// mustParseLevelOrder разбирает запись дерева по уровням или завершает тест
+func mustParseLevelOrder(t *testing.T, input string) *BinaryTree {
+	t.Helper()
+	bt, err := ParseLevelOrder(input)
+	if err != nil {
+		t.Fatalf("ParseLevelOrder(%s) error = %v", input, err)
+	}
+	return bt
+}
+
+// randomTree строит дерево случайной формы со случайными значениями со знаком
+func randomTree(rng *rand.Rand, depth int) *TreeNode {
+	if depth == 0 || rng.Intn(4) == 0 {
+		return nil
+	}
+	values := []int{0, 256, -1, -256, math.MaxInt64, math.MinInt64, rng.Int() - rng.Int()}
+	return &TreeNode{
+		Digit: values[rng.Intn(len(values))],
+		Left:  randomTree(rng, depth-1),
+		Right: randomTree(rng, depth-1),
+	}
+}
+
+// TestSerializeBinaryRoundTripProperty проверяет, что любое дерево восстанавливается без изменений
+func TestSerializeBinaryRoundTripProperty(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		bt := &BinaryTree{Root: randomTree(rng, 1+rng.Intn(8))}
+		data, err := bt.SerializeBinary()
+		if err != nil {
+			t.Fatalf("SerializeBinary() error = %v", err)
+		}
+		restored := NewBinaryTree()
+		if err := restored.DeserializeBinary(data); err != nil {
+			t.Fatalf("DeserializeBinary() error = %v for %v", err, data)
+		}
+		if !treesEqual(bt.Root, restored.Root) {
+			t.Fatalf("round trip changed the tree: %v", data)
+		}
+
+		// Любое усечение данных должно приводить к ошибке
+		for cut := 1; cut < len(data); cut++ {
+			if err := restored.DeserializeBinary(data[:cut]); err == nil {
+				t.Fatalf("DeserializeBinary() of %d/%d bytes error = nil", cut, len(data))
+			}
+		}
+		if err := restored.DeserializeBinary(append(data, 0)); err == nil {
+			t.Fatalf("DeserializeBinary() with trailing byte error = nil")
+		}
+	}
+}
+
+// TestSerializeBinaryOrdered проверяет сохранение режима дерева поиска и проверку порядка
+func TestSerializeBinaryOrdered(t *testing.T) {
+	bst := NewBinarySearchTree()
+	for _, v := range []int{0, -5, 256, -300, 7} {
+		bst.Insert(v)
+	}
+	data, _ := bst.SerializeBinary()
+	restored := NewBinaryTree()
+	if err := restored.DeserializeBinary(data); err != nil {
+		t.Fatalf("DeserializeBinary() error = %v", err)
+	}
+	if !restored.Ordered || !treesEqual(bst.Root, restored.Root) {
+		t.Errorf("DeserializeBinary() did not restore the search tree")
+	}
+
+	unordered := &BinaryTree{Root: &TreeNode{Digit: 1, Left: &TreeNode{Digit: 2}}}
+	data, _ = unordered.SerializeBinary()
+	data[1] = binaryTreeFlagOrdered
+	if err := restored.DeserializeBinary(data); err == nil {
+		t.Errorf("DeserializeBinary() error = nil; want error for unordered search tree")
+	}
+
+	invalid := [][]byte{
+		{2, 0, 0},       // неизвестная версия
+		{1, 4, 0},       // неизвестный флаг
+		{1, 0, 1, 2, 4}, // неизвестный флаг потомков
+		{1, 0, 2, 2, 0}, // узлов меньше заявленного
+		{1, 0, 1, 2, 1}, // заявлен потомок без данных
+	}
+	for _, data := range invalid {
+		if err := restored.DeserializeBinary(data); err == nil {
+			t.Errorf("DeserializeBinary(%v) error = nil; want error", data)
+		}
+	}
+}
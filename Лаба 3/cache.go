@@ -0,0 +1,569 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CachePolicy определяет стратегию вытеснения кэша
+type CachePolicy int
+
+const (
+	PolicyLRU CachePolicy = iota // Вытесняется давно не использованный элемент
+	PolicyLFU                    // Вытесняется редко используемый элемент
+)
+
+// String возвращает название стратегии вытеснения
+func (p CachePolicy) String() string {
+	if p == PolicyLFU {
+		return "lfu"
+	}
+	return "lru"
+}
+
+// ParseCachePolicy разбирает название стратегии вытеснения
+func ParseCachePolicy(name string) (CachePolicy, error) {
+	switch strings.ToLower(name) {
+	case "lru":
+		return PolicyLRU, nil
+	case "lfu":
+		return PolicyLFU, nil
+	}
+	return PolicyLRU, fmt.Errorf("неизвестная стратегия вытеснения: %s", name)
+}
+
+// Cache представляет ограниченный кэш на основе хэш-таблицы и двусвязных списков.
+// Значения хранятся в HashTable, порядок использования - в DoublyLinkedList:
+// для LRU один список (голова - самый свежий элемент), для LFU по списку на каждую частоту.
+type Cache struct {
+	Capacity  int                       // Public
+	Policy    CachePolicy               // Public
+	Hits      int                       // Public
+	Misses    int                       // Public
+	Evictions int                       // Public
+	OnEvict   func(key, value string)   // Public, вызывается при вытеснении
+	values    *HashTable                // Значения по ключу
+	order     *DoublyLinkedList         // Порядок использования для LRU
+	freqLists map[int]*DoublyLinkedList // Списки ключей по частоте для LFU
+	nodes     map[string]*DoublyNode    // Узел списка для каждого ключа
+	freq      map[string]int            // Частота обращений для LFU
+	minFreq   int                       // Минимальная частота для LFU
+}
+
+// NewCache создает новый кэш с указанной емкостью и стратегией (Public)
+func NewCache(capacity int, policy CachePolicy) *Cache {
+	c := &Cache{
+		Capacity: capacity,
+		Policy:   policy,
+	}
+	c.reset()
+	return c
+}
+
+// reset очищает содержимое кэша, сохраняя счетчики (Private)
+func (c *Cache) reset() {
+	buckets := c.Capacity
+	if buckets < 1 {
+		buckets = 1
+	}
+	c.values = NewHashTable(buckets)
+	c.order = NewDoublyLinkedList()
+	c.freqLists = make(map[int]*DoublyLinkedList)
+	c.nodes = make(map[string]*DoublyNode)
+	c.freq = make(map[string]int)
+	c.minFreq = 0
+}
+
+// Len возвращает количество элементов в кэше (Public)
+func (c *Cache) Len() int {
+	return len(c.nodes)
+}
+
+// Get возвращает значение по ключу и отмечает обращение к нему (Public)
+func (c *Cache) Get(key string) (string, bool) {
+	node := c.values.findNodeByKey(key)
+	if node == nil {
+		c.Misses++
+		return "", false
+	}
+	c.Hits++
+	c.touch(key)
+	return node.Value, true
+}
+
+// Peek возвращает значение по ключу, не меняя порядок вытеснения и счетчики (Public)
+func (c *Cache) Peek(key string) (string, bool) {
+	node := c.values.findNodeByKey(key)
+	if node == nil {
+		return "", false
+	}
+	return node.Value, true
+}
+
+// Set добавляет или обновляет значение, при переполнении вытесняя элемент (Public)
+func (c *Cache) Set(key, value string) {
+	if c.Capacity <= 0 {
+		return
+	}
+	if node := c.values.findNodeByKey(key); node != nil {
+		node.Value = value
+		c.touch(key)
+		return
+	}
+	if c.Len() >= c.Capacity {
+		c.evict()
+	}
+	c.values.HSet(key, value)
+	c.insert(key, 1)
+}
+
+// Delete удаляет ключ из кэша без вызова OnEvict (Public)
+func (c *Cache) Delete(key string) bool {
+	if c.values.findNodeByKey(key) == nil {
+		return false
+	}
+	c.detach(key)
+	c.values.HDel(key)
+	return true
+}
+
+// Keys возвращает ключи в порядке от первого кандидата на вытеснение к последнему (Public)
+func (c *Cache) Keys() []string {
+	return slices.AppendSeq(make([]string, 0, c.Len()), c.keys())
+}
+
+// keys возвращает итератор по ключам в порядке от первого кандидата на вытеснение (Private)
+func (c *Cache) keys() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		if c.Policy == PolicyLRU {
+			for node := c.order.Tail; node != nil; node = node.Prev {
+				if !yield(node.Data) {
+					return
+				}
+			}
+			return
+		}
+		for _, f := range c.frequencies() {
+			for node := c.freqLists[f].Tail; node != nil; node = node.Prev {
+				if !yield(node.Data) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Frequency возвращает число обращений к ключу (для LRU всегда 1 у существующего ключа) (Public)
+func (c *Cache) Frequency(key string) int {
+	if _, ok := c.nodes[key]; !ok {
+		return 0
+	}
+	if c.Policy == PolicyLRU {
+		return 1
+	}
+	return c.freq[key]
+}
+
+// HitRatio возвращает долю успешных обращений (Public)
+func (c *Cache) HitRatio() float64 {
+	total := c.Hits + c.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(total)
+}
+
+// CStats печатает статистику кэша (Public)
+func (c *Cache) CStats() {
+	fmt.Printf("Стратегия: %s\n", c.Policy)
+	fmt.Printf("Заполнено: %d из %d\n", c.Len(), c.Capacity)
+	fmt.Printf("Попаданий: %d\n", c.Hits)
+	fmt.Printf("Промахов: %d\n", c.Misses)
+	fmt.Printf("Доля попаданий: %.2f\n", c.HitRatio())
+	fmt.Printf("Вытеснений: %d\n", c.Evictions)
+}
+
+// Print выводит пары ключ-значение в порядке вытеснения (Public)
+func (c *Cache) Print() {
+	for _, key := range c.Keys() {
+		value, _ := c.Peek(key)
+		fmt.Printf("%s => %s ", key, value)
+	}
+	fmt.Println()
+}
+
+// insert добавляет ключ в структуры порядка с заданной частотой (Private)
+func (c *Cache) insert(key string, freq int) {
+	node := &DoublyNode{Data: key}
+	c.nodes[key] = node
+	if c.Policy == PolicyLRU {
+		c.order.linkNodeToHead(node)
+		return
+	}
+	c.freq[key] = freq
+	c.listForFreq(freq).linkNodeToHead(node)
+	if c.minFreq == 0 || freq < c.minFreq {
+		c.minFreq = freq
+	}
+}
+
+// touch отмечает обращение к существующему ключу (Private)
+func (c *Cache) touch(key string) {
+	node := c.nodes[key]
+	if c.Policy == PolicyLRU {
+		c.order.unlinkNode(node)
+		c.order.linkNodeToHead(node)
+		return
+	}
+	freq := c.freq[key]
+	list := c.freqLists[freq]
+	list.unlinkNode(node)
+	if list.Head == nil {
+		delete(c.freqLists, freq)
+		if c.minFreq == freq {
+			c.minFreq = freq + 1
+		}
+	}
+	c.freq[key] = freq + 1
+	c.listForFreq(freq + 1).linkNodeToHead(node)
+}
+
+// detach удаляет ключ из структур порядка (Private)
+func (c *Cache) detach(key string) {
+	node := c.nodes[key]
+	delete(c.nodes, key)
+	if c.Policy == PolicyLRU {
+		c.order.unlinkNode(node)
+		return
+	}
+	freq := c.freq[key]
+	delete(c.freq, key)
+	list := c.freqLists[freq]
+	list.unlinkNode(node)
+	if list.Head == nil {
+		delete(c.freqLists, freq)
+		if c.minFreq == freq {
+			c.minFreq = 0
+			if fs := c.frequencies(); len(fs) > 0 {
+				c.minFreq = fs[0]
+			}
+		}
+	}
+}
+
+// evict вытесняет один элемент согласно стратегии (Private)
+func (c *Cache) evict() {
+	var victim *DoublyNode
+	if c.Policy == PolicyLRU {
+		victim = c.order.Tail
+	} else if list, ok := c.freqLists[c.minFreq]; ok {
+		victim = list.Tail
+	}
+	if victim == nil {
+		return
+	}
+	key := victim.Data
+	value, _ := c.Peek(key)
+	c.detach(key)
+	c.values.HDel(key)
+	c.Evictions++
+	if c.OnEvict != nil {
+		c.OnEvict(key, value)
+	}
+}
+
+// listForFreq возвращает список ключей для частоты, создавая его при необходимости (Private)
+func (c *Cache) listForFreq(freq int) *DoublyLinkedList {
+	list, ok := c.freqLists[freq]
+	if !ok {
+		list = NewDoublyLinkedList()
+		c.freqLists[freq] = list
+	}
+	return list
+}
+
+// frequencies возвращает используемые частоты по возрастанию (Private)
+func (c *Cache) frequencies() []int {
+	fs := make([]int, 0, len(c.freqLists))
+	for f := range c.freqLists {
+		fs = append(fs, f)
+	}
+	for i := 1; i < len(fs); i++ {
+		for j := i; j > 0 && fs[j] < fs[j-1]; j-- {
+			fs[j], fs[j-1] = fs[j-1], fs[j]
+		}
+	}
+	return fs
+}
+
+// SaveToFile сохраняет кэш в файл (Public).
+// Первая строка - заголовок "cache стратегия емкость попадания промахи вытеснения",
+// далее строки "ключ значение частота" в порядке от первого кандидата на вытеснение.
+func (c *Cache) SaveToFile(filename string) error {
+	file, err := createSnapshotFile(filename)
+	if err != nil {
+		return fmt.Errorf("не удалось создать файл: %v", err)
+	}
+	defer file.Close()
+
+	header := fmt.Sprintf("cache %s %d %d %d %d\n", c.Policy, c.Capacity, c.Hits, c.Misses, c.Evictions)
+	if _, err := file.WriteString(header); err != nil {
+		return fmt.Errorf("ошибка записи в файл: %v", err)
+	}
+	for _, key := range c.Keys() {
+		value, _ := c.Peek(key)
+		_, err := file.WriteString(fmt.Sprintf("%s %s %d\n", key, value, c.Frequency(key)))
+		if err != nil {
+			return fmt.Errorf("ошибка записи в файл: %v", err)
+		}
+	}
+	return file.Commit()
+}
+
+// LoadFromFile загружает кэш из файла (Public).
+// Строки, не соответствующие формату, пропускаются, как в HashTable.LoadFromFile.
+func (c *Cache) LoadFromFile(filename string) error {
+	file, err := openSnapshotFile(filename)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть файл: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	first := true
+	for scanner.Scan() {
+		parts := strings.Split(scanner.Text(), " ")
+		if first {
+			first = false
+			if len(parts) == 6 && parts[0] == "cache" {
+				if err := c.applyHeader(parts[1:]); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch len(parts) {
+		case 2:
+			c.restore(parts[0], parts[1], 1)
+		case 3:
+			freq, err := strconv.Atoi(parts[2])
+			if err != nil || freq < 1 {
+				freq = 1
+			}
+			c.restore(parts[0], parts[1], freq)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения файла: %v", err)
+	}
+	return nil
+}
+
+// applyHeader применяет параметры кэша из заголовка файла (Private)
+func (c *Cache) applyHeader(fields []string) error {
+	policy, err := ParseCachePolicy(fields[0])
+	if err != nil {
+		return err
+	}
+	numbers := make([]int, 4)
+	for i, field := range fields[1:] {
+		numbers[i], err = strconv.Atoi(field)
+		if err != nil {
+			return fmt.Errorf("недопустимый заголовок кэша: %v", err)
+		}
+	}
+	c.Policy = policy
+	c.Capacity = numbers[0]
+	c.Hits, c.Misses, c.Evictions = numbers[1], numbers[2], numbers[3]
+	c.reset()
+	return nil
+}
+
+// restore добавляет элемент из файла с сохраненной частотой (Private)
+func (c *Cache) restore(key, value string, freq int) {
+	if c.values.findNodeByKey(key) != nil {
+		c.Set(key, value)
+		return
+	}
+	if c.Capacity <= 0 {
+		return
+	}
+	if c.Len() >= c.Capacity {
+		c.evict()
+	}
+	c.values.HSet(key, value)
+	c.insert(key, freq)
+}
+
+// EncodeTo записывает кэш в w в бинарном формате списочных структур (Public):
+// пять строк заголовка, как в SaveToFile (стратегия, емкость, попадания, промахи,
+// вытеснения), далее тройки строк "ключ", "значение", "частота" в порядке вытеснения
+func (c *Cache) EncodeTo(w io.Writer) error {
+	return encodeStrings(w, func(yield func(string) bool) {
+		header := []string{c.Policy.String(), strconv.Itoa(c.Capacity), strconv.Itoa(c.Hits), strconv.Itoa(c.Misses), strconv.Itoa(c.Evictions)}
+		for _, field := range header {
+			if !yield(field) {
+				return
+			}
+		}
+		for key := range c.keys() {
+			value, _ := c.Peek(key)
+			if !yield(key) || !yield(value) || !yield(strconv.Itoa(c.Frequency(key))) {
+				return
+			}
+		}
+	})
+}
+
+// DecodeFrom читает кэш из r в бинарном формате по одному элементу (Public).
+// Элементы сверх емкости отклоняются, а не вытесняются; при ошибке кэш не меняется.
+func (c *Cache) DecodeFrom(r io.Reader) error {
+	temp := NewCache(c.Capacity, c.Policy)
+	headerRead := false
+	var fields []string // Строки текущего заголовка или элемента
+	var parseErr error
+	err := decodeStrings(r, func(field string) bool {
+		fields = append(fields, field)
+		switch {
+		case !headerRead && len(fields) == 5:
+			headerRead = true
+			parseErr = temp.applyHeader(fields)
+			fields = fields[:0]
+		case headerRead && len(fields) == 3:
+			freq, err := strconv.Atoi(fields[2])
+			switch {
+			case err != nil || freq < 1:
+				parseErr = fmt.Errorf("недопустимая частота %q", fields[2])
+			case temp.Len() >= temp.Capacity:
+				parseErr = fmt.Errorf("количество элементов превышает емкость кэша %d", temp.Capacity)
+			default:
+				temp.restore(fields[0], fields[1], freq)
+			}
+			fields = fields[:0]
+		}
+		return parseErr == nil
+	})
+	if err != nil {
+		return err
+	}
+	if parseErr != nil {
+		return parseErr
+	}
+	if len(fields) != 0 {
+		return fmt.Errorf("недостаточно данных для чтения элемента кэша")
+	}
+	temp.OnEvict = c.OnEvict
+	*c = *temp
+	return nil
+}
+
+// SerializeBinary сериализует кэш в бинарный формат (см. EncodeTo) (Public)
+func (c *Cache) SerializeBinary() ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := c.EncodeTo(&buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// DeserializeBinary десериализует кэш из бинарного формата (Public)
+func (c *Cache) DeserializeBinary(data []byte) error {
+	return c.DecodeFrom(bytes.NewReader(data))
+}
+
+// cacheItemJSON - элемент кэша в текстовом формате
+type cacheItemJSON struct {
+	Key   string
+	Value string
+	Freq  int
+}
+
+// cacheJSON - текстовое представление кэша: параметры и элементы в порядке вытеснения
+type cacheJSON struct {
+	Policy    string
+	Capacity  int
+	Hits      int
+	Misses    int
+	Evictions int
+	Items     []cacheItemJSON
+}
+
+// SerializeText сериализует кэш в текстовый формат (JSON) (Public)
+func (c *Cache) SerializeText() (string, error) {
+	temp := cacheJSON{Policy: c.Policy.String(), Capacity: c.Capacity, Hits: c.Hits, Misses: c.Misses, Evictions: c.Evictions, Items: []cacheItemJSON{}}
+	for key := range c.keys() {
+		value, _ := c.Peek(key)
+		temp.Items = append(temp.Items, cacheItemJSON{Key: key, Value: value, Freq: c.Frequency(key)})
+	}
+	data, err := json.Marshal(temp)
+	if err != nil {
+		return "", fmt.Errorf("ошибка сериализации в текстовый формат: %v", err)
+	}
+	return string(data), nil
+}
+
+// DeserializeText десериализует кэш из текстового формата (JSON) (Public).
+// Элементы сверх емкости отклоняются; при ошибке кэш не меняется.
+func (c *Cache) DeserializeText(data string) error {
+	var temp cacheJSON
+	if err := json.Unmarshal([]byte(data), &temp); err != nil {
+		return fmt.Errorf("ошибка десериализации из текстового формата: %v", err)
+	}
+	policy, err := ParseCachePolicy(temp.Policy)
+	if err != nil {
+		return err
+	}
+	if len(temp.Items) > max(temp.Capacity, 0) {
+		return fmt.Errorf("количество элементов %d превышает емкость кэша %d", len(temp.Items), temp.Capacity)
+	}
+	restored := NewCache(temp.Capacity, policy)
+	restored.Hits, restored.Misses, restored.Evictions = temp.Hits, temp.Misses, temp.Evictions
+	for _, item := range temp.Items {
+		if item.Freq < 1 {
+			return fmt.Errorf("недопустимая частота %d у ключа %q", item.Freq, item.Key)
+		}
+		restored.restore(item.Key, item.Value, item.Freq)
+	}
+	restored.OnEvict = c.OnEvict
+	*c = *restored
+	return nil
+}
+
+// MarshalBinary реализует encoding.BinaryMarshaler (Public)
+func (c *Cache) MarshalBinary() ([]byte, error) {
+	return c.SerializeBinary()
+}
+
+// UnmarshalBinary реализует encoding.BinaryUnmarshaler (Public)
+func (c *Cache) UnmarshalBinary(data []byte) error {
+	return c.DeserializeBinary(data)
+}
+
+// MarshalText реализует encoding.TextMarshaler; текстовый формат - JSON (Public)
+func (c *Cache) MarshalText() ([]byte, error) {
+	text, err := c.SerializeText()
+	return []byte(text), err
+}
+
+// UnmarshalText реализует encoding.TextUnmarshaler (Public)
+func (c *Cache) UnmarshalText(data []byte) error {
+	return c.DeserializeText(string(data))
+}
+
+// MarshalJSON реализует json.Marshaler (Public)
+func (c *Cache) MarshalJSON() ([]byte, error) {
+	return c.MarshalText()
+}
+
+// UnmarshalJSON реализует json.Unmarshaler (Public)
+func (c *Cache) UnmarshalJSON(data []byte) error {
+	return c.UnmarshalText(data)
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestNewCache(t *testing.T) {
+	c := NewCache(3, PolicyLRU)
+	if c.Capacity != 3 || c.Policy != PolicyLRU || c.Len() != 0 {
+		t.Errorf("NewCache() = %+v; want empty LRU cache with capacity 3", c)
+	}
+}
+
+func TestParseCachePolicy(t *testing.T) {
+	if p, err := ParseCachePolicy("LFU"); err != nil || p != PolicyLFU {
+		t.Errorf("ParseCachePolicy(LFU) = %v, %v; want lfu", p, err)
+	}
+	if p, err := ParseCachePolicy("lru"); err != nil || p != PolicyLRU {
+		t.Errorf("ParseCachePolicy(lru) = %v, %v; want lru", p, err)
+	}
+	if _, err := ParseCachePolicy("fifo"); err == nil {
+		t.Errorf("ParseCachePolicy(fifo) error = nil; want error")
+	}
+}
+
+func TestCacheLRUEviction(t *testing.T) {
+	c := NewCache(2, PolicyLRU)
+	var evicted []string
+	c.OnEvict = func(key, value string) {
+		evicted = append(evicted, key+"="+value)
+	}
+
+	c.Set("a", "1")
+	c.Set("b", "2")
+	c.Get("a") // "b" становится самым давним
+	c.Set("c", "3")
+
+	if _, ok := c.Peek("b"); ok {
+		t.Errorf("Ожидалось вытеснение ключа 'b'")
+	}
+	if !reflect.DeepEqual(evicted, []string{"b=2"}) {
+		t.Errorf("OnEvict вызван с %v; want [b=2]", evicted)
+	}
+	if !reflect.DeepEqual(c.Keys(), []string{"a", "c"}) {
+		t.Errorf("Keys() = %v; want [a c]", c.Keys())
+	}
+
+	// Обновление существующего ключа не вытесняет элементы
+	c.Set("a", "10")
+	if value, _ := c.Peek("a"); value != "10" || c.Evictions != 1 {
+		t.Errorf("Set() existing key: value = %s, evictions = %d", value, c.Evictions)
+	}
+}
+
+func TestCacheLFUEviction(t *testing.T) {
+	c := NewCache(2, PolicyLFU)
+	c.Set("a", "1")
+	c.Set("b", "2")
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	c.Set("c", "3") // "b" используется реже, чем "a"
+
+	if _, ok := c.Peek("b"); ok {
+		t.Errorf("Ожидалось вытеснение ключа 'b'")
+	}
+	if c.Frequency("a") != 3 || c.Frequency("c") != 1 {
+		t.Errorf("Frequency() a = %d, c = %d; want 3 and 1", c.Frequency("a"), c.Frequency("c"))
+	}
+
+	// При равной частоте вытесняется давно использованный
+	c.Set("d", "4")
+	if _, ok := c.Peek("c"); ok {
+		t.Errorf("Ожидалось вытеснение ключа 'c'")
+	}
+	if _, ok := c.Peek("a"); !ok {
+		t.Errorf("Ключ 'a' не должен быть вытеснен")
+	}
+}
+
+func TestCacheHitsAndMisses(t *testing.T) {
+	c := NewCache(2, PolicyLRU)
+	c.Set("a", "1")
+	if value, ok := c.Get("a"); !ok || value != "1" {
+		t.Errorf("Get(a) = %s, %v; want 1, true", value, ok)
+	}
+	if _, ok := c.Get("missing"); ok {
+		t.Errorf("Get(missing) ok = true; want false")
+	}
+	if c.Hits != 1 || c.Misses != 1 || c.HitRatio() != 0.5 {
+		t.Errorf("Hits = %d, Misses = %d, HitRatio = %.2f; want 1, 1, 0.5", c.Hits, c.Misses, c.HitRatio())
+	}
+	if NewCache(1, PolicyLRU).HitRatio() != 0 {
+		t.Errorf("HitRatio() для пустого кэша должен быть 0")
+	}
+}
+
+func TestCacheDelete(t *testing.T) {
+	for _, policy := range []CachePolicy{PolicyLRU, PolicyLFU} {
+		c := NewCache(3, policy)
+		c.Set("a", "1")
+		c.Set("b", "2")
+		c.Get("b")
+		if !c.Delete("b") || c.Len() != 1 {
+			t.Errorf("%s: Delete(b) не удалил ключ", policy)
+		}
+		if c.Delete("b") {
+			t.Errorf("%s: повторный Delete(b) = true; want false", policy)
+		}
+		c.Set("c", "3")
+		c.Set("d", "4")
+		c.Set("e", "5")
+		if _, ok := c.Peek("a"); ok {
+			t.Errorf("%s: ожидалось вытеснение ключа 'a'", policy)
+		}
+	}
+}
+
+func TestCacheZeroCapacity(t *testing.T) {
+	c := NewCache(0, PolicyLRU)
+	c.Set("a", "1")
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d; want 0 for zero capacity", c.Len())
+	}
+}
+
+func TestCacheSaveLoad(t *testing.T) {
+	filename := "test_cache.txt"
+	defer os.Remove(filename)
+
+	for _, policy := range []CachePolicy{PolicyLRU, PolicyLFU} {
+		c := NewCache(3, policy)
+		c.Set("a", "1")
+		c.Set("b", "2")
+		c.Set("c", "3")
+		c.Get("a")
+		c.Get("a")
+		c.Get("x")
+
+		if err := c.SaveToFile(filename); err != nil {
+			t.Fatalf("SaveToFile() error = %v", err)
+		}
+
+		loaded := NewCache(10, PolicyLRU)
+		if err := loaded.LoadFromFile(filename); err != nil {
+			t.Fatalf("LoadFromFile() error = %v", err)
+		}
+		if loaded.Policy != policy || loaded.Capacity != 3 {
+			t.Errorf("%s: загружены стратегия %s и емкость %d", policy, loaded.Policy, loaded.Capacity)
+		}
+		if loaded.Hits != 2 || loaded.Misses != 1 {
+			t.Errorf("%s: загружены счетчики %d/%d; want 2/1", policy, loaded.Hits, loaded.Misses)
+		}
+		if !reflect.DeepEqual(loaded.Keys(), c.Keys()) {
+			t.Errorf("%s: Keys() = %v; want %v", policy, loaded.Keys(), c.Keys())
+		}
+		if loaded.Frequency("a") != c.Frequency("a") {
+			t.Errorf("%s: Frequency(a) = %d; want %d", policy, loaded.Frequency("a"), c.Frequency("a"))
+		}
+	}
+
+	// Файл без заголовка читается как пары ключ-значение
+	os.WriteFile(filename, []byte("HELLO\nkey1 value1\n"), 0644)
+	c := NewCache(2, PolicyLRU)
+	if err := c.LoadFromFile(filename); err != nil {
+		t.Errorf("LoadFromFile() error = %v; want nil", err)
+	}
+	if value, ok := c.Peek("key1"); !ok || value != "value1" {
+		t.Errorf("Peek(key1) = %s, %v; want value1, true", value, ok)
+	}
+
+	// Некорректная стратегия в заголовке
+	os.WriteFile(filename, []byte("cache fifo 3 0 0 0\n"), 0644)
+	if err := c.LoadFromFile(filename); err == nil {
+		t.Errorf("LoadFromFile() error = nil; want error for invalid policy")
+	}
+
+	if err := c.LoadFromFile("nonexistent_cache.txt"); err == nil {
+		t.Errorf("LoadFromFile() error = nil; want error for missing file")
+	}
+}
+
+func TestCacheStatsOutput(t *testing.T) {
+	c := NewCache(2, PolicyLFU)
+	c.Set("a", "1")
+	c.Get("a")
+	output := captureStdout(c.CStats)
+	expected := "Стратегия: lfu\nЗаполнено: 1 из 2\nПопаданий: 1\nПромахов: 0\nДоля попаданий: 1.00\nВытеснений: 0\n"
+	if output != expected {
+		t.Errorf("CStats() = %q; want %q", output, expected)
+	}
+	if output := captureStdout(c.Print); output != "a => 1 \n" {
+		t.Errorf("Print() = %q", output)
+	}
+}
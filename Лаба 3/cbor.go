@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// Кодирование переносимых значений в CBOR (RFC 8949). Запись следует
+// детерминированным правилам из раздела 4.2.1: самые короткие заголовки,
+// только определенные длины, ключи словарей по sortedPortableKeys.
+// При разборе принимаются и неопределенные длины, и метка самоописания 55799.
+
+// Старшие типы CBOR
+const (
+	cborUnsigned = 0
+	cborNegative = 1
+	cborBytes    = 2
+	cborText     = 3
+	cborArray    = 4
+	cborMap      = 5
+	cborTag      = 6
+	cborSimple   = 7
+)
+
+// cborSelfDescribe - метка, которой можно пометить начало данных CBOR
+const cborSelfDescribe = 55799
+
+// encodeCBOR кодирует переносимое значение в CBOR
+func encodeCBOR(value any) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := writeCBOR(&buffer, value); err != nil {
+		return nil, fmt.Errorf("ошибка сериализации в CBOR: %v", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// writeCBOR записывает значение и вложенные в него значения
+func writeCBOR(buffer *bytes.Buffer, value any) error {
+	switch value := value.(type) {
+	case nil:
+		buffer.WriteByte(0xf6)
+	case bool:
+		if value {
+			buffer.WriteByte(0xf5)
+		} else {
+			buffer.WriteByte(0xf4)
+		}
+	case int:
+		writeCBORInt(buffer, int64(value))
+	case int64:
+		writeCBORInt(buffer, value)
+	case string:
+		if utf8.ValidString(value) {
+			writeCBORHead(buffer, cborText, uint64(len(value)))
+		} else {
+			writeCBORHead(buffer, cborBytes, uint64(len(value)))
+		}
+		buffer.WriteString(value)
+	case []any:
+		writeCBORHead(buffer, cborArray, uint64(len(value)))
+		for _, item := range value {
+			if err := writeCBOR(buffer, item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		writeCBORHead(buffer, cborMap, uint64(len(value)))
+		for _, key := range sortedPortableKeys(value) {
+			writeCBOR(buffer, key)
+			if err := writeCBOR(buffer, value[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("неподдерживаемый тип %T", value)
+	}
+	return nil
+}
+
+// writeCBORInt записывает целое число; отрицательное n хранится как -1-n
+func writeCBORInt(buffer *bytes.Buffer, value int64) {
+	if value < 0 {
+		writeCBORHead(buffer, cborNegative, uint64(-1-value))
+	} else {
+		writeCBORHead(buffer, cborUnsigned, uint64(value))
+	}
+}
+
+// writeCBORHead записывает старший тип и аргумент в самой короткой форме
+func writeCBORHead(buffer *bytes.Buffer, major byte, argument uint64) {
+	major <<= 5
+	switch {
+	case argument < 24:
+		buffer.WriteByte(major | byte(argument))
+	case argument <= math.MaxUint8:
+		buffer.Write([]byte{major | 24, byte(argument)})
+	case argument <= math.MaxUint16:
+		buffer.WriteByte(major | 25)
+		buffer.Write(binary.BigEndian.AppendUint16(nil, uint16(argument)))
+	case argument <= math.MaxUint32:
+		buffer.WriteByte(major | 26)
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(argument)))
+	default:
+		buffer.WriteByte(major | 27)
+		buffer.Write(binary.BigEndian.AppendUint64(nil, argument))
+	}
+}
+
+// decodeCBOR разбирает одно значение CBOR, занимающее все данные.
+// Числа с плавающей точкой, undefined, простые значения и метки,
+// кроме метки самоописания, отклоняются.
+func decodeCBOR(data []byte) (any, error) {
+	decoder := &portableDecoder{data: data}
+	value, err := decoder.cborValue(0)
+	if err != nil {
+		return nil, err
+	}
+	if decoder.pos != len(data) {
+		return nil, fmt.Errorf("лишние данные после значения: %d байт", len(data)-decoder.pos)
+	}
+	return value, nil
+}
+
+// cborHead читает заголовок: старший тип, аргумент и признак неопределенной длины
+func (d *portableDecoder) cborHead() (major byte, argument uint64, indefinite bool, err error) {
+	initial, err := d.byte()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	major, info := initial>>5, initial&0x1f
+	switch {
+	case info < 24:
+		return major, uint64(info), false, nil
+	case info <= 27:
+		argument, err = d.uint(1 << (info - 24))
+		return major, argument, false, err
+	case info == 31:
+		return major, 0, true, nil
+	}
+	return 0, 0, false, fmt.Errorf("зарезервированное значение дополнительной информации %d", info)
+}
+
+// cborValue разбирает значение на текущей позиции; depth - глубина вложенности
+func (d *portableDecoder) cborValue(depth int) (any, error) {
+	start := d.pos
+	major, argument, indefinite, err := d.cborHead()
+	if err != nil {
+		return nil, err
+	}
+	if indefinite && (major == cborUnsigned || major == cborNegative || major == cborTag) {
+		return nil, fmt.Errorf("неопределенная длина недопустима для старшего типа %d", major)
+	}
+	switch major {
+	case cborUnsigned:
+		if argument > math.MaxInt64 {
+			return nil, fmt.Errorf("целое число %d вне допустимого диапазона", argument)
+		}
+		return int64(argument), nil
+	case cborNegative:
+		if argument > math.MaxInt64 {
+			return nil, fmt.Errorf("целое число -1-%d вне допустимого диапазона", argument)
+		}
+		return -1 - int64(argument), nil
+	case cborBytes, cborText:
+		if indefinite {
+			return d.cborChunks(major)
+		}
+		text, err := d.string(argument)
+		if err != nil {
+			return nil, err
+		}
+		if major == cborText && !utf8.ValidString(text) {
+			return nil, fmt.Errorf("текстовая строка содержит некорректный UTF-8")
+		}
+		return text, nil
+	case cborArray:
+		return d.cborArray(argument, indefinite, depth)
+	case cborMap:
+		return d.cborMap(argument, indefinite, depth)
+	case cborTag:
+		if argument != cborSelfDescribe {
+			return nil, fmt.Errorf("метка %d не поддерживается", argument)
+		}
+		if depth >= maxPortableDepth {
+			return nil, fmt.Errorf("превышена глубина вложенности %d", maxPortableDepth)
+		}
+		return d.cborValue(depth + 1)
+	}
+	switch d.data[start] {
+	case 0xf4:
+		return false, nil
+	case 0xf5:
+		return true, nil
+	case 0xf6:
+		return nil, nil
+	case 0xf9, 0xfa, 0xfb:
+		return nil, fmt.Errorf("числа с плавающей точкой не поддерживаются")
+	case 0xff:
+		return nil, fmt.Errorf("неожиданный маркер конца (break)")
+	}
+	return nil, fmt.Errorf("простое значение %d не поддерживается", argument)
+}
+
+// cborBreak пропускает маркер конца неопределенной длины, если он следующий
+func (d *portableDecoder) cborBreak() bool {
+	if d.pos < len(d.data) && d.data[d.pos] == 0xff {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+// cborChunks склеивает строку неопределенной длины из частей того же типа
+func (d *portableDecoder) cborChunks(major byte) (any, error) {
+	var text []byte
+	for !d.cborBreak() {
+		chunkMajor, length, indefinite, err := d.cborHead()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || indefinite {
+			return nil, fmt.Errorf("часть строки неопределенной длины имеет другой тип")
+		}
+		chunk, err := d.string(length)
+		if err != nil {
+			return nil, err
+		}
+		if len(text)+len(chunk) > maxStringLength {
+			return nil, fmt.Errorf("длина строки превышает допустимую %d", maxStringLength)
+		}
+		text = append(text, chunk...)
+	}
+	if major == cborText && !utf8.Valid(text) {
+		return nil, fmt.Errorf("текстовая строка содержит некорректный UTF-8")
+	}
+	return string(text), nil
+}
+
+// cborArray разбирает массив из length элементов или до маркера конца
+func (d *portableDecoder) cborArray(length uint64, indefinite bool, depth int) (any, error) {
+	if indefinite {
+		length = 0
+	}
+	if err := d.enter(length, 1, depth); err != nil {
+		return nil, err
+	}
+	items := make([]any, 0, length)
+	for i := uint64(0); indefinite || i < length; i++ {
+		if indefinite && d.cborBreak() {
+			break
+		}
+		item, err := d.cborValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// cborMap разбирает словарь со строковыми ключами из length пар или до маркера конца
+func (d *portableDecoder) cborMap(length uint64, indefinite bool, depth int) (any, error) {
+	if indefinite {
+		length = 0
+	}
+	if err := d.enter(length, 2, depth); err != nil {
+		return nil, err
+	}
+	values := make(map[string]any, length)
+	for i := uint64(0); indefinite || i < length; i++ {
+		if indefinite && d.cborBreak() {
+			break
+		}
+		key, err := d.cborValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.cborValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := addPortableKey(values, key, value); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
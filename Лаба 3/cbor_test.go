@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCBORVectors проверяет примеры из приложения A RFC 8949
+func TestCBORVectors(t *testing.T) {
+	checkPortableVectors(t, []portableVector{
+		{int64(0), "00"},
+		{int64(23), "17"},
+		{int64(24), "1818"},
+		{int64(100), "1864"},
+		{int64(1000), "1903e8"},
+		{int64(1000000), "1a000f4240"},
+		{int64(1000000000000), "1b000000e8d4a51000"},
+		{int64(-1), "20"},
+		{int64(-10), "29"},
+		{int64(-100), "3863"},
+		{int64(-1000), "3903e7"},
+		{"", "60"},
+		{"a", "6161"},
+		{"IETF", "6449455446"},
+		{"ü", "62c3bc"},
+		{"\xff", "41ff"},
+		{false, "f4"},
+		{true, "f5"},
+		{nil, "f6"},
+		{[]any{}, "80"},
+		{[]any{int64(1), int64(2), int64(3)}, "83010203"},
+		{map[string]any{}, "a0"},
+		{map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}}, "a26161016162820203"},
+		// Детерминированный порядок: более короткий ключ раньше
+		{map[string]any{"bb": int64(1), "c": int64(2)}, "a261630262626201"},
+	}, encodeCBOR, decodeCBOR)
+}
+
+// TestCBORIndefinite проверяет разбор неопределенных длин и метки самоописания
+func TestCBORIndefinite(t *testing.T) {
+	cases := map[string]any{
+		"9fff":                       []any{},
+		"9f018202039f0405ffff":       []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}},
+		"7f657374726561646d696e67ff": "streaming",
+		"5f42010243030405ff":         "\x01\x02\x03\x04\x05",
+		"bf6346756ef563416d7421ff":   map[string]any{"Fun": true, "Amt": int64(-2)},
+		"d9d9f783010203":             []any{int64(1), int64(2), int64(3)},
+	}
+	for input, want := range cases {
+		data, _ := hex.DecodeString(input)
+		got, err := decodeCBOR(data)
+		if err != nil {
+			t.Errorf("%s: ошибка разбора: %v", input, err)
+		} else if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: получено %#v, ожидалось %#v", input, got, want)
+		}
+	}
+}
+
+// TestCBORMalformed проверяет отклонение некорректных и неподдерживаемых данных
+func TestCBORMalformed(t *testing.T) {
+	checkPortableMalformed(t, map[string]string{
+		"":                   "неожиданный конец данных",
+		"0000":               "лишние данные",
+		"1c":                 "зарезервированное значение",
+		"1f":                 "неопределенная длина недопустима",
+		"f97c00":             "плавающей точкой",
+		"f7":                 "простое значение 23",
+		"f814":               "простое значение 20",
+		"ff":                 "маркер конца",
+		"c11a514b67b0":       "метка 1 не поддерживается",
+		"62c3":               "больше оставшихся данных",
+		"62c328":             "некорректный UTF-8",
+		"7f4161ff":           "другой тип",
+		"9f01":               "неожиданный конец данных",
+		"9bffffffffffffffff": "больше оставшихся данных",
+		"1bffffffffffffffff": "вне допустимого диапазона",
+		"3bffffffffffffffff": "вне допустимого диапазона",
+		"a10101":             "ключ словаря должен быть строкой",
+		"a2616101616102":     `ключ "a" повторяется`,
+	}, decodeCBOR)
+
+	deep := []byte(strings.Repeat("\x81", maxPortableDepth+1) + "\xf6")
+	if _, err := decodeCBOR(deep); err == nil || !strings.Contains(err.Error(), "глубина") {
+		t.Errorf("Ожидалась ошибка глубины вложенности, получено %v", err)
+	}
+}
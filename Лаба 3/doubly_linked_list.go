@@ -2,10 +2,10 @@ package main
 
 import (
 	"bufio"
-	"encoding/binary"
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 )
 
 // DoublyNode представляет узел в двусвязном списке
@@ -97,6 +97,34 @@ func (dll *DoublyLinkedList) RemoveByValue(value string) {
 	}
 }
 
+// unlinkNode отсоединяет указанный узел от списка за O(1) (Private)
+func (dll *DoublyLinkedList) unlinkNode(node *DoublyNode) {
+	if node.Prev != nil {
+		node.Prev.Next = node.Next
+	} else {
+		dll.Head = node.Next
+	}
+	if node.Next != nil {
+		node.Next.Prev = node.Prev
+	} else {
+		dll.Tail = node.Prev
+	}
+	node.Next = nil
+	node.Prev = nil
+}
+
+// linkNodeToHead вставляет существующий узел в начало списка (Private)
+func (dll *DoublyLinkedList) linkNodeToHead(node *DoublyNode) {
+	node.Prev = nil
+	node.Next = dll.Head
+	if dll.Head != nil {
+		dll.Head.Prev = node
+	} else {
+		dll.Tail = node
+	}
+	dll.Head = node
+}
+
 // Search ищет узел с указанным значением в списке (Public)
 func (dll *DoublyLinkedList) Search(value string) *DoublyNode {
 	current := dll.Head
@@ -109,6 +137,15 @@ func (dll *DoublyLinkedList) Search(value string) *DoublyNode {
 	return nil
 }
 
+// Length возвращает количество элементов в списке (Public)
+func (dll *DoublyLinkedList) Length() int {
+	count := 0
+	for current := dll.Head; current != nil; current = current.Next {
+		count++
+	}
+	return count
+}
+
 // Print выводит элементы списка (Public)
 func (dll *DoublyLinkedList) Print() {
 	current := dll.Head
@@ -121,7 +158,7 @@ func (dll *DoublyLinkedList) Print() {
 
 // SaveToFile сохраняет список в файл (Public)
 func (dll *DoublyLinkedList) SaveToFile(filename string) error {
-	file, err := os.Create(filename)
+	file, err := createSnapshotFile(filename)
 	if err != nil {
 		return fmt.Errorf("не удалось создать файл: %v", err)
 	}
@@ -135,12 +172,12 @@ func (dll *DoublyLinkedList) SaveToFile(filename string) error {
 		}
 		current = current.Next
 	}
-	return nil
+	return file.Commit()
 }
 
 // LoadFromFile загружает список из файла (Public)
 func (dll *DoublyLinkedList) LoadFromFile(filename string) error {
-	file, err := os.Open(filename)
+	file, err := openSnapshotFile(filename)
 	if err != nil {
 		return fmt.Errorf("не удалось открыть файл: %v", err)
 	}
@@ -201,36 +238,143 @@ func (dll *DoublyLinkedList) DeserializeText(data string) error {
 
 // SerializeBinary сериализует двусвязный список в бинарный формат
 func (dll *DoublyLinkedList) SerializeBinary() ([]byte, error) {
-	var result []byte
-	current := dll.Head
-	for current != nil {
-		// Записываем длину строки
-		length := uint32(len(current.Data))
-		lengthBytes := make([]byte, 4)
-		binary.LittleEndian.PutUint32(lengthBytes, length)
-		result = append(result, lengthBytes...)
-
-		// Записываем строку
-		result = append(result, []byte(current.Data)...)
-		current = current.Next
+	var buffer bytes.Buffer
+	if err := dll.EncodeTo(&buffer); err != nil {
+		return nil, err
 	}
-	return result, nil
+	return buffer.Bytes(), nil
 }
 
-// DeserializeBinary десериализует двусвязный список из бинарного формата
+// DeserializeBinary десериализует двусвязный список из бинарного формата.
+// Данные без заголовка, их тип не проверяется; снимок с проверкой типа читает DeserializeSnapshot.
 func (dll *DoublyLinkedList) DeserializeBinary(data []byte) error {
-	dll.Head = nil
-	dll.Tail = nil
-	offset := 0
-	for offset < len(data) {
-		// Читаем длину строки
-		length := binary.LittleEndian.Uint32(data[offset : offset+4])
-		offset += 4
+	return dll.DecodeFrom(bytes.NewReader(data))
+}
+
+// EncodeTo записывает двусвязный список в w в бинарном формате поэлементно (Public)
+func (dll *DoublyLinkedList) EncodeTo(w io.Writer) error {
+	return encodeStrings(w, func(yield func(string) bool) {
+		for current := dll.Head; current != nil; current = current.Next {
+			if !yield(current.Data) {
+				return
+			}
+		}
+	})
+}
+
+// DecodeFrom читает двусвязный список из r в бинарном формате поэлементно.
+// При ошибке список не меняется (Public)
+func (dll *DoublyLinkedList) DecodeFrom(r io.Reader) error {
+	restored := NewDoublyLinkedList()
+	if err := decodeStrings(r, func(value string) bool {
+		restored.AddToTail(value)
+		return true
+	}); err != nil {
+		return err
+	}
+	*dll = *restored
+	return nil
+}
+
+// MarshalBinary реализует encoding.BinaryMarshaler (Public)
+func (dll *DoublyLinkedList) MarshalBinary() ([]byte, error) {
+	return dll.SerializeBinary()
+}
+
+// UnmarshalBinary реализует encoding.BinaryUnmarshaler (Public)
+func (dll *DoublyLinkedList) UnmarshalBinary(data []byte) error {
+	return dll.DeserializeBinary(data)
+}
 
-		// Читаем строку
-		strData := string(data[offset : offset+int(length)])
-		offset += int(length)
-		dll.AddToTail(strData)
+// MarshalText реализует encoding.TextMarshaler; текстовый формат - JSON (Public)
+func (dll *DoublyLinkedList) MarshalText() ([]byte, error) {
+	text, err := dll.SerializeText()
+	return []byte(text), err
+}
+
+// UnmarshalText реализует encoding.TextUnmarshaler (Public)
+func (dll *DoublyLinkedList) UnmarshalText(data []byte) error {
+	return dll.DeserializeText(string(data))
+}
+
+// MarshalJSON реализует json.Marshaler (Public)
+func (dll *DoublyLinkedList) MarshalJSON() ([]byte, error) {
+	return dll.MarshalText()
+}
+
+// UnmarshalJSON реализует json.Unmarshaler (Public)
+func (dll *DoublyLinkedList) UnmarshalJSON(data []byte) error {
+	return dll.UnmarshalText(data)
+}
+
+// portable возвращает переносимое представление двусвязного списка: массив строк, как в SerializeText (Private)
+func (dll *DoublyLinkedList) portable() any {
+	return portableStrings(func(yield func(string) bool) {
+		for current := dll.Head; current != nil; current = current.Next {
+			if !yield(current.Data) {
+				return
+			}
+		}
+	})
+}
+
+// setPortable восстанавливает список из переносимого представления; при ошибке список не меняется (Private)
+func (dll *DoublyLinkedList) setPortable(value any) error {
+	values, err := stringsFromPortable(value)
+	if err != nil {
+		return err
+	}
+	restored := NewDoublyLinkedList()
+	for _, value := range values {
+		restored.AddToTail(value)
+	}
+	*dll = *restored
+	return nil
+}
+
+// SerializeMsgPack сериализует список в MessagePack (Public)
+func (dll *DoublyLinkedList) SerializeMsgPack() ([]byte, error) {
+	return serializeMsgPack(dll)
+}
+
+// DeserializeMsgPack десериализует список из MessagePack (Public)
+func (dll *DoublyLinkedList) DeserializeMsgPack(data []byte) error {
+	return deserializeMsgPack(dll, data)
+}
+
+// SerializeCBOR сериализует список в CBOR (Public)
+func (dll *DoublyLinkedList) SerializeCBOR() ([]byte, error) {
+	return serializeCBOR(dll)
+}
+
+// DeserializeCBOR десериализует список из CBOR (Public)
+func (dll *DoublyLinkedList) DeserializeCBOR(data []byte) error {
+	return deserializeCBOR(dll, data)
+}
+
+// SerializeSnapshot сериализует двусвязный список в снимок с заголовком, типом и контрольной суммой
+func (dll *DoublyLinkedList) SerializeSnapshot() ([]byte, error) {
+	payload, err := dll.SerializeBinary()
+	if err != nil {
+		return nil, err
+	}
+	return encodeSnapshot(SnapshotDoublyList, dll.Length(), payload), nil
+}
+
+// DeserializeSnapshot восстанавливает двусвязный список из снимка. Снимок другой структуры,
+// другой версии или с неверной контрольной суммой отклоняется без изменения данных.
+func (dll *DoublyLinkedList) DeserializeSnapshot(data []byte) error {
+	count, payload, err := decodeSnapshot(data, SnapshotDoublyList)
+	if err != nil {
+		return err
+	}
+	temp := NewDoublyLinkedList()
+	if err := temp.DeserializeBinary(payload); err != nil {
+		return err
+	}
+	if err := checkSnapshotCount(SnapshotDoublyList, temp.Length(), count); err != nil {
+		return err
 	}
+	*dll = *temp
 	return nil
 }
@@ -270,3 +270,28 @@ func TestSerializeDeserializeBinarydll(t *testing.T) {
 		t.Error("Длины списков не совпадают после десериализации")
 	}
 }
+
+func TestUnlinkAndLinkNodedll(t *testing.T) {
+	dll := NewDoublyLinkedList()
+	dll.AddToTail("first")
+	dll.AddToTail("second")
+	dll.AddToTail("third")
+
+	middle := dll.Head.Next
+	dll.unlinkNode(middle)
+	if dll.Head.Next != dll.Tail || dll.Tail.Prev != dll.Head {
+		t.Errorf("unlinkNode() = %v; want list with elements ['first', 'third']", dll)
+	}
+
+	dll.linkNodeToHead(middle)
+	if dll.Head.Data != "second" || dll.Head.Next.Data != "first" || dll.Head.Next.Prev != middle {
+		t.Errorf("linkNodeToHead() = %v; want list with elements ['second', 'first', 'third']", dll)
+	}
+
+	dll.unlinkNode(dll.Tail)
+	dll.unlinkNode(dll.Head)
+	dll.unlinkNode(dll.Head)
+	if dll.Head != nil || dll.Tail != nil {
+		t.Errorf("unlinkNode() = %v; want empty list", dll)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import "fmt"
+
+// testStructures - общий для тестов набор всех структур программы: с ним работают
+// EXPORT и IMPORT, из него собираются сеанс и снимки
+type testStructures struct {
+	array      *Array
+	stack      *Stack
+	queue      *Queue
+	singlyList *SinglyLinkedList
+	doublyList *DoublyLinkedList
+	hashTable  *HashTable
+	cbTree     *BinaryTree
+	stringTree *StringTree
+	avlTree    *AVLTree
+	rbTree     *RedBlackTree
+	cache      *Cache
+	pq         *PriorityQueue
+}
+
+// emptyStructures возвращает набор пустых структур
+func emptyStructures() *testStructures {
+	return &testStructures{NewArray(10), NewStack(), NewQueue(), NewSinglyLinkedList(), NewDoublyLinkedList(),
+		NewHashTable(10), NewBinarySearchTree(), &StringTree{Ordered: true}, NewAVLTree(), NewRedBlackTree(),
+		NewCache(3, PolicyLFU), NewPriorityQueue(MinHeap)}
+}
+
+// transferSample - строки, которые ломают наивное разбиение по запятым, табуляциям и строкам
+var transferSample = []string{"plain", "a,b", `"quoted"`, "tab\there", "line\nbreak", "<&>", "", " spaces "}
+
+// fixtureValues - значения деревьев: отрицательные, на границах байтов varint и больше 2 байт
+var fixtureValues = []int{50, 30, 70, -5, 60, 0, -256, 256, 1 << 20}
+
+// filledStructures возвращает набор заполненных структур
+func filledStructures() *testStructures {
+	s := emptyStructures()
+	for _, value := range transferSample {
+		s.array.AddToTheEnd(value)
+		s.stack.Push(value)
+		s.queue.Push(value)
+		s.singlyList.AddToTail(value)
+		s.doublyList.AddToTail(value)
+		s.hashTable.HSet("k"+value, value)
+	}
+	for _, value := range fixtureValues {
+		s.cbTree.Insert(value)
+		s.avlTree.Insert(value)
+		s.rbTree.Insert(value)
+	}
+	s.cache.Set("k1", "v,1")
+	s.cache.Set("k2", "v\t2")
+	s.cache.Get("k1")
+	s.pq.Push("low", 5)
+	s.pq.Push("high", -1)
+	s.pq.Push("mid", 3)
+	return s
+}
+
+// session собирает сеанс из структур набора
+func (s *testStructures) session() *Session {
+	return &Session{
+		Array:      s.array,
+		Stack:      s.stack,
+		Queue:      s.queue,
+		SinglyList: s.singlyList,
+		DoublyList: s.doublyList,
+		HashTable:  s.hashTable,
+		BinaryTree: s.cbTree,
+	}
+}
+
+// snapshotFixture - структура со снимком и конструктор пустой структуры того же типа
+type snapshotFixture struct {
+	kind   SnapshotType
+	filled Snapshotter
+	empty  func() Snapshotter
+}
+
+// snapshotFixtures возвращает заполненные структуры всех типов снимков и конструкторы пустых
+func snapshotFixtures() []snapshotFixture {
+	s := filledStructures()
+	return []snapshotFixture{
+		{SnapshotArray, s.array, func() Snapshotter { return emptyStructures().array }},
+		{SnapshotStack, s.stack, func() Snapshotter { return emptyStructures().stack }},
+		{SnapshotQueue, s.queue, func() Snapshotter { return emptyStructures().queue }},
+		{SnapshotSinglyList, s.singlyList, func() Snapshotter { return emptyStructures().singlyList }},
+		{SnapshotDoublyList, s.doublyList, func() Snapshotter { return emptyStructures().doublyList }},
+		{SnapshotHashTable, s.hashTable, func() Snapshotter { return emptyStructures().hashTable }},
+		{SnapshotBinaryTree, s.cbTree, func() Snapshotter { return NewBinaryTree() }},
+	}
+}
+
+// fixture возвращает заполненную структуру из набора snapshotFixtures
+func fixture(kind SnapshotType) Snapshotter {
+	for _, entry := range snapshotFixtures() {
+		if entry.kind == kind {
+			return entry.filled
+		}
+	}
+	panic(fmt.Sprintf("нет структуры %s в наборе", kind))
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Цели для нативного фаззинга Go (go test -fuzz=FuzzИмя). Без флага -fuzz
+// они выполняются как обычные тесты на начальном наборе данных.
+
+// binaryCodec - структура с бинарной сериализацией
+type binaryCodec interface {
+	SerializeBinary() ([]byte, error)
+	DeserializeBinary(data []byte) error
+}
+
+// textCodec - структура с текстовой сериализацией
+type textCodec interface {
+	SerializeText() (string, error)
+	DeserializeText(data string) error
+}
+
+// fuzzDecoder проверяет декодер на произвольных данных: он не должен паниковать,
+// а принятые данные после повторной сериализации должны читаться в то же состояние.
+// decode разбирает данные и возвращает описание состояния и повторную сериализацию.
+func fuzzDecoder(f *testing.F, seeds [][]byte, decode func(data []byte) (state string, encoded []byte, err error)) {
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		state, encoded, err := decode(data)
+		if err != nil {
+			return
+		}
+		restored, _, err := decode(encoded)
+		if err != nil {
+			t.Fatalf("повторная сериализация %q не читается: %v", encoded, err)
+		}
+		if restored != state {
+			t.Errorf("состояние после повторного чтения %q, ожидалось %q", restored, state)
+		}
+	})
+}
+
+// fuzzBinaryDecoder проверяет DeserializeBinary; начальный набор - сериализация filled
+// и заведомо поврежденные данные
+func fuzzBinaryDecoder[T binaryCodec](f *testing.F, filled T, fresh func() T, state func(T) string) {
+	seed, _ := filled.SerializeBinary()
+	seeds := [][]byte{seed, seed[:len(seed)/2], nil, {0xFF, 0xFF, 0xFF, 0xFF, 'a'}, {1, 0, 0}}
+	fuzzDecoder(f, seeds, func(data []byte) (string, []byte, error) {
+		target := fresh()
+		if err := target.DeserializeBinary(data); err != nil {
+			return "", nil, err
+		}
+		encoded, _ := target.SerializeBinary()
+		return state(target), encoded, nil
+	})
+}
+
+// fuzzTextDecoder проверяет DeserializeText; состояние описывается текстовой сериализацией
+func fuzzTextDecoder[T textCodec](f *testing.F, filled T, fresh func() T) {
+	seed, _ := filled.SerializeText()
+	seeds := [][]byte{[]byte(seed), []byte(seed[:len(seed)/2]), []byte("null"), []byte("[]"), []byte(`{"Root":null}`)}
+	fuzzDecoder(f, seeds, func(data []byte) (string, []byte, error) {
+		target := fresh()
+		if err := target.DeserializeText(string(data)); err != nil {
+			return "", nil, err
+		}
+		encoded, _ := target.SerializeText()
+		return encoded, []byte(encoded), nil
+	})
+}
+
+// textState описывает состояние структуры ее текстовой сериализацией
+func textState[T textCodec](value T) string {
+	text, _ := value.SerializeText()
+	return text
+}
+
+// hashTableState описывает содержимое хэш-таблицы независимо от порядка цепочек
+func hashTableState(ht *HashTable) string {
+	var pairs []string
+	for _, head := range ht.Table {
+		for current := head; current != nil; current = current.Next {
+			pairs = append(pairs, fmt.Sprintf("%q=%q", current.Key, current.Value))
+		}
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// TestDecodersRejectMalformedInput проверяет, что некорректные данные отклоняются
+// ошибкой, а не отбрасываются молча, и что структура при этом не меняется
+func TestDecodersRejectMalformedInput(t *testing.T) {
+	filled := fixture(SnapshotArray).(*Array)
+	overflow, _ := filled.SerializeBinary()
+	overflowText, _ := filled.SerializeText()
+	treeDuplicate := []byte{1, 0, 0, 0, 1, 0, 0, 0}
+
+	cases := []struct {
+		name   string
+		target textCodec
+		decode func(target textCodec) error
+	}{
+		{"массив сверх емкости (бинарный)", NewArray(2), func(target textCodec) error {
+			return target.(*Array).DeserializeBinary(overflow)
+		}},
+		{"массив сверх емкости (текст)", NewArray(2), func(target textCodec) error {
+			return target.DeserializeText(overflowText)
+		}},
+		{"хэш-таблица без разделителя", NewHashTable(4), func(target textCodec) error {
+			return target.DeserializeText(`["k:v","broken"]`)
+		}},
+		{"хэш-таблица с повтором ключа", NewHashTable(4), func(target textCodec) error {
+			return target.DeserializeText(`["k:1","k:2"]`)
+		}},
+		{"хэш-таблица с повтором ключа (бинарный)", NewHashTable(4), func(target textCodec) error {
+			return target.(*HashTable).DeserializeBinary([]byte{1, 0, 0, 0, 'k', 0, 0, 0, 0, 1, 0, 0, 0, 'k', 0, 0, 0, 0})
+		}},
+		{"AVL-дерево с повтором", NewAVLTree(), func(target textCodec) error {
+			return target.(*AVLTree).DeserializeBinary(treeDuplicate)
+		}},
+		{"красно-черное дерево с повтором", NewRedBlackTree(), func(target textCodec) error {
+			return target.(*RedBlackTree).DeserializeBinary(treeDuplicate)
+		}},
+		{"неупорядоченное дерево поиска", NewBinaryTree(), func(target textCodec) error {
+			return target.DeserializeText(`{"Root":{"Digit":1,"Left":{"Digit":2}},"Ordered":true}`)
+		}},
+		{"строковое дерево с повтором ключа", &StringTree{}, func(target textCodec) error {
+			return target.DeserializeText(`{"Root":{"Key":"a","Left":{"Key":"a"}}}`)
+		}},
+		{"строковое дерево поиска не по порядку", &StringTree{}, func(target textCodec) error {
+			return target.DeserializeText(`{"Root":{"Key":"a","Left":{"Key":"b"}},"Ordered":true}`)
+		}},
+		{"слишком длинная строка", NewQueue(), func(target textCodec) error {
+			return target.(*Queue).DeserializeBinary([]byte{0xFF, 0xFF, 0xFF, 0x7F, 'a'})
+		}},
+		{"количество элементов снимка", NewArray(1), func(target textCodec) error {
+			return target.(*Array).DeserializeSnapshot(encodeSnapshot(SnapshotArray, 1<<30, nil))
+		}},
+	}
+	for _, tc := range cases {
+		before := textState(tc.target)
+		if err := tc.decode(tc.target); err == nil {
+			t.Errorf("%s: ожидалась ошибка", tc.name)
+		}
+		if after := textState(tc.target); after != before {
+			t.Errorf("%s: структура изменена при ошибке: %s, было %s", tc.name, after, before)
+		}
+	}
+}
+
+func FuzzArrayDeserializeBinary(f *testing.F) {
+	fuzzBinaryDecoder(f, fixture(SnapshotArray).(*Array), func() *Array { return NewArray(8) }, textState)
+}
+
+func FuzzArrayDeserializeText(f *testing.F) {
+	fuzzTextDecoder(f, fixture(SnapshotArray).(*Array), func() *Array { return NewArray(8) })
+}
+
+func FuzzStackDeserializeBinary(f *testing.F) {
+	seed, _ := fixture(SnapshotStack).(*Stack).SerializeBinary()
+	fuzzDecoder(f, [][]byte{seed, seed[:len(seed)/2], nil}, func(data []byte) (string, []byte, error) {
+		stack := NewStack()
+		if err := stack.DeserializeBinary(data); err != nil {
+			return "", nil, err
+		}
+		// Стек записывается с вершины, а читается в порядке записи
+		stack.reverse()
+		encoded, _ := stack.SerializeBinary()
+		return textState(stack), encoded, nil
+	})
+}
+
+func FuzzStackDeserializeText(f *testing.F) {
+	fuzzTextDecoder(f, fixture(SnapshotStack).(*Stack), NewStack)
+}
+
+func FuzzQueueDeserializeBinary(f *testing.F) {
+	fuzzBinaryDecoder(f, fixture(SnapshotQueue).(*Queue), NewQueue, textState)
+}
+
+func FuzzQueueDeserializeText(f *testing.F) {
+	fuzzTextDecoder(f, fixture(SnapshotQueue).(*Queue), NewQueue)
+}
+
+func FuzzSinglyListDeserializeBinary(f *testing.F) {
+	fuzzBinaryDecoder(f, fixture(SnapshotSinglyList).(*SinglyLinkedList), NewSinglyLinkedList, textState)
+}
+
+func FuzzSinglyListDeserializeText(f *testing.F) {
+	fuzzTextDecoder(f, fixture(SnapshotSinglyList).(*SinglyLinkedList), NewSinglyLinkedList)
+}
+
+func FuzzDoublyListDeserializeBinary(f *testing.F) {
+	fuzzBinaryDecoder(f, fixture(SnapshotDoublyList).(*DoublyLinkedList), NewDoublyLinkedList, textState)
+}
+
+func FuzzDoublyListDeserializeText(f *testing.F) {
+	fuzzTextDecoder(f, fixture(SnapshotDoublyList).(*DoublyLinkedList), NewDoublyLinkedList)
+}
+
+func FuzzHashTableDeserializeBinary(f *testing.F) {
+	newTable := func() *HashTable { return NewHashTable(4) }
+	fuzzBinaryDecoder(f, fixture(SnapshotHashTable).(*HashTable), newTable, hashTableState)
+}
+
+func FuzzHashTableDeserializeText(f *testing.F) {
+	seed, _ := fixture(SnapshotHashTable).(*HashTable).SerializeText()
+	fuzzDecoder(f, [][]byte{[]byte(seed), []byte(`["a"]`), []byte(`["a:1","a:2"]`)}, func(data []byte) (string, []byte, error) {
+		table := NewHashTable(4)
+		if err := table.DeserializeText(string(data)); err != nil {
+			return "", nil, err
+		}
+		encoded, _ := table.SerializeText()
+		return hashTableState(table), []byte(encoded), nil
+	})
+}
+
+func FuzzBinaryTreeDeserializeBinary(f *testing.F) {
+	fuzzBinaryDecoder(f, fixture(SnapshotBinaryTree).(*BinaryTree), NewBinaryTree, textState)
+}
+
+func FuzzBinaryTreeDeserializeText(f *testing.F) {
+	fuzzTextDecoder(f, fixture(SnapshotBinaryTree).(*BinaryTree), NewBinaryTree)
+}
+
+func FuzzAVLTreeDeserializeBinary(f *testing.F) {
+	avl := filledStructures().avlTree
+	fuzzBinaryDecoder(f, avl, NewAVLTree, textState)
+}
+
+func FuzzAVLTreeDeserializeText(f *testing.F) {
+	avl := filledStructures().avlTree
+	fuzzTextDecoder(f, avl, NewAVLTree)
+}
+
+func FuzzRedBlackTreeDeserializeBinary(f *testing.F) {
+	rb := filledStructures().rbTree
+	fuzzBinaryDecoder(f, rb, NewRedBlackTree, textState)
+}
+
+func FuzzRedBlackTreeDeserializeText(f *testing.F) {
+	rb := filledStructures().rbTree
+	fuzzTextDecoder(f, rb, NewRedBlackTree)
+}
+
+func FuzzStringTreeDeserializeText(f *testing.F) {
+	tree := NewKeyedSearchTree[string, string]()
+	tree.Put("m", "payload")
+	tree.Insert("c")
+	tree.Insert("x")
+	fuzzTextDecoder(f, tree, func() *StringTree { return &StringTree{} })
+}
+
+func FuzzDeserializeSnapshot(f *testing.F) {
+	var seeds [][]byte
+	for _, entry := range snapshotFixtures() {
+		seed, _ := entry.filled.SerializeSnapshot()
+		seeds = append(seeds, seed)
+	}
+	fuzzDecoder(f, seeds, func(data []byte) (string, []byte, error) {
+		if len(data) < snapshotHeaderSize {
+			return "", nil, fmt.Errorf("короткие данные")
+		}
+		structure, err := newSnapshotStructure(SnapshotType(data[5]))
+		if err != nil {
+			return "", nil, err
+		}
+		if err := structure.DeserializeSnapshot(data); err != nil {
+			return "", nil, err
+		}
+		encoded, _ := structure.SerializeSnapshot()
+		return string(encoded), encoded, nil
+	})
+}
+
+func FuzzSessionDeserialize(f *testing.F) {
+	seed, _ := filledStructures().session().Serialize()
+	empty, _ := emptyStructures().session().Serialize()
+	fuzzDecoder(f, [][]byte{seed, empty}, func(data []byte) (string, []byte, error) {
+		session := emptyStructures().session()
+		if err := session.Deserialize(data); err != nil {
+			return "", nil, err
+		}
+		encoded, _ := session.Serialize()
+		return string(encoded), encoded, nil
+	})
+}
+
+// fuzzPortableDecoder проверяет разбор переносимых значений; состояние - каноническая запись
+func fuzzPortableDecoder(f *testing.F, encode func(any) ([]byte, error), decode func([]byte) (any, error)) {
+	state := filledStructures()
+	var seeds [][]byte
+	for _, structure := range state.portableStructures() {
+		seed, _ := encode(structure.(portableCodec).portable())
+		seeds = append(seeds, seed)
+	}
+	fuzzDecoder(f, seeds, func(data []byte) (string, []byte, error) {
+		value, err := decode(data)
+		if err != nil {
+			return "", nil, err
+		}
+		encoded, err := encode(value)
+		if err != nil {
+			return "", nil, err
+		}
+		return string(encoded), encoded, nil
+	})
+}
+
+func FuzzDeserializeMsgPack(f *testing.F) {
+	fuzzPortableDecoder(f, encodeMsgPack, decodeMsgPack)
+}
+
+func FuzzDeserializeCBOR(f *testing.F) {
+	f.Add([]byte{0xbf, 0x61, 0x61, 0x9f, 0x01, 0xff, 0xff})
+	f.Add([]byte{0xd9, 0xd9, 0xf7, 0x7f, 0x61, 0x61, 0xff})
+	fuzzPortableDecoder(f, encodeCBOR, decodeCBOR)
+}
+
+func FuzzSnapshotCodecDecode(f *testing.F) {
+	data, _ := filledStructures().session().Serialize()
+	var seeds [][]byte
+	for _, compression := range []Compression{CompressionGzip, CompressionZlib, CompressionDeflate} {
+		seed, _ := SnapshotCodec{Compression: compression}.Encode(data)
+		seeds = append(seeds, seed)
+	}
+	encrypted, _ := SnapshotCodec{Passphrase: "secret"}.Encode(data)
+	seeds = append(seeds, encrypted, []byte(codecMagic))
+	// Пароль не задан: зашифрованные данные отклоняются до дорогой выработки ключа
+	fuzzDecoder(f, seeds, func(data []byte) (string, []byte, error) {
+		decoded, err := SnapshotCodec{}.Decode(data)
+		if err != nil {
+			return "", nil, err
+		}
+		encoded, err := SnapshotCodec{Compression: CompressionGzip}.Encode(decoded)
+		return string(decoded), encoded, err
+	})
+}
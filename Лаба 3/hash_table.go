@@ -2,11 +2,13 @@ package main
 
 import (
 	"bufio"
-	"encoding/binary"
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"strconv"
 	"strings"
+	"unsafe"
 )
 
 // HashNode представляет узел в хэш-таблице
@@ -135,32 +137,43 @@ func (ht *HashTable) HPrint() {
 	}
 }
 
-// LoadFromFile загружает хэш-таблицу из файла (Public)
+// LoadFromFile загружает хэш-таблицу из файла: по паре "ключ значение" в строке.
+// Поля в кавычках разбираются как строки Go, поэтому допускают пробелы и переводы
+// строк; значение без кавычек занимает остаток строки. При ошибке таблица не меняется (Public)
 func (ht *HashTable) LoadFromFile(filename string) error {
-	file, err := os.Open(filename)
+	file, err := openSnapshotFile(filename)
 	if err != nil {
 		return fmt.Errorf("не удалось открыть файл: %v", err)
 	}
 	defer file.Close()
 
+	var pairs [][2]string
 	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
 		line := scanner.Text()
-		parts := strings.Split(line, " ")
-		if len(parts) == 2 {
-			ht.HSet(parts[0], parts[1])
+		if line == "" {
+			continue
 		}
+		key, value, err := parseHashLine(line)
+		if err != nil {
+			return fmt.Errorf("строка %d: %v", lineNumber, err)
+		}
+		pairs = append(pairs, [2]string{key, value})
 	}
 
 	if err := scanner.Err(); err != nil {
 		return fmt.Errorf("ошибка чтения файла: %v", err)
 	}
+	for _, pair := range pairs {
+		ht.HSet(pair[0], pair[1])
+	}
 	return nil
 }
 
-// SaveToFile сохраняет хэш-таблицу в файл (Public)
+// SaveToFile сохраняет хэш-таблицу в файл; поля с пробелами, переводами строк
+// или кавычкой в начале записываются в кавычках (Public)
 func (ht *HashTable) SaveToFile(filename string) error {
-	file, err := os.Create(filename)
+	file, err := createSnapshotFile(filename)
 	if err != nil {
 		return fmt.Errorf("не удалось создать файл: %v", err)
 	}
@@ -169,14 +182,52 @@ func (ht *HashTable) SaveToFile(filename string) error {
 	for i := 0; i < ht.Capacity; i++ {
 		current := ht.Table[i]
 		for current != nil {
-			_, err := file.WriteString(fmt.Sprintf("%s %s\n", current.Key, current.Value))
+			_, err := file.WriteString(quoteHashField(current.Key) + " " + quoteHashField(current.Value) + "\n")
 			if err != nil {
 				return fmt.Errorf("ошибка записи в файл: %v", err)
 			}
 			current = current.Next
 		}
 	}
-	return nil
+	return file.Commit()
+}
+
+// quoteHashField заключает поле в кавычки, если без них строку файла нельзя разобрать однозначно (Private)
+func quoteHashField(field string) string {
+	if field == "" || strings.HasPrefix(field, `"`) || strings.ContainsAny(field, " \r\n") {
+		return strconv.Quote(field)
+	}
+	return field
+}
+
+// parseHashLine разбирает строку файла хэш-таблицы на ключ и значение (Private)
+func parseHashLine(line string) (string, string, error) {
+	var key, rest string
+	if strings.HasPrefix(line, `"`) {
+		quoted, err := strconv.QuotedPrefix(line)
+		if err != nil {
+			return "", "", fmt.Errorf("некорректный ключ в кавычках")
+		}
+		key, _ = strconv.Unquote(quoted)
+		rest = line[len(quoted):]
+	} else {
+		var found bool
+		key, rest, found = strings.Cut(line, " ")
+		if !found {
+			return "", "", fmt.Errorf("нет значения для ключа %q", key)
+		}
+		rest = " " + rest
+	}
+	rest, found := strings.CutPrefix(rest, " ")
+	if !found {
+		return "", "", fmt.Errorf("ключ и значение должны разделяться пробелом")
+	}
+	// Значение без кавычек - остаток строки, как и в файлах старого формата
+	if quoted, err := strconv.QuotedPrefix(rest); err == nil && len(quoted) == len(rest) {
+		value, _ := strconv.Unquote(quoted)
+		return key, value, nil
+	}
+	return key, rest, nil
 }
 
 // findNodeByKey вспомогательная функция для поиска узла по ключу (Private)
@@ -192,13 +243,15 @@ func (ht *HashTable) findNodeByKey(key string) *HashNode {
 	return nil
 }
 
-// SerializeText сериализует хэш-таблицу в текстовый формат (JSON)
+// SerializeText сериализует хэш-таблицу в текстовый формат: JSON-объект
+// {"ключ": "значение"} с ключами по возрастанию. Ключи и значения сохраняются
+// без потерь, в том числе с двоеточиями, пробелами и переводами строк (Public)
 func (ht *HashTable) SerializeText() (string, error) {
-	var data []string
+	data := make(map[string]string)
 	for i := 0; i < ht.Capacity; i++ {
 		current := ht.Table[i]
 		for current != nil {
-			data = append(data, fmt.Sprintf("%s:%s", current.Key, current.Value))
+			data[current.Key] = current.Value
 			current = current.Next
 		}
 	}
@@ -209,75 +262,319 @@ func (ht *HashTable) SerializeText() (string, error) {
 	return string(result), nil
 }
 
-// DeserializeText десериализует хэш-таблицу из текстового формата (JSON)
+// DeserializeText десериализует хэш-таблицу из текстового формата. Кроме
+// JSON-объекта читается и прежний формат - массив строк "ключ:значение",
+// разделенных по первому двоеточию. При ошибке таблица не меняется (Public)
 func (ht *HashTable) DeserializeText(data string) error {
-	var temp []string
-	err := json.Unmarshal([]byte(data), &temp)
+	ht.ensureTable()
+	restored := NewHashTable(ht.Capacity)
+	add := func(key, value string) error {
+		if restored.findNodeByKey(key) != nil {
+			return fmt.Errorf("ключ %q повторяется", key)
+		}
+		restored.appendPair(key, value)
+		return nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(data))
+	token, err := decoder.Token()
 	if err != nil {
 		return fmt.Errorf("ошибка десериализации из текстового формата: %v", err)
 	}
-	ht.Clear()
-	for _, pair := range temp {
-		parts := strings.Split(pair, ":")
-		if len(parts) == 2 {
-			ht.HSet(parts[0], parts[1])
+	switch token {
+	case json.Delim('{'):
+		// Объект читается по токенам, чтобы заметить повторяющиеся ключи
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return fmt.Errorf("ошибка десериализации из текстового формата: %v", err)
+			}
+			var value string
+			if err := decoder.Decode(&value); err != nil {
+				return fmt.Errorf("ошибка десериализации из текстового формата: %v", err)
+			}
+			if err := add(key.(string), value); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for i := 0; decoder.More(); i++ {
+			var pair string
+			if err := decoder.Decode(&pair); err != nil {
+				return fmt.Errorf("ошибка десериализации из текстового формата: %v", err)
+			}
+			key, value, found := strings.Cut(pair, ":")
+			if !found {
+				return fmt.Errorf("запись %d (%q) не содержит разделителя ключа и значения", i, pair)
+			}
+			if err := add(key, value); err != nil {
+				return err
+			}
+		}
+	case nil:
+		// null соответствует пустой таблице
+	default:
+		return fmt.Errorf("ошибка десериализации из текстового формата: ожидался объект или массив")
+	}
+	if token != nil {
+		if _, err := decoder.Token(); err != nil {
+			return fmt.Errorf("ошибка десериализации из текстового формата: %v", err)
 		}
 	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("ошибка десериализации из текстового формата: лишние данные")
+	}
+	*ht = *restored
 	return nil
 }
 
 // SerializeBinary сериализует хэш-таблицу в бинарный формат
 func (ht *HashTable) SerializeBinary() ([]byte, error) {
-	var result []byte
-	for i := 0; i < ht.Capacity; i++ {
-		current := ht.Table[i]
-		for current != nil {
-			// Записываем длину ключа
-			keyLength := uint32(len(current.Key))
-			keyLengthBytes := make([]byte, 4)
-			binary.LittleEndian.PutUint32(keyLengthBytes, keyLength)
-			result = append(result, keyLengthBytes...)
+	var buffer bytes.Buffer
+	if err := ht.EncodeTo(&buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
 
-			// Записываем ключ
-			result = append(result, []byte(current.Key)...)
+// DeserializeBinary десериализует хэш-таблицу из бинарного формата.
+// Данные без заголовка, их тип не проверяется; снимок с проверкой типа читает DeserializeSnapshot.
+func (ht *HashTable) DeserializeBinary(data []byte) error {
+	return ht.DecodeFrom(bytes.NewReader(data))
+}
 
-			// Записываем длину значения
-			valueLength := uint32(len(current.Value))
-			valueLengthBytes := make([]byte, 4)
-			binary.LittleEndian.PutUint32(valueLengthBytes, valueLength)
-			result = append(result, valueLengthBytes...)
+// EncodeTo записывает хэш-таблицу в w в бинарном формате: ключ и значение
+// каждой пары как две строки с длиной (Public)
+func (ht *HashTable) EncodeTo(w io.Writer) error {
+	return encodeStrings(w, func(yield func(string) bool) {
+		for i := 0; i < ht.Capacity; i++ {
+			for current := ht.Table[i]; current != nil; current = current.Next {
+				if !yield(current.Key) || !yield(current.Value) {
+					return
+				}
+			}
+		}
+	})
+}
 
-			// Записываем значение
-			result = append(result, []byte(current.Value)...)
+// DecodeFrom читает хэш-таблицу из r в бинарном формате по одной паре.
+// При ошибке таблица не меняется (Public)
+func (ht *HashTable) DecodeFrom(r io.Reader) error {
+	ht.ensureTable()
+	restored := NewHashTable(ht.Capacity)
+	var key string
+	hasKey := false
+	var duplicate error
+	err := decodeStrings(r, func(value string) bool {
+		if hasKey {
+			if restored.findNodeByKey(key) != nil {
+				duplicate = fmt.Errorf("ключ %q повторяется", key)
+				return false
+			}
+			restored.appendPair(key, value)
+		} else {
+			key = value
+		}
+		hasKey = !hasKey
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if duplicate != nil {
+		return duplicate
+	}
+	if hasKey {
+		return fmt.Errorf("недостаточно данных для чтения значения ключа %q", key)
+	}
+	*ht = *restored
+	return nil
+}
 
-			current = current.Next
+// appendPair добавляет новый ключ в конец цепочки: после чтения цепочки идут
+// в том же порядке, что и при записи, и повторная запись дает те же данные (Private)
+func (ht *HashTable) appendPair(key, value string) {
+	link := &ht.Table[ht.HashFunction(key)]
+	for *link != nil {
+		link = &(*link).Next
+	}
+	*link = &HashNode{Key: key, Value: value}
+}
+
+// ensureTable создает таблицу для нулевого значения HashTable, например при json.Unmarshal (Private)
+func (ht *HashTable) ensureTable() {
+	if ht.Capacity < 1 || len(ht.Table) != ht.Capacity {
+		*ht = *NewHashTable(max(ht.Capacity, 1))
+	}
+}
+
+// MarshalBinary реализует encoding.BinaryMarshaler (Public)
+func (ht *HashTable) MarshalBinary() ([]byte, error) {
+	return ht.SerializeBinary()
+}
+
+// UnmarshalBinary реализует encoding.BinaryUnmarshaler (Public)
+func (ht *HashTable) UnmarshalBinary(data []byte) error {
+	return ht.DeserializeBinary(data)
+}
+
+// MarshalText реализует encoding.TextMarshaler; текстовый формат - JSON (Public)
+func (ht *HashTable) MarshalText() ([]byte, error) {
+	text, err := ht.SerializeText()
+	return []byte(text), err
+}
+
+// UnmarshalText реализует encoding.TextUnmarshaler (Public)
+func (ht *HashTable) UnmarshalText(data []byte) error {
+	return ht.DeserializeText(string(data))
+}
+
+// MarshalJSON реализует json.Marshaler (Public)
+func (ht *HashTable) MarshalJSON() ([]byte, error) {
+	return ht.MarshalText()
+}
+
+// UnmarshalJSON реализует json.Unmarshaler (Public)
+func (ht *HashTable) UnmarshalJSON(data []byte) error {
+	return ht.UnmarshalText(data)
+}
+
+// portable возвращает переносимое представление хэш-таблицы: словарь
+// ключ - значение, как в SerializeText (Private)
+func (ht *HashTable) portable() any {
+	data := make(map[string]any)
+	for i := 0; i < ht.Capacity; i++ {
+		for current := ht.Table[i]; current != nil; current = current.Next {
+			data[current.Key] = current.Value
 		}
 	}
-	return result, nil
+	return data
 }
 
-// DeserializeBinary десериализует хэш-таблицу из бинарного формата
-func (ht *HashTable) DeserializeBinary(data []byte) error {
-	ht.Clear()
-	offset := 0
-	for offset < len(data) {
-		// Читаем длину ключа
-		keyLength := binary.LittleEndian.Uint32(data[offset : offset+4])
-		offset += 4
+// setPortable восстанавливает хэш-таблицу из переносимого представления.
+// При ошибке таблица не меняется (Private)
+func (ht *HashTable) setPortable(value any) error {
+	pairs, ok := value.(map[string]any)
+	if !ok && value != nil {
+		return fmt.Errorf("ожидался словарь, получено: %s", portableKind(value))
+	}
+	ht.ensureTable()
+	restored := NewHashTable(ht.Capacity)
+	for _, key := range sortedPortableKeys(pairs) {
+		text, ok := pairs[key].(string)
+		if !ok {
+			return fmt.Errorf("значение ключа %q: ожидалась строка, получено: %s", key, portableKind(pairs[key]))
+		}
+		restored.HSet(key, text)
+	}
+	*ht = *restored
+	return nil
+}
+
+// SerializeMsgPack сериализует хэш-таблицу в MessagePack (Public)
+func (ht *HashTable) SerializeMsgPack() ([]byte, error) {
+	return serializeMsgPack(ht)
+}
+
+// DeserializeMsgPack десериализует хэш-таблицу из MessagePack (Public)
+func (ht *HashTable) DeserializeMsgPack(data []byte) error {
+	return deserializeMsgPack(ht, data)
+}
 
-		// Читаем ключ
-		key := string(data[offset : offset+int(keyLength)])
-		offset += int(keyLength)
+// SerializeCBOR сериализует хэш-таблицу в CBOR (Public)
+func (ht *HashTable) SerializeCBOR() ([]byte, error) {
+	return serializeCBOR(ht)
+}
 
-		// Читаем длину значения
-		valueLength := binary.LittleEndian.Uint32(data[offset : offset+4])
-		offset += 4
+// DeserializeCBOR десериализует хэш-таблицу из CBOR (Public)
+func (ht *HashTable) DeserializeCBOR(data []byte) error {
+	return deserializeCBOR(ht, data)
+}
 
-		// Читаем значение
-		value := string(data[offset : offset+int(valueLength)])
-		offset += int(valueLength)
+// SerializeSnapshot сериализует хэш-таблицу в снимок с заголовком, типом и контрольной суммой
+func (ht *HashTable) SerializeSnapshot() ([]byte, error) {
+	payload, err := ht.SerializeBinary()
+	if err != nil {
+		return nil, err
+	}
+	return encodeSnapshot(SnapshotHashTable, ht.Size(), payload), nil
+}
 
-		ht.HSet(key, value)
+// DeserializeSnapshot восстанавливает хэш-таблицу из снимка. Снимок другой структуры,
+// другой версии или с неверной контрольной суммой отклоняется без изменения данных.
+func (ht *HashTable) DeserializeSnapshot(data []byte) error {
+	count, payload, err := decodeSnapshot(data, SnapshotHashTable)
+	if err != nil {
+		return err
+	}
+	temp := NewHashTable(ht.Capacity)
+	if err := temp.DeserializeBinary(payload); err != nil {
+		return err
 	}
+	if err := checkSnapshotCount(SnapshotHashTable, temp.Size(), count); err != nil {
+		return err
+	}
+	*ht = *temp
 	return nil
 }
+
+// HashTableStats содержит диагностическую информацию о хэш-таблице
+type HashTableStats struct {
+	Entries         int         // Количество пар ключ-значение
+	Capacity        int         // Количество корзин
+	LoadFactor      float64     // Отношение Entries к Capacity
+	LongestChain    int         // Длина самой длинной цепочки
+	ChainHistogram  map[int]int // Длина цепочки -> количество корзин с такой длиной
+	EmptyBuckets    int         // Количество пустых корзин
+	EmptyRatio      float64     // Доля пустых корзин
+	EstimatedMemory int         // Примерный объем памяти в байтах
+}
+
+// Stats собирает статистику распределения элементов по корзинам (Public)
+func (ht *HashTable) Stats() HashTableStats {
+	stats := HashTableStats{
+		Capacity:       ht.Capacity,
+		ChainHistogram: make(map[int]int),
+	}
+
+	nodeSize := int(unsafe.Sizeof(HashNode{}))
+	stats.EstimatedMemory = int(unsafe.Sizeof(*ht)) + ht.Capacity*int(unsafe.Sizeof(ht.Table[0]))
+	for i := 0; i < ht.Capacity; i++ {
+		chain := 0
+		for current := ht.Table[i]; current != nil; current = current.Next {
+			chain++
+			stats.EstimatedMemory += nodeSize + len(current.Key) + len(current.Value)
+		}
+		stats.ChainHistogram[chain]++
+		stats.Entries += chain
+		if chain == 0 {
+			stats.EmptyBuckets++
+		}
+		if chain > stats.LongestChain {
+			stats.LongestChain = chain
+		}
+	}
+
+	if ht.Capacity > 0 {
+		stats.LoadFactor = float64(stats.Entries) / float64(ht.Capacity)
+		stats.EmptyRatio = float64(stats.EmptyBuckets) / float64(ht.Capacity)
+	}
+	return stats
+}
+
+// HStats печатает статистику хэш-таблицы (Public)
+func (ht *HashTable) HStats() {
+	stats := ht.Stats()
+	fmt.Printf("Элементов: %d\n", stats.Entries)
+	fmt.Printf("Корзин: %d\n", stats.Capacity)
+	fmt.Printf("Коэффициент заполнения: %.2f\n", stats.LoadFactor)
+	fmt.Printf("Самая длинная цепочка: %d\n", stats.LongestChain)
+	fmt.Printf("Пустых корзин: %d (%.1f%%)\n", stats.EmptyBuckets, stats.EmptyRatio*100)
+	fmt.Printf("Примерный объем памяти: %d байт\n", stats.EstimatedMemory)
+	fmt.Println("Гистограмма длин цепочек:")
+	for length := 0; length <= stats.LongestChain; length++ {
+		if count, ok := stats.ChainHistogram[length]; ok {
+			fmt.Printf("  %d: %d\n", length, count)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -258,6 +260,108 @@ func TestSerializeDeserializeTextEmptyTableht(t *testing.T) {
 		t.Errorf("Ожидалось, что таблица будет пустой, но размер равен %d", newHt.Size())
 	}
 }
+
+// trickyPairs - пары с символами, которые ломали прежние текстовый и файловый форматы
+var trickyPairs = map[string]string{
+	"host:port":       "127.0.0.1:8080",
+	"с пробелом":      "значение с пробелами",
+	"":                "пустой ключ",
+	"пустое":          "",
+	`"кавычки"`:       `"в кавычках"`,
+	"перевод\nстроки": "a\r\nb",
+	"хвост":           "пробел в конце ",
+}
+
+// checkTrickyPairs проверяет, что таблица содержит ровно trickyPairs
+func checkTrickyPairs(t *testing.T, ht *HashTable) {
+	t.Helper()
+	if ht.Size() != len(trickyPairs) {
+		t.Errorf("Ожидалось %d пар, получено %d", len(trickyPairs), ht.Size())
+	}
+	for key, value := range trickyPairs {
+		if node := ht.findNodeByKey(key); node == nil || node.Value != value {
+			t.Errorf("Ключ %q: ожидалось значение %q, получен узел %+v", key, value, node)
+		}
+	}
+}
+
+func TestSerializeTextLosslessht(t *testing.T) {
+	ht := NewHashTable(10)
+	for key, value := range trickyPairs {
+		ht.HSet(key, value)
+	}
+	serialized, err := ht.SerializeText()
+	if err != nil {
+		t.Fatalf("Ошибка сериализации: %v", err)
+	}
+	newHt := NewHashTable(10)
+	if err := newHt.DeserializeText(serialized); err != nil {
+		t.Fatalf("Ошибка десериализации: %v", err)
+	}
+	checkTrickyPairs(t, newHt)
+
+	if err := newHt.DeserializeText(`{"a":"1","a":"2"}`); err == nil {
+		t.Errorf("Ожидалась ошибка для повторяющегося ключа")
+	}
+	if err := newHt.DeserializeText(`{"a":1}`); err == nil {
+		t.Errorf("Ожидалась ошибка для нестрокового значения")
+	}
+	if err := newHt.DeserializeText(`{"a":"1"} {}`); err == nil {
+		t.Errorf("Ожидалась ошибка для лишних данных")
+	}
+	checkTrickyPairs(t, newHt)
+}
+
+func TestDeserializeTextLegacyht(t *testing.T) {
+	ht := NewHashTable(10)
+	if err := ht.DeserializeText(`["key1:value1","url:http://x"]`); err != nil {
+		t.Fatalf("Ошибка чтения прежнего формата: %v", err)
+	}
+	if ht.Size() != 2 || ht.findNodeByKey("url").Value != "http://x" {
+		t.Errorf("Прежний формат прочитан неверно: размер %d", ht.Size())
+	}
+	if err := ht.DeserializeText("null"); err != nil || ht.Size() != 0 {
+		t.Errorf("null должен давать пустую таблицу: %v, размер %d", err, ht.Size())
+	}
+}
+
+func TestSaveToFileLosslessht(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "hash.txt")
+	ht := NewHashTable(10)
+	for key, value := range trickyPairs {
+		ht.HSet(key, value)
+	}
+	if err := ht.SaveToFile(filename); err != nil {
+		t.Fatalf("Ошибка сохранения: %v", err)
+	}
+	newHt := NewHashTable(10)
+	if err := newHt.LoadFromFile(filename); err != nil {
+		t.Fatalf("Ошибка загрузки: %v", err)
+	}
+	checkTrickyPairs(t, newHt)
+
+	// Простые пары записываются как раньше, а значение без кавычек занимает остаток строки
+	os.WriteFile(filename, []byte("key1 value1\nkey2 значение с пробелами\n"), 0644)
+	legacy := NewHashTable(10)
+	if err := legacy.LoadFromFile(filename); err != nil {
+		t.Fatalf("Ошибка загрузки прежнего формата: %v", err)
+	}
+	if legacy.findNodeByKey("key2").Value != "значение с пробелами" {
+		t.Errorf("Значение с пробелами прочитано неверно")
+	}
+	simple := NewHashTable(10)
+	simple.HSet("key1", "value1")
+	simple.SaveToFile(filename)
+	if data, _ := os.ReadFile(filename); string(data) != "key1 value1\n" {
+		t.Errorf("Простая пара записана как %q", data)
+	}
+
+	os.WriteFile(filename, []byte("key1 value1\nбез_значения\n"), 0644)
+	if err := legacy.LoadFromFile(filename); err == nil || !strings.Contains(err.Error(), "строка 2") {
+		t.Errorf("Ожидалась ошибка в строке 2, получено %v", err)
+	}
+}
+
 func TestSerializeDeserializeBinaryht(t *testing.T) {
 	ht := NewHashTable(10)
 	ht.HSet("key1", "value1")
@@ -314,3 +418,63 @@ func TestSerializeDeserializeBinaryEmptyTableht(t *testing.T) {
 		t.Errorf("Ожидалось, что таблица будет пустой, но размер равен %d", newHt.Size())
 	}
 }
+
+// TestDeserializeKeepsChainOrderht проверяет, что цепочки с коллизиями читаются
+// в порядке записи и повторная сериализация дает те же данные
+func TestDeserializeKeepsChainOrderht(t *testing.T) {
+	ht := NewHashTable(1)
+	for _, key := range []string{"a", "b", "c"} {
+		ht.HSet(key, "v"+key)
+	}
+	binaryData, _ := ht.SerializeBinary()
+	fromBinary := NewHashTable(1)
+	if err := fromBinary.DeserializeBinary(binaryData); err != nil {
+		t.Fatalf("Ошибка десериализации: %v", err)
+	}
+	if again, _ := fromBinary.SerializeBinary(); string(again) != string(binaryData) {
+		t.Errorf("Бинарные данные после повторной записи отличаются: %q, ожидалось %q", again, binaryData)
+	}
+
+	text, _ := ht.SerializeText()
+	fromText := NewHashTable(1)
+	if err := fromText.DeserializeText(text); err != nil {
+		t.Fatalf("Ошибка десериализации: %v", err)
+	}
+	if again, _ := fromText.SerializeText(); again != text {
+		t.Errorf("Текст после повторной записи %s, ожидалось %s", again, text)
+	}
+}
+
+func TestStatsht(t *testing.T) {
+	ht := NewHashTable(4)
+	stats := ht.Stats()
+	if stats.Entries != 0 || stats.EmptyBuckets != 4 || stats.LongestChain != 0 {
+		t.Errorf("Неверная статистика пустой таблицы: %+v", stats)
+	}
+	if stats.EmptyRatio != 1 || stats.LoadFactor != 0 {
+		t.Errorf("Ожидались доля пустых корзин 1 и коэффициент заполнения 0, получено %+v", stats)
+	}
+
+	ht = NewHashTable(1) // Все ключи попадают в одну корзину
+	ht.HSet("key1", "value1")
+	ht.HSet("key2", "value2")
+	ht.HSet("key3", "value3")
+	stats = ht.Stats()
+	if stats.Entries != 3 || stats.LongestChain != 3 || stats.EmptyBuckets != 0 {
+		t.Errorf("Неверная статистика таблицы с коллизиями: %+v", stats)
+	}
+	if stats.LoadFactor != 3 {
+		t.Errorf("Ожидался коэффициент заполнения 3, получено %.2f", stats.LoadFactor)
+	}
+	if stats.ChainHistogram[3] != 1 {
+		t.Errorf("Ожидалась одна корзина длины 3, гистограмма: %v", stats.ChainHistogram)
+	}
+	if stats.EstimatedMemory <= 0 {
+		t.Errorf("Ожидался положительный объем памяти, получено %d", stats.EstimatedMemory)
+	}
+
+	output := captureStdout(ht.HStats)
+	if !strings.Contains(output, "Самая длинная цепочка: 3") {
+		t.Errorf("HStats() вывел неожиданный результат: %s", output)
+	}
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// KeyedNode представляет узел дерева с ключом произвольного упорядоченного типа
+// и необязательной полезной нагрузкой
+type KeyedNode[K cmp.Ordered, V any] struct {
+	Key        K
+	Payload    V
+	HasPayload bool `json:",omitempty"`
+	Left       *KeyedNode[K, V]
+	Right      *KeyedNode[K, V]
+}
+
+// KeyedTree представляет бинарное дерево с ключами любого упорядоченного типа
+// (строки, целые и вещественные числа). Как и BinaryTree, по умолчанию узлы
+// заполняются по уровням, а в упорядоченном режиме дерево является деревом поиска.
+// Ключи уникальны: повторная вставка ключа заменяет его полезную нагрузку.
+type KeyedTree[K cmp.Ordered, V any] struct {
+	Root    *KeyedNode[K, V]
+	Ordered bool `json:",omitempty"`
+}
+
+// StringTree - дерево со строковыми ключами и строковой полезной нагрузкой,
+// используемое командами T для файлов с нечисловыми значениями
+type StringTree = KeyedTree[string, string]
+
+// NewKeyedTree создает новое дерево с ключами, заполняемое по уровням
+func NewKeyedTree[K cmp.Ordered, V any]() *KeyedTree[K, V] {
+	return &KeyedTree[K, V]{}
+}
+
+// NewKeyedSearchTree создает новое дерево поиска с ключами
+func NewKeyedSearchTree[K cmp.Ordered, V any]() *KeyedTree[K, V] {
+	return &KeyedTree[K, V]{Ordered: true}
+}
+
+// Insert добавляет ключ без полезной нагрузки. Если ключ уже есть, дерево не меняется.
+func (t *KeyedTree[K, V]) Insert(key K) {
+	if t.find(key) == nil {
+		t.insertNode(&KeyedNode[K, V]{Key: key})
+	}
+}
+
+// Put добавляет ключ с полезной нагрузкой или заменяет нагрузку существующего ключа
+func (t *KeyedTree[K, V]) Put(key K, payload V) {
+	if node := t.find(key); node != nil {
+		node.Payload, node.HasPayload = payload, true
+		return
+	}
+	t.insertNode(&KeyedNode[K, V]{Key: key, Payload: payload, HasPayload: true})
+}
+
+// insertNode вставляет новый узел с учетом режима дерева
+func (t *KeyedTree[K, V]) insertNode(newNode *KeyedNode[K, V]) {
+	if t.Ordered {
+		link := &t.Root
+		for *link != nil {
+			if newNode.Key < (*link).Key {
+				link = &(*link).Left
+			} else {
+				link = &(*link).Right
+			}
+		}
+		*link = newNode
+		return
+	}
+	if t.Root == nil {
+		t.Root = newNode
+		return
+	}
+	queue := []*KeyedNode[K, V]{t.Root}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current.Left == nil {
+			current.Left = newNode
+			return
+		}
+		if current.Right == nil {
+			current.Right = newNode
+			return
+		}
+		queue = append(queue, current.Left, current.Right)
+	}
+}
+
+// Contains проверяет, есть ли ключ в дереве
+func (t *KeyedTree[K, V]) Contains(key K) bool {
+	return t.find(key) != nil
+}
+
+// Get возвращает полезную нагрузку ключа. Второе значение ложно,
+// если ключа нет или нагрузка для него не задана.
+func (t *KeyedTree[K, V]) Get(key K) (V, bool) {
+	node := t.find(key)
+	if node == nil || !node.HasPayload {
+		var zero V
+		return zero, false
+	}
+	return node.Payload, true
+}
+
+// find ищет узел по ключу: за O(h) в дереве поиска, иначе обходом по уровням
+func (t *KeyedTree[K, V]) find(key K) *KeyedNode[K, V] {
+	if t.Ordered {
+		current := t.Root
+		for current != nil && current.Key != key {
+			if key < current.Key {
+				current = current.Left
+			} else {
+				current = current.Right
+			}
+		}
+		return current
+	}
+	for node := range t.nodes() {
+		if node.Key == key {
+			return node
+		}
+	}
+	return nil
+}
+
+// Delete удаляет ключ из дерева и сообщает, был ли он найден
+func (t *KeyedTree[K, V]) Delete(key K) bool {
+	if t.Ordered {
+		return t.deleteOrdered(key)
+	}
+	return t.deleteLevelOrder(key)
+}
+
+// deleteLevelOrder удаляет ключ с заменой на последний узел в порядке обхода по уровням
+func (t *KeyedTree[K, V]) deleteLevelOrder(key K) bool {
+	var target, last, lastParent *KeyedNode[K, V]
+	for node := range t.nodes() {
+		if target == nil && node.Key == key {
+			target = node
+		}
+		if node.Left != nil || node.Right != nil {
+			lastParent = node
+		}
+		last = node
+	}
+	if target == nil {
+		return false
+	}
+
+	target.Key, target.Payload, target.HasPayload = last.Key, last.Payload, last.HasPayload
+	switch {
+	case lastParent == nil:
+		t.Root = nil
+	case lastParent.Right == last:
+		lastParent.Right = nil
+	default:
+		lastParent.Left = nil
+	}
+	return true
+}
+
+// deleteOrdered удаляет ключ из дерева поиска
+func (t *KeyedTree[K, V]) deleteOrdered(key K) bool {
+	link := &t.Root
+	for *link != nil && (*link).Key != key {
+		if key < (*link).Key {
+			link = &(*link).Left
+		} else {
+			link = &(*link).Right
+		}
+	}
+	node := *link
+	if node == nil {
+		return false
+	}
+	switch {
+	case node.Left == nil:
+		*link = node.Right
+	case node.Right == nil:
+		*link = node.Left
+	default:
+		successorLink := &node.Right
+		for (*successorLink).Left != nil {
+			successorLink = &(*successorLink).Left
+		}
+		successor := *successorLink
+		node.Key, node.Payload, node.HasPayload = successor.Key, successor.Payload, successor.HasPayload
+		*successorLink = successor.Right
+	}
+	return true
+}
+
+// Len возвращает количество ключей в дереве
+func (t *KeyedTree[K, V]) Len() int {
+	count := 0
+	for range t.nodes() {
+		count++
+	}
+	return count
+}
+
+// Min возвращает наименьший ключ дерева
+func (t *KeyedTree[K, V]) Min() (K, error) {
+	var smallest K
+	if t.Root == nil {
+		return smallest, fmt.Errorf("дерево пустое")
+	}
+	if t.Ordered {
+		current := t.Root
+		for current.Left != nil {
+			current = current.Left
+		}
+		return current.Key, nil
+	}
+	smallest = t.Root.Key
+	for node := range t.nodes() {
+		smallest = min(smallest, node.Key)
+	}
+	return smallest, nil
+}
+
+// Max возвращает наибольший ключ дерева
+func (t *KeyedTree[K, V]) Max() (K, error) {
+	var largest K
+	if t.Root == nil {
+		return largest, fmt.Errorf("дерево пустое")
+	}
+	if t.Ordered {
+		current := t.Root
+		for current.Right != nil {
+			current = current.Right
+		}
+		return current.Key, nil
+	}
+	largest = t.Root.Key
+	for node := range t.nodes() {
+		largest = max(largest, node.Key)
+	}
+	return largest, nil
+}
+
+// All возвращает итератор по парам ключ-нагрузка в порядке обхода по уровням
+func (t *KeyedTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for node := range t.nodes() {
+			if !yield(node.Key, node.Payload) {
+				return
+			}
+		}
+	}
+}
+
+// nodes возвращает итератор по узлам в порядке обхода по уровням
+func (t *KeyedTree[K, V]) nodes() iter.Seq[*KeyedNode[K, V]] {
+	return func(yield func(*KeyedNode[K, V]) bool) {
+		if t.Root == nil {
+			return
+		}
+		queue := []*KeyedNode[K, V]{t.Root}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			if !yield(current) {
+				return
+			}
+			if current.Left != nil {
+				queue = append(queue, current.Left)
+			}
+			if current.Right != nil {
+				queue = append(queue, current.Right)
+			}
+		}
+	}
+}
+
+// Display печатает дерево, повернутое на 90 градусов; нагрузка выводится после двоеточия
+func (t *KeyedTree[K, V]) Display() {
+	if t.Root == nil {
+		fmt.Println("Дерево пустое.")
+		return
+	}
+	t.printNode(t.Root, 0)
+}
+
+// printNode вспомогательная функция для печати дерева
+func (t *KeyedTree[K, V]) printNode(node *KeyedNode[K, V], level int) {
+	if node != nil {
+		t.printNode(node.Right, level+1)
+		for i := 0; i < level; i++ {
+			fmt.Print("   ")
+		}
+		if node.HasPayload {
+			fmt.Printf("%v: %v\n", node.Key, node.Payload)
+		} else {
+			fmt.Println(node.Key)
+		}
+		t.printNode(node.Left, level+1)
+	}
+}
+
+// Clear удаляет все узлы из дерева
+func (t *KeyedTree[K, V]) Clear() {
+	t.Root = nil
+}
+
+// LoadFromFile загружает дерево из файла. Каждая строка содержит ключ
+// и, через табуляцию, необязательную полезную нагрузку.
+func (t *KeyedTree[K, V]) LoadFromFile(file string) error {
+	t.Clear()
+	f, err := openSnapshotFile(file)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть файл: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		keyText, payloadText, hasPayload := strings.Cut(scanner.Text(), "\t")
+		key, err := parseKeyed[K](keyText)
+		if err != nil {
+			return fmt.Errorf("недопустимый ключ в файле: %v", err)
+		}
+		if !hasPayload {
+			t.Insert(key)
+			continue
+		}
+		payload, err := parseKeyed[V](payloadText)
+		if err != nil {
+			return fmt.Errorf("недопустимая нагрузка в файле: %v", err)
+		}
+		t.Put(key, payload)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения файла: %v", err)
+	}
+	return nil
+}
+
+// SaveToFile сохраняет дерево в файл по уровням
+func (t *KeyedTree[K, V]) SaveToFile(file string) error {
+	f, err := createSnapshotFile(file)
+	if err != nil {
+		return fmt.Errorf("не удалось создать файл: %v", err)
+	}
+	defer f.Close()
+
+	for node := range t.nodes() {
+		line := fmt.Sprint(node.Key)
+		if node.HasPayload {
+			line += "\t" + fmt.Sprint(node.Payload)
+		}
+		if _, err := f.WriteString(line + "\n"); err != nil {
+			return fmt.Errorf("ошибка записи в файл: %v", err)
+		}
+	}
+	return f.Commit()
+}
+
+// SerializeText сериализует дерево в текстовый формат (JSON)
+func (t *KeyedTree[K, V]) SerializeText() (string, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return "", fmt.Errorf("ошибка сериализации в текстовый формат: %v", err)
+	}
+	return string(data), nil
+}
+
+// DeserializeText десериализует дерево из текстового формата (JSON).
+// Дерево с повторяющимися ключами или упорядоченное дерево, нарушающее
+// порядок дерева поиска, отклоняется; при ошибке дерево не меняется.
+func (t *KeyedTree[K, V]) DeserializeText(data string) error {
+	var temp KeyedTree[K, V]
+	if err := json.Unmarshal([]byte(data), &temp); err != nil {
+		return fmt.Errorf("ошибка десериализации из текстового формата: %v", err)
+	}
+	if err := temp.validate(); err != nil {
+		return err
+	}
+	*t = temp
+	return nil
+}
+
+// validate проверяет уникальность ключей, а в упорядоченном режиме - что
+// ключи в симметричном обходе строго возрастают
+func (t *KeyedTree[K, V]) validate() error {
+	if t.Ordered {
+		var stack []*KeyedNode[K, V]
+		var previous *KeyedNode[K, V]
+		for current := t.Root; current != nil || len(stack) > 0; current = current.Right {
+			for ; current != nil; current = current.Left {
+				stack = append(stack, current)
+			}
+			current = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if previous != nil && !(previous.Key < current.Key) {
+				return fmt.Errorf("упорядоченное дерево нарушает порядок дерева поиска у ключа %v", current.Key)
+			}
+			previous = current
+		}
+		return nil
+	}
+	seen := make(map[K]bool)
+	for node := range t.nodes() {
+		if seen[node.Key] {
+			return fmt.Errorf("ключ %v повторяется", node.Key)
+		}
+		seen[node.Key] = true
+	}
+	return nil
+}
+
+// StringTreeFromBinaryTree создает строковое дерево с ключами из целочисленного
+// дерева, сохраняя режим и порядок обхода по уровням
+func StringTreeFromBinaryTree(bt *BinaryTree) *StringTree {
+	tree := &StringTree{Ordered: bt.Ordered}
+	for value := range bt.LevelOrder() {
+		tree.Insert(strconv.Itoa(value))
+	}
+	return tree
+}
+
+// parseKeyed разбирает строку в значение типа T: строки берутся как есть,
+// целые и вещественные числа разбираются целиком, прочие типы - через fmt.Sscan
+func parseKeyed[T any](text string) (T, error) {
+	var value T
+	var err error
+	switch p := any(&value).(type) {
+	case *string:
+		*p = text
+	case *int:
+		*p, err = strconv.Atoi(text)
+	case *float64:
+		*p, err = strconv.ParseFloat(text, 64)
+	default:
+		_, err = fmt.Sscan(text, &value)
+	}
+	return value, err
+}
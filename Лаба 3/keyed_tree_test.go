@@ -0,0 +1,161 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestKeyedTreeInsertAndGet(t *testing.T) {
+	tree := NewKeyedTree[string, string]()
+	tree.Insert("HELLO")
+	tree.Put("world", "payload")
+	tree.Insert("abc")
+	tree.Insert("HELLO")
+
+	if tree.Len() != 3 {
+		t.Errorf("Len() = %d; want 3", tree.Len())
+	}
+	if !tree.Contains("HELLO") || tree.Contains("missing") {
+		t.Errorf("Contains() returned wrong result")
+	}
+	if _, ok := tree.Get("HELLO"); ok {
+		t.Errorf("Get(HELLO) ok = true; want false for key without payload")
+	}
+	if payload, ok := tree.Get("world"); !ok || payload != "payload" {
+		t.Errorf("Get(world) = %q, %v; want payload", payload, ok)
+	}
+	tree.Put("HELLO", "greeting")
+	if payload, ok := tree.Get("HELLO"); !ok || payload != "greeting" || tree.Len() != 3 {
+		t.Errorf("Put() on existing key: Get = %q, %v, Len = %d", payload, ok, tree.Len())
+	}
+	if tree.Root.Key != "HELLO" || tree.Root.Left.Key != "world" || tree.Root.Right.Key != "abc" {
+		t.Errorf("level-order insertion produced unexpected shape")
+	}
+}
+
+func TestKeyedSearchTree(t *testing.T) {
+	tree := NewKeyedSearchTree[float64, int]()
+	for i, key := range []float64{2.5, -1, 10, 0.5, 3} {
+		tree.Put(key, i)
+	}
+	var keys []float64
+	for key := range tree.All() {
+		keys = append(keys, key)
+	}
+	if !slices.Equal(keys, []float64{2.5, -1, 10, 0.5, 3}) {
+		t.Errorf("All() = %v", keys)
+	}
+	if smallest, _ := tree.Min(); smallest != -1 {
+		t.Errorf("Min() = %v; want -1", smallest)
+	}
+	if largest, _ := tree.Max(); largest != 10 {
+		t.Errorf("Max() = %v; want 10", largest)
+	}
+	if !tree.Delete(2.5) || tree.Contains(2.5) || tree.Root.Key != 3 {
+		t.Errorf("Delete(2.5) did not replace the root with its successor")
+	}
+	if payload, ok := tree.Get(10); !ok || payload != 2 {
+		t.Errorf("Get(10) = %d, %v; want 2", payload, ok)
+	}
+	if tree.Delete(42) {
+		t.Errorf("Delete(42) = true; want false")
+	}
+}
+
+func TestKeyedTreeDeleteLevelOrder(t *testing.T) {
+	tree := NewKeyedTree[string, int]()
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		tree.Put(key, i)
+	}
+	if !tree.Delete("b") {
+		t.Fatalf("Delete(b) = false; want true")
+	}
+	if tree.Root.Left.Key != "e" || tree.Root.Left.Right != nil {
+		t.Errorf("Delete(b) did not move the last node into its place")
+	}
+	if payload, _ := tree.Get("e"); payload != 4 {
+		t.Errorf("Get(e) = %d; want payload to move with the key", payload)
+	}
+	for _, key := range []string{"a", "c", "d", "e"} {
+		tree.Delete(key)
+	}
+	if tree.Root != nil {
+		t.Errorf("tree is not empty after deleting all keys")
+	}
+	if _, err := tree.Min(); err == nil {
+		t.Errorf("Min() on empty tree error = nil")
+	}
+}
+
+func TestKeyedTreeSaveLoad(t *testing.T) {
+	filename := "test_keyed_tree.txt"
+	defer os.Remove(filename)
+
+	tree := NewKeyedTree[string, string]()
+	tree.Insert("HELLO")
+	tree.Put("key", "value with spaces")
+	tree.Insert("42")
+	if err := tree.SaveToFile(filename); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	loaded := NewKeyedTree[string, string]()
+	if err := loaded.LoadFromFile(filename); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if loaded.Len() != 3 || loaded.Root.Key != "HELLO" {
+		t.Errorf("LoadFromFile() produced a different tree")
+	}
+	if payload, ok := loaded.Get("key"); !ok || payload != "value with spaces" {
+		t.Errorf("Get(key) = %q, %v after load", payload, ok)
+	}
+
+	// Целочисленные ключи загружаются из файла целочисленного дерева
+	os.WriteFile(filename, []byte("10\n5\n15\n"), 0644)
+	ints := NewKeyedSearchTree[int, string]()
+	if err := ints.LoadFromFile(filename); err != nil || ints.Root.Key != 10 || ints.Len() != 3 {
+		t.Errorf("LoadFromFile() of integer file = %v", err)
+	}
+	os.WriteFile(filename, []byte("10\nHELLO\n"), 0644)
+	if err := ints.LoadFromFile(filename); err == nil {
+		t.Errorf("LoadFromFile() error = nil; want error for non-numeric key")
+	}
+	if err := ints.LoadFromFile("nonexistent_keyed.txt"); err == nil {
+		t.Errorf("LoadFromFile() error = nil; want error for missing file")
+	}
+}
+
+func TestKeyedTreeSerializeText(t *testing.T) {
+	tree := NewKeyedSearchTree[string, string]()
+	tree.Put("b", "2")
+	tree.Insert("a")
+	data, err := tree.SerializeText()
+	if err != nil {
+		t.Fatalf("SerializeText() error = %v", err)
+	}
+	restored := NewKeyedTree[string, string]()
+	if err := restored.DeserializeText(data); err != nil {
+		t.Fatalf("DeserializeText() error = %v", err)
+	}
+	if !restored.Ordered || restored.Root.Key != "b" || restored.Root.Left.Key != "a" || restored.Root.Left.HasPayload {
+		t.Errorf("DeserializeText() = %s", data)
+	}
+	if err := restored.DeserializeText("{"); err == nil {
+		t.Errorf("DeserializeText() error = nil; want error for invalid JSON")
+	}
+}
+
+func TestStringTreeFromBinaryTree(t *testing.T) {
+	bt := NewBinarySearchTree()
+	for _, v := range []int{10, 5, 15} {
+		bt.Insert(v)
+	}
+	tree := StringTreeFromBinaryTree(bt)
+	tree.Insert("HELLO")
+	if !tree.Ordered || tree.Len() != 4 || !tree.Contains("15") {
+		t.Errorf("StringTreeFromBinaryTree() lost values")
+	}
+	if largest, _ := tree.Max(); largest != "HELLO" {
+		t.Errorf("Max() = %q; want HELLO", largest)
+	}
+}
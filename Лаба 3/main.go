@@ -8,9 +8,14 @@ import (
 	"strings"
 )
 
-func processQuery(query string, array *Array, stack *Stack, queue *Queue, singlyList *SinglyLinkedList, doublyList *DoublyLinkedList, hashTable *HashTable, cbTree *BinaryTree, filename string) {
+func processQuery(query string, array *Array, stack *Stack, queue *Queue, singlyList *SinglyLinkedList, doublyList *DoublyLinkedList, hashTable *HashTable, cbTree *BinaryTree, stringTree *StringTree, history *PersistentTree, avlTree *AVLTree, rbTree *RedBlackTree, cache *Cache, priorityQueue *PriorityQueue, filename string) {
 	tokens := strings.Split(query, " ")
 
+	if usesStringTree(tokens, cbTree, stringTree) {
+		processStringTreeQuery(tokens, stringTree)
+		return
+	}
+
 	switch tokens[0] {
 	case "MPUSH":
 		if len(tokens) == 3 {
@@ -50,7 +55,7 @@ func processQuery(query string, array *Array, stack *Stack, queue *Queue, singly
 			fmt.Println("Ошибка сериализации:", err)
 		} else {
 			// Сохраняем сериализованные данные в файл
-			err = os.WriteFile(filename, []byte(serializedData), 0644)
+			err = writeFileAtomic(filename, []byte(serializedData))
 			if err != nil {
 				fmt.Println("Ошибка записи в файл:", err)
 			} else {
@@ -173,6 +178,29 @@ func processQuery(query string, array *Array, stack *Stack, queue *Queue, singly
 		}
 	case "HPRINT":
 		hashTable.HPrint()
+	case "HSTATS":
+		hashTable.HStats()
+	case "CSET":
+		if len(tokens) == 3 {
+			key := tokens[1]
+			value := tokens[2]
+			cache.Set(key, value)
+		} else {
+			fmt.Println("Ошибка: команда CSET требует 2 аргумента.")
+		}
+	case "CGET":
+		if len(tokens) == 2 {
+			key := tokens[1]
+			if value, ok := cache.Get(key); ok {
+				fmt.Printf("Значение для ключа [%s]: %s\n", key, value)
+			} else {
+				fmt.Printf("Ключ [%s] не найден в кэше.\n", key)
+			}
+		} else {
+			fmt.Println("Ошибка: команда CGET требует 1 аргумент.")
+		}
+	case "CSTATS":
+		cache.CStats()
 	case "TINSERT":
 		if len(tokens) == 2 {
 			digit, _ := strconv.Atoi(tokens[1])
@@ -180,6 +208,15 @@ func processQuery(query string, array *Array, stack *Stack, queue *Queue, singly
 		} else {
 			fmt.Println("Ошибка: команда TINSERT требует 1 аргумент.")
 		}
+	case "TDEL":
+		if len(tokens) == 2 {
+			digit, _ := strconv.Atoi(tokens[1])
+			if !cbTree.Delete(digit) {
+				fmt.Printf("Значение %d не найдено в дереве.\n", digit)
+			}
+		} else {
+			fmt.Println("Ошибка: команда TDEL требует 1 аргумент.")
+		}
 	case "TISCBT":
 		if cbTree.IsComplete() {
 			fmt.Println("Дерево является полным двоичным деревом.")
@@ -198,7 +235,296 @@ func processQuery(query string, array *Array, stack *Stack, queue *Queue, singly
 			fmt.Println("Ошибка: команда TFIND требует 1 аргумент.")
 		}
 	case "TDISPLAY":
-		cbTree.Display()
+		format := ""
+		if len(tokens) == 3 && tokens[1] == "--format" {
+			format = tokens[2]
+		} else if len(tokens) == 2 && strings.HasPrefix(tokens[1], "--format=") {
+			format = strings.TrimPrefix(tokens[1], "--format=")
+		} else if len(tokens) != 1 {
+			fmt.Println("Ошибка: команда TDISPLAY принимает только параметр --format.")
+			break
+		}
+		if format == "" {
+			cbTree.Display()
+		} else if cbTree.Root == nil {
+			fmt.Println("Дерево пустое.")
+		} else if output, err := cbTree.Render(format); err != nil {
+			fmt.Println("Ошибка:", err)
+		} else {
+			fmt.Print(output)
+		}
+	case "TTRAVERSE":
+		if len(tokens) == 2 {
+			if err := cbTree.PrintTraversal(tokens[1]); err != nil {
+				fmt.Println("Ошибка:", err)
+			}
+		} else {
+			fmt.Println("Ошибка: команда TTRAVERSE требует 1 аргумент.")
+		}
+	case "TBUILD":
+		var built *BinaryTree
+		var err error
+		if len(tokens) == 3 && tokens[1] == "level" {
+			built, err = ParseLevelOrder(tokens[2])
+		} else if len(tokens) == 5 && tokens[3] == "in" && (tokens[1] == "pre" || tokens[1] == "post") {
+			order, orderErr := ParseIntList(tokens[2])
+			inorder, inErr := ParseIntList(tokens[4])
+			if orderErr != nil || inErr != nil {
+				fmt.Println("Ошибка: значения обходов должны быть целыми числами через запятую.")
+				break
+			}
+			if tokens[1] == "pre" {
+				built, err = NewBinaryTreeFromPreIn(order, inorder)
+			} else {
+				built, err = NewBinaryTreeFromPostIn(order, inorder)
+			}
+		} else {
+			fmt.Println("Ошибка: используйте TBUILD pre|post <значения> in <значения> или TBUILD level [..].")
+			break
+		}
+		if err == nil {
+			err = cbTree.Replace(built)
+		}
+		if err != nil {
+			fmt.Println("Ошибка:", err)
+		} else {
+			cbTree.Display()
+		}
+	case "TVERSIONS":
+		history.PrintVersions()
+	case "TCHECKOUT":
+		if len(tokens) == 2 {
+			n, _ := strconv.Atoi(tokens[1])
+			tree, err := history.Tree(n)
+			if err != nil {
+				fmt.Println("Ошибка:", err)
+				break
+			}
+			*cbTree = *tree
+			history.Checkout(n)
+			cbTree.Display()
+		} else {
+			fmt.Println("Ошибка: команда TCHECKOUT требует 1 аргумент.")
+		}
+	case "TDIFF":
+		if len(tokens) == 3 {
+			a, _ := strconv.Atoi(tokens[1])
+			b, _ := strconv.Atoi(tokens[2])
+			if added, removed, err := history.Diff(a, b); err != nil {
+				fmt.Println("Ошибка:", err)
+			} else {
+				fmt.Println("Добавлены:", added)
+				fmt.Println("Удалены:", removed)
+			}
+		} else {
+			fmt.Println("Ошибка: команда TDIFF требует 2 аргумента.")
+		}
+	case "TEXPORT":
+		if len(tokens) == 2 {
+			if output, err := cbTree.Export(tokens[1]); err != nil {
+				fmt.Println("Ошибка:", err)
+			} else {
+				fmt.Println(output)
+			}
+		} else {
+			fmt.Println("Ошибка: команда TEXPORT требует 1 аргумент.")
+		}
+	case "TGET":
+		if len(tokens) == 2 {
+			index, _ := strconv.Atoi(tokens[1])
+			if value, err := cbTree.At(index); err != nil {
+				fmt.Println("Ошибка:", err)
+			} else {
+				fmt.Printf("Элемент по индексу %d: %d\n", index, value)
+			}
+		} else {
+			fmt.Println("Ошибка: команда TGET требует 1 аргумент.")
+		}
+	case "TSET":
+		if len(tokens) == 3 {
+			index, _ := strconv.Atoi(tokens[1])
+			value, _ := strconv.Atoi(tokens[2])
+			if err := cbTree.Set(index, value); err != nil {
+				fmt.Println("Ошибка:", err)
+			}
+		} else {
+			fmt.Println("Ошибка: команда TSET требует 2 аргумента.")
+		}
+	case "TINFO":
+		cbTree.PrintInfo()
+	case "TPATH":
+		if len(tokens) == 2 {
+			value, _ := strconv.Atoi(tokens[1])
+			if path, err := cbTree.PathTo(value); err != nil {
+				fmt.Println("Ошибка:", err)
+			} else {
+				fmt.Printf("Путь до %d: %v\n", value, path)
+			}
+		} else {
+			fmt.Println("Ошибка: команда TPATH требует 1 аргумент.")
+		}
+	case "TLCA":
+		if len(tokens) == 3 {
+			a, _ := strconv.Atoi(tokens[1])
+			b, _ := strconv.Atoi(tokens[2])
+			if lca, err := cbTree.LowestCommonAncestor(a, b); err != nil {
+				fmt.Println("Ошибка:", err)
+			} else {
+				fmt.Printf("Наименьший общий предок %d и %d: %d\n", a, b, lca)
+			}
+		} else {
+			fmt.Println("Ошибка: команда TLCA требует 2 аргумента.")
+		}
+	case "TMIN":
+		if value, err := cbTree.Min(); err != nil {
+			fmt.Println("Ошибка:", err)
+		} else {
+			fmt.Printf("Минимальное значение: %d\n", value)
+		}
+	case "TMAX":
+		if value, err := cbTree.Max(); err != nil {
+			fmt.Println("Ошибка:", err)
+		} else {
+			fmt.Printf("Максимальное значение: %d\n", value)
+		}
+	case "TSUCC":
+		if len(tokens) == 2 {
+			value, _ := strconv.Atoi(tokens[1])
+			if next, err := cbTree.Successor(value); err != nil {
+				fmt.Println("Ошибка:", err)
+			} else {
+				fmt.Printf("Следующее значение после %d: %d\n", value, next)
+			}
+		} else {
+			fmt.Println("Ошибка: команда TSUCC требует 1 аргумент.")
+		}
+	case "TPRED":
+		if len(tokens) == 2 {
+			value, _ := strconv.Atoi(tokens[1])
+			if prev, err := cbTree.Predecessor(value); err != nil {
+				fmt.Println("Ошибка:", err)
+			} else {
+				fmt.Printf("Предыдущее значение перед %d: %d\n", value, prev)
+			}
+		} else {
+			fmt.Println("Ошибка: команда TPRED требует 1 аргумент.")
+		}
+	case "AVLINSERT":
+		if len(tokens) == 2 {
+			digit, _ := strconv.Atoi(tokens[1])
+			avlTree.Insert(digit)
+		} else {
+			fmt.Println("Ошибка: команда AVLINSERT требует 1 аргумент.")
+		}
+	case "AVLDEL":
+		if len(tokens) == 2 {
+			digit, _ := strconv.Atoi(tokens[1])
+			if !avlTree.Delete(digit) {
+				fmt.Printf("Значение %d не найдено в дереве.\n", digit)
+			}
+		} else {
+			fmt.Println("Ошибка: команда AVLDEL требует 1 аргумент.")
+		}
+	case "AVLFIND":
+		if len(tokens) == 2 {
+			value, _ := strconv.Atoi(tokens[1])
+			if avlTree.FindValue(value) {
+				fmt.Printf("Значение %d найдено в AVL-дереве.\n", value)
+			} else {
+				fmt.Printf("Значение %d не найдено в AVL-дереве.\n", value)
+			}
+		} else {
+			fmt.Println("Ошибка: команда AVLFIND требует 1 аргумент.")
+		}
+	case "AVLDISPLAY":
+		avlTree.Display()
+	case "AVLCHECK":
+		if err := avlTree.CheckInvariants(); err != nil {
+			fmt.Println("Инварианты нарушены:", err)
+		} else {
+			fmt.Printf("Инварианты соблюдены, высота дерева: %d\n", avlTree.Height())
+		}
+	case "RBINSERT":
+		if len(tokens) == 2 {
+			digit, _ := strconv.Atoi(tokens[1])
+			rbTree.Insert(digit)
+		} else {
+			fmt.Println("Ошибка: команда RBINSERT требует 1 аргумент.")
+		}
+	case "RBDEL":
+		if len(tokens) == 2 {
+			digit, _ := strconv.Atoi(tokens[1])
+			if !rbTree.Delete(digit) {
+				fmt.Printf("Значение %d не найдено в дереве.\n", digit)
+			}
+		} else {
+			fmt.Println("Ошибка: команда RBDEL требует 1 аргумент.")
+		}
+	case "RBFIND":
+		if len(tokens) == 2 {
+			value, _ := strconv.Atoi(tokens[1])
+			if rbTree.FindValue(value) {
+				fmt.Printf("Значение %d найдено в красно-черном дереве.\n", value)
+			} else {
+				fmt.Printf("Значение %d не найдено в красно-черном дереве.\n", value)
+			}
+		} else {
+			fmt.Println("Ошибка: команда RBFIND требует 1 аргумент.")
+		}
+	case "RBDISPLAY":
+		rbTree.Display()
+	case "RBCHECK":
+		if err := rbTree.CheckInvariants(); err != nil {
+			fmt.Println("Инварианты нарушены:", err)
+		} else {
+			fmt.Printf("Инварианты соблюдены, высота дерева: %d\n", rbTree.Height())
+		}
+	case "RANGE", "RANK", "SELECT", "COUNT":
+		processOrderQuery(tokens[0], tokens[1:], cbTree)
+	case "AVLRANGE", "AVLRANK", "AVLSELECT", "AVLCOUNT":
+		processOrderQuery(strings.TrimPrefix(tokens[0], "AVL"), tokens[1:], avlTree)
+	case "RBRANGE", "RBRANK", "RBSELECT", "RBCOUNT":
+		processOrderQuery(strings.TrimPrefix(tokens[0], "RB"), tokens[1:], rbTree)
+	case "PQPUSH":
+		if len(tokens) == 3 {
+			value := tokens[1]
+			priority, err := strconv.Atoi(tokens[2])
+			if err != nil {
+				fmt.Println("Ошибка: приоритет должен быть целым числом.")
+				break
+			}
+			priorityQueue.Push(value, priority)
+		} else {
+			fmt.Println("Ошибка: команда PQPUSH требует 2 аргумента.")
+		}
+	case "PQPOP":
+		if item, err := priorityQueue.Pop(); err != nil {
+			fmt.Println("Ошибка:", err)
+		} else {
+			fmt.Printf("Извлечен элемент %s с приоритетом %d\n", item.Value, item.Priority)
+		}
+	case "PQPEEK":
+		if item, err := priorityQueue.Peek(); err != nil {
+			fmt.Println("Ошибка:", err)
+		} else {
+			fmt.Printf("Верхний элемент %s с приоритетом %d\n", item.Value, item.Priority)
+		}
+	case "PQDECREASE":
+		if len(tokens) == 3 {
+			value := tokens[1]
+			priority, err := strconv.Atoi(tokens[2])
+			if err != nil {
+				fmt.Println("Ошибка: приоритет должен быть целым числом.")
+				break
+			}
+			if err := priorityQueue.DecreaseKey(value, priority); err != nil {
+				fmt.Println("Ошибка:", err)
+			}
+		} else {
+			fmt.Println("Ошибка: команда PQDECREASE требует 2 аргумента.")
+		}
+	case "PQPRINT":
+		priorityQueue.Print()
 	case "PRINT":
 		array.Print()
 		stack.Print()
@@ -206,13 +532,201 @@ func processQuery(query string, array *Array, stack *Stack, queue *Queue, singly
 		singlyList.Print()
 		doublyList.Print()
 		hashTable.HPrint()
+	case "EXPORT", "IMPORT":
+		if len(tokens) == 4 {
+			processTransferQuery(tokens, array, stack, queue, singlyList, doublyList, hashTable, cbTree, stringTree, avlTree, rbTree, cache, priorityQueue)
+		} else {
+			fmt.Printf("Ошибка: команда %s требует 3 аргумента: структура, формат и файл.\n", tokens[0])
+		}
+	case "SAVEALL", "LOADALL":
+		if len(tokens) == 2 {
+			session := &Session{Array: array, Stack: stack, Queue: queue, SinglyList: singlyList, DoublyList: doublyList, HashTable: hashTable, BinaryTree: cbTree, Codec: fileCodec}
+			processSessionQuery(tokens[0], tokens[1], session)
+		} else {
+			fmt.Printf("Ошибка: команда %s требует 1 аргумент.\n", tokens[0])
+		}
 	default:
 		fmt.Printf("Неизвестная команда: %s\n", tokens[0])
 	}
 }
 
+// sessionCommands - команды сохранения и восстановления всего сеанса.
+// Они работают с собственным файлом, поэтому файл из --file для них не загружается,
+// и выполняются только с --session или --aof, которые хранят структуры между запусками.
+var sessionCommands = map[string]bool{"SAVEALL": true, "LOADALL": true}
+
+// processSessionQuery выполняет SAVEALL file и LOADALL file
+func processSessionQuery(command, filename string, session *Session) {
+	if command == "SAVEALL" {
+		if err := session.SaveToFile(filename); err != nil {
+			fmt.Println("Ошибка:", err)
+			return
+		}
+		fmt.Printf("Сеанс сохранен в файл %s\n", filename)
+		return
+	}
+	if err := session.LoadFromFile(filename); err != nil {
+		fmt.Println("Ошибка:", err)
+		return
+	}
+	fmt.Printf("Сеанс загружен из файла %s\n", filename)
+	for name, structure := range session.Named {
+		fmt.Printf("Именованная структура %s: %T\n", name, structure)
+	}
+}
+
+// processTransferQuery выполняет EXPORT structure format file и IMPORT structure format file
+func processTransferQuery(tokens []string, array *Array, stack *Stack, queue *Queue, singlyList *SinglyLinkedList, doublyList *DoublyLinkedList, hashTable *HashTable, cbTree *BinaryTree, stringTree *StringTree, avlTree *AVLTree, rbTree *RedBlackTree, cache *Cache, priorityQueue *PriorityQueue) {
+	command, name, filename := tokens[0], tokens[1], tokens[3]
+	format, err := ParseTransferFormat(tokens[2])
+	if err != nil {
+		fmt.Println("Ошибка:", err)
+		return
+	}
+	target, err := transferTargetFor(name, array, stack, queue, singlyList, doublyList, hashTable, cbTree, stringTree, avlTree, rbTree, cache, priorityQueue)
+	if err != nil {
+		fmt.Println("Ошибка:", err)
+		return
+	}
+	if command == "EXPORT" {
+		count, err := target.ExportToFile(filename, format)
+		if err != nil {
+			fmt.Println("Ошибка:", err)
+			return
+		}
+		fmt.Printf("Структура %s выгружена в файл %s (%s), строк: %d\n", name, filename, format, count)
+		return
+	}
+	count, err := target.ImportFromFile(filename, format)
+	if err != nil {
+		fmt.Printf("Ошибка импорта из %s: %v\n", filename, err)
+		return
+	}
+	fmt.Printf("Структура %s загружена из файла %s (%s), строк: %d\n", name, filename, format, count)
+}
+
+// orderQueryCommands - команды порядковых статистик для дерева из команд T.
+// Они не начинаются с T, поэтому файл для них выбирается отдельно.
+var orderQueryCommands = map[string]bool{"RANGE": true, "RANK": true, "SELECT": true, "COUNT": true}
+
+// processOrderQuery выполняет запросы по диапазонам и порядковые статистики:
+// RANGE lo hi, RANK x, SELECT k и COUNT lo hi
+func processOrderQuery(command string, args []string, tree OrderStatistics) {
+	values := make([]int, len(args))
+	for i, arg := range args {
+		value, err := strconv.Atoi(arg)
+		if err != nil {
+			fmt.Println("Ошибка: аргументы должны быть целыми числами.")
+			return
+		}
+		values[i] = value
+	}
+	switch command {
+	case "RANGE":
+		if len(values) == 2 {
+			fmt.Println("Значения:", tree.Range(values[0], values[1]))
+		} else {
+			fmt.Println("Ошибка: команда RANGE требует 2 аргумента.")
+		}
+	case "RANK":
+		if len(values) == 1 {
+			fmt.Printf("Значений не больше %d: %d\n", values[0], tree.Rank(values[0]))
+		} else {
+			fmt.Println("Ошибка: команда RANK требует 1 аргумент.")
+		}
+	case "SELECT":
+		if len(values) == 1 {
+			if value, err := tree.Select(values[0]); err != nil {
+				fmt.Println("Ошибка:", err)
+			} else {
+				fmt.Printf("%d-е по возрастанию значение: %d\n", values[0], value)
+			}
+		} else {
+			fmt.Println("Ошибка: команда SELECT требует 1 аргумент.")
+		}
+	case "COUNT":
+		if len(values) == 2 {
+			fmt.Printf("Значений в диапазоне: %d\n", tree.CountRange(values[0], values[1]))
+		} else {
+			fmt.Println("Ошибка: команда COUNT требует 2 аргумента.")
+		}
+	}
+}
+
+// usesStringTree определяет, должна ли команда T работать со строковым деревом:
+// оно уже содержит ключи или TINSERT получил нечисловой ключ либо нагрузку.
+// При переходе в строковый режим значения целочисленного дерева переносятся.
+func usesStringTree(tokens []string, cbTree *BinaryTree, stringTree *StringTree) bool {
+	if !strings.HasPrefix(tokens[0], "T") {
+		return false
+	}
+	if stringTree.Root != nil {
+		return true
+	}
+	if tokens[0] != "TINSERT" || len(tokens) < 2 {
+		return false
+	}
+	if _, err := strconv.Atoi(tokens[1]); err == nil && len(tokens) == 2 {
+		return false
+	}
+	*stringTree = *StringTreeFromBinaryTree(cbTree)
+	cbTree.Clear()
+	return true
+}
+
+// processStringTreeQuery выполняет команды T для дерева со строковыми ключами
+func processStringTreeQuery(tokens []string, stringTree *StringTree) {
+	switch tokens[0] {
+	case "TINSERT":
+		if len(tokens) == 2 {
+			stringTree.Insert(tokens[1])
+		} else if len(tokens) == 3 {
+			stringTree.Put(tokens[1], tokens[2])
+		} else {
+			fmt.Println("Ошибка: команда TINSERT требует 1 или 2 аргумента.")
+		}
+	case "TDEL":
+		if len(tokens) == 2 {
+			if !stringTree.Delete(tokens[1]) {
+				fmt.Printf("Значение %s не найдено в дереве.\n", tokens[1])
+			}
+		} else {
+			fmt.Println("Ошибка: команда TDEL требует 1 аргумент.")
+		}
+	case "TFIND":
+		if len(tokens) == 2 {
+			if !stringTree.Contains(tokens[1]) {
+				fmt.Printf("Значение %s не найдено в дереве.\n", tokens[1])
+			} else if payload, ok := stringTree.Get(tokens[1]); ok {
+				fmt.Printf("Значение %s найдено в дереве: %s\n", tokens[1], payload)
+			} else {
+				fmt.Printf("Значение %s найдено в дереве.\n", tokens[1])
+			}
+		} else {
+			fmt.Println("Ошибка: команда TFIND требует 1 аргумент.")
+		}
+	case "TDISPLAY":
+		stringTree.Display()
+	case "TMIN":
+		if key, err := stringTree.Min(); err != nil {
+			fmt.Println("Ошибка:", err)
+		} else {
+			fmt.Printf("Минимальное значение: %s\n", key)
+		}
+	case "TMAX":
+		if key, err := stringTree.Max(); err != nil {
+			fmt.Println("Ошибка:", err)
+		} else {
+			fmt.Printf("Максимальное значение: %s\n", key)
+		}
+	default:
+		fmt.Printf("Ошибка: команда %s недоступна для дерева со строковыми ключами.\n", tokens[0])
+	}
+}
+
 func main() {
-	var query, filename string
+	var query, filename, sessionFile, aofFile string
+	fsyncPolicy := FsyncEverySec
 	array := NewArray(10)
 	stack := NewStack()
 	queue := NewQueue()
@@ -220,6 +734,10 @@ func main() {
 	doublyList := NewDoublyLinkedList()
 	hashTable := NewHashTable(10)
 	cbTree := NewBinaryTree()
+	avlTree := NewAVLTree()
+	rbTree := NewRedBlackTree()
+	cache := NewCache(10, PolicyLRU)
+	priorityQueue := NewPriorityQueue(MinHeap)
 
 	for i := 1; i < len(os.Args); i++ {
 		arg := os.Args[i]
@@ -231,31 +749,176 @@ func main() {
 			query = os.Args[i+1]
 			i++
 		}
+		if arg == "--session" && i+1 < len(os.Args) {
+			sessionFile = os.Args[i+1]
+			i++
+		}
+		if arg == "--aof" && i+1 < len(os.Args) {
+			aofFile = os.Args[i+1]
+			i++
+		}
+		if arg == "--appendfsync" && i+1 < len(os.Args) {
+			policy, err := ParseFsyncPolicy(os.Args[i+1])
+			if err != nil {
+				fmt.Println("Ошибка:", err)
+				return
+			}
+			fsyncPolicy = policy
+			i++
+		}
+		if arg == "--compress" && i+1 < len(os.Args) {
+			compression, err := ParseCompression(os.Args[i+1])
+			if err != nil {
+				fmt.Println("Ошибка:", err)
+				return
+			}
+			fileCodec.Compression = compression
+			i++
+		}
+		if arg == "--backup" {
+			keepBackups = true
+		}
+		if arg == "--cache-policy" && i+1 < len(os.Args) {
+			policy, err := ParseCachePolicy(os.Args[i+1])
+			if err != nil {
+				fmt.Println("Ошибка:", err)
+				return
+			}
+			cache = NewCache(10, policy)
+			i++
+		}
+		if arg == "--heap" && i+1 < len(os.Args) {
+			kind, err := ParseHeapKind(os.Args[i+1])
+			if err != nil {
+				fmt.Println("Ошибка:", err)
+				return
+			}
+			priorityQueue = NewPriorityQueue(kind)
+			i++
+		}
+		if arg == "--tree" && i+1 < len(os.Args) {
+			switch os.Args[i+1] {
+			case "cbt":
+				cbTree = NewBinaryTree()
+			case "bst":
+				cbTree = NewBinarySearchTree()
+			default:
+				fmt.Printf("Ошибка: неизвестный тип дерева %s\n", os.Args[i+1])
+				return
+			}
+			i++
+		}
 	}
 
-	if filename != "" && query != "" {
-		command := strings.Split(query, " ")[0]
+	fileCodec.Passphrase = os.Getenv(passphraseEnv)
+
+	stringTree := &StringTree{Ordered: cbTree.Ordered}
+	history := NewPersistentTree()
+	historyFile := filename + ".versions"
+
+	// Журнал команд из --aof заменяет загрузку и сохранение файла из --file:
+	// состояние восстанавливается повторным выполнением записанных команд
+	var aof *AppendOnlyLog
+	if aofFile != "" {
+		var err error
+		aof, err = OpenAppendOnlyLog(aofFile, fsyncPolicy, aofMode(cbTree, cache, priorityQueue))
+		if err != nil {
+			fmt.Println("Ошибка:", err)
+			return
+		}
+		defer func() {
+			if err := aof.Close(); err != nil {
+				fmt.Println("Ошибка:", err)
+			}
+		}()
+		_, err = aof.Replay(func(logged string) {
+			processQuery(logged, array, stack, queue, singlyList, doublyList, hashTable, cbTree, stringTree, history, avlTree, rbTree, cache, priorityQueue, "")
+			history.Record(logged, cbTree)
+		})
+		if err != nil {
+			fmt.Println("Ошибка:", err)
+			return
+		}
+	}
 
-		switch command[0] {
+	command := strings.Split(query, " ")[0]
+	// EXPORT и IMPORT работают со структурой из аргумента, и файл из --file
+	// загружается и сохраняется как для команд этой структуры
+	fileCommand := command
+	if transferCommands[command] {
+		fileCommand = ""
+		if tokens := strings.Split(query, " "); len(tokens) > 1 {
+			fileCommand = transferStructures[tokens[1]]
+		}
+	}
+	useFile := filename != "" && query != "" && aof == nil && !sessionCommands[command] && fileCommand != ""
+
+	// Без --session и --aof структуры не хранятся между запусками: SAVEALL
+	// сохранил бы пустой сеанс, а загруженный LOADALL сеанс был бы потерян
+	if sessionCommands[command] && sessionFile == "" && aof == nil {
+		fmt.Printf("Ошибка: команда %s требует --session или --aof.\n", command)
+		return
+	}
+
+	// Сеанс из --session хранит все основные структуры между запусками
+	session := &Session{Array: array, Stack: stack, Queue: queue, SinglyList: singlyList, DoublyList: doublyList, HashTable: hashTable, BinaryTree: cbTree, Codec: fileCodec}
+	if sessionFile != "" {
+		if _, err := os.Stat(sessionFile); err == nil {
+			if err := session.LoadFromFile(sessionFile); err != nil {
+				fmt.Println("Ошибка:", err)
+				return
+			}
+		}
+	}
+
+	if useFile {
+		kind := fileCommand[0]
+		if orderQueryCommands[fileCommand] {
+			kind = 'T'
+		}
+
+		var loadErr error
+		switch kind {
 		case 'M':
-			array.LoadFromFile(filename)
+			loadErr = loadExisting(filename, array.LoadFromFile)
 		case 'S':
-			stack.LoadFromFile(filename)
+			loadErr = loadExisting(filename, stack.LoadFromFile)
 		case 'Q':
-			queue.LoadFromFile(filename)
+			loadErr = loadExisting(filename, queue.LoadFromFile)
 		case 'L':
-			if command[1] == 'S' {
-				singlyList.LoadFromFile(filename)
-			} else if command[1] == 'D' {
-				doublyList.LoadFromFile(filename)
+			if fileCommand[1] == 'S' {
+				loadErr = loadExisting(filename, singlyList.LoadFromFile)
+			} else if fileCommand[1] == 'D' {
+				loadErr = loadExisting(filename, doublyList.LoadFromFile)
 			}
 		case 'H':
-			hashTable.LoadFromFile(filename)
+			loadErr = loadExisting(filename, hashTable.LoadFromFile)
+		case 'C':
+			loadErr = loadExisting(filename, cache.LoadFromFile)
 		case 'T':
-			cbTree.LoadFromFile(filename)
+			// Файл с нечисловыми значениями загружается в строковое дерево
+			loadTree := func(name string) error {
+				if err := cbTree.LoadFromFile(name); err != nil {
+					cbTree.Clear()
+					return stringTree.LoadFromFile(name)
+				}
+				return nil
+			}
+			loadErr = loadExisting(filename, loadTree)
+			// История версий хранится рядом с файлом дерева в виде журнала операций
+			if loadErr == nil && stringTree.Root == nil {
+				loadErr = loadExisting(historyFile, history.LoadFromFile)
+				history.Record("load", cbTree)
+			}
+		case 'A':
+			loadErr = loadExisting(filename, avlTree.LoadFromFile)
+		case 'R':
+			loadErr = loadExisting(filename, rbTree.LoadFromFile)
 		case 'P':
-			if command == "PRINT" {
-				file, err := os.Open(filename)
+			if strings.HasPrefix(fileCommand, "PQ") {
+				loadErr = loadExisting(filename, priorityQueue.LoadFromFile)
+			} else if fileCommand == "PRINT" {
+				file, err := openSnapshotFile(filename)
 				if err != nil {
 					fmt.Printf("Ошибка: не удалось открыть файл %s\n", filename)
 					return
@@ -270,35 +933,95 @@ func main() {
 			fmt.Println("Ошибка: нераспознанный тип команды.")
 			return
 		}
+		// Файл, который не удалось прочитать (поврежден, зашифрован другим паролем),
+		// нельзя перезаписывать результатом команды над пустой структурой
+		if loadErr != nil {
+			fmt.Println("Ошибка:", loadErr)
+			return
+		}
 	}
 
-	if query != "" {
-		processQuery(query, array, stack, queue, singlyList, doublyList, hashTable, cbTree, filename)
+	if query != "" && command == "BGREWRITEAOF" {
+		if aof == nil {
+			fmt.Println("Ошибка: журнал команд не включен, используйте --aof.")
+			return
+		}
+		if err := aof.StartRewrite(compactCommands(array, stack, queue, singlyList, doublyList, hashTable, cbTree, stringTree, avlTree, rbTree, cache, priorityQueue)); err != nil {
+			fmt.Println("Ошибка:", err)
+			return
+		}
+		fmt.Println("Перезапись журнала запущена в фоне.")
+	} else if query != "" {
+		processQuery(query, array, stack, queue, singlyList, doublyList, hashTable, cbTree, stringTree, history, avlTree, rbTree, cache, priorityQueue, filename)
 	} else {
 		fmt.Println("Ошибка: запрос не указан.")
 		return
 	}
 
-	if filename != "" && query != "" {
-		command := strings.Split(query, " ")[0]
+	if useFile {
+		kind := fileCommand[0]
+		if orderQueryCommands[fileCommand] {
+			kind = 'T'
+		}
 
-		switch command[0] {
+		var saveErr error
+		switch kind {
 		case 'M':
-			array.SaveToFile(filename)
+			saveErr = array.SaveToFile(filename)
 		case 'S':
-			stack.SaveToFile(filename)
+			saveErr = stack.SaveToFile(filename)
 		case 'Q':
-			queue.SaveToFile(filename)
+			saveErr = queue.SaveToFile(filename)
 		case 'L':
-			if command[1] == 'S' {
-				singlyList.SaveToFile(filename)
-			} else if command[1] == 'D' {
-				doublyList.SaveToFile(filename)
+			if fileCommand[1] == 'S' {
+				saveErr = singlyList.SaveToFile(filename)
+			} else if fileCommand[1] == 'D' {
+				saveErr = doublyList.SaveToFile(filename)
 			}
 		case 'H':
-			hashTable.SaveToFile(filename)
+			saveErr = hashTable.SaveToFile(filename)
+		case 'C':
+			saveErr = cache.SaveToFile(filename)
 		case 'T':
-			cbTree.SaveToFile(filename)
+			if stringTree.Root != nil {
+				saveErr = stringTree.SaveToFile(filename)
+			} else {
+				saveErr = cbTree.SaveToFile(filename)
+				if saveErr == nil {
+					history.Record(query, cbTree)
+					saveErr = history.SaveToFile(historyFile)
+				}
+			}
+		case 'A':
+			saveErr = avlTree.SaveToFile(filename)
+		case 'R':
+			saveErr = rbTree.SaveToFile(filename)
+		case 'P':
+			if strings.HasPrefix(fileCommand, "PQ") {
+				saveErr = priorityQueue.SaveToFile(filename)
+			}
+		}
+		if saveErr != nil {
+			fmt.Println("Ошибка:", saveErr)
+		}
+	}
+
+	if aof != nil {
+		if stateReplacingCommands[command] {
+			err := aof.StartRewrite(compactCommands(array, stack, queue, singlyList, doublyList, hashTable, cbTree, stringTree, avlTree, rbTree, cache, priorityQueue))
+			if err != nil {
+				fmt.Println("Ошибка:", err)
+			}
+		} else if mutatingCommands[command] {
+			if err := aof.Append(query); err != nil {
+				fmt.Println("Ошибка:", err)
+			}
+		}
+	}
+
+	if sessionFile != "" {
+		if err := session.SaveToFile(sessionFile); err != nil {
+			fmt.Println("Ошибка:", err)
 		}
 	}
 }
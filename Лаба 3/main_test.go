@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runMain запускает программу с аргументами командной строки и возвращает ее вывод.
+// Глобальные настройки из флагов сбрасываются после запуска.
+func runMain(t *testing.T, args ...string) string {
+	t.Helper()
+	oldArgs := os.Args
+	defer func() {
+		os.Args = oldArgs
+		fileCodec = SnapshotCodec{}
+		keepBackups = false
+	}()
+	os.Args = append([]string{"lab3"}, args...)
+	return captureStdout(main)
+}
+
+// TestMainWrongPassphrase проверяет, что файл, который не удалось расшифровать,
+// не перезаписывается результатом команды над пустой структурой
+func TestMainWrongPassphrase(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "data.enc")
+	t.Setenv(passphraseEnv, "secret")
+	runMain(t, "--file", filename, "--compress", "gzip", "--query", "SPUSH a")
+	original, err := os.ReadFile(filename)
+	if err != nil || !bytes.HasPrefix(original, []byte(codecMagic)) {
+		t.Fatalf("Файл не зашифрован: %q, %v", original, err)
+	}
+
+	for _, passphrase := range []string{"", "wrong"} {
+		t.Setenv(passphraseEnv, passphrase)
+		output := runMain(t, "--file", filename, "--query", "SPUSH c")
+		if !strings.HasPrefix(output, "Ошибка:") {
+			t.Errorf("Пароль %q: ожидалась ошибка загрузки, получено %q", passphrase, output)
+		}
+		if data, _ := os.ReadFile(filename); !bytes.Equal(data, original) {
+			t.Errorf("Пароль %q: файл изменен: %q", passphrase, data)
+		}
+	}
+
+	t.Setenv(passphraseEnv, "secret")
+	if output := runMain(t, "--file", filename, "--query", "PRINT"); !strings.HasPrefix(output, "a\n") {
+		t.Errorf("С верным паролем ожидалось значение a, получено %q", output)
+	}
+}
+
+// TestMainTreeFileShape проверяет, что дерево из TBUILD сохраняет форму между запусками с --file
+func TestMainTreeFileShape(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tree.txt")
+	runMain(t, "--file", filename, "--query", "TBUILD level [1,null,2,null,3]")
+	if output := runMain(t, "--file", filename, "--query", "TEXPORT level"); output != "[1,null,2,null,3]\n" {
+		t.Errorf("После перезапуска дерево %q", output)
+	}
+}
+
+// TestMainSaveError проверяет, что ошибка сохранения файла выводится, а не теряется
+func TestMainSaveError(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "missing", "stack.txt")
+	if output := runMain(t, "--file", filename, "--query", "SPUSH a"); !strings.HasPrefix(output, "Ошибка:") {
+		t.Errorf("Ожидалось сообщение об ошибке сохранения, получено %q", output)
+	}
+}
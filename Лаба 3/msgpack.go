@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// Кодирование переносимых значений в MessagePack (https://msgpack.org/).
+// Числа и длины записываются в самой короткой форме, ключи словарей - по
+// порядку sortedPortableKeys, поэтому одинаковые структуры дают одинаковые байты.
+// Строки с некорректным UTF-8 записываются как bin, чтобы сохранить их без потерь.
+
+// encodeMsgPack кодирует переносимое значение в MessagePack
+func encodeMsgPack(value any) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := writeMsgPack(&buffer, value); err != nil {
+		return nil, fmt.Errorf("ошибка сериализации в MessagePack: %v", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// writeMsgPack записывает значение и вложенные в него значения
+func writeMsgPack(buffer *bytes.Buffer, value any) error {
+	switch value := value.(type) {
+	case nil:
+		buffer.WriteByte(0xc0)
+	case bool:
+		if value {
+			buffer.WriteByte(0xc3)
+		} else {
+			buffer.WriteByte(0xc2)
+		}
+	case int:
+		writeMsgPackInt(buffer, int64(value))
+	case int64:
+		writeMsgPackInt(buffer, value)
+	case string:
+		if utf8.ValidString(value) {
+			if err := writeMsgPackLength(buffer, len(value), 0xa0, 32, msgPackStrCodes); err != nil {
+				return err
+			}
+		} else if err := writeMsgPackLength(buffer, len(value), 0, 0, msgPackBinCodes); err != nil {
+			return err
+		}
+		buffer.WriteString(value)
+	case []any:
+		if err := writeMsgPackLength(buffer, len(value), 0x90, 16, msgPackArrayCodes); err != nil {
+			return err
+		}
+		for _, item := range value {
+			if err := writeMsgPack(buffer, item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		if err := writeMsgPackLength(buffer, len(value), 0x80, 16, msgPackMapCodes); err != nil {
+			return err
+		}
+		for _, key := range sortedPortableKeys(value) {
+			writeMsgPack(buffer, key)
+			if err := writeMsgPack(buffer, value[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("неподдерживаемый тип %T", value)
+	}
+	return nil
+}
+
+// writeMsgPackInt записывает целое число в самой короткой форме
+func writeMsgPackInt(buffer *bytes.Buffer, value int64) {
+	switch {
+	case value >= 0 && value < 128:
+		buffer.WriteByte(byte(value))
+	case value >= -32 && value < 0:
+		buffer.WriteByte(byte(value))
+	case value >= 0 && value <= math.MaxUint8:
+		buffer.Write([]byte{0xcc, byte(value)})
+	case value >= 0 && value <= math.MaxUint16:
+		buffer.WriteByte(0xcd)
+		buffer.Write(binary.BigEndian.AppendUint16(nil, uint16(value)))
+	case value >= 0 && value <= math.MaxUint32:
+		buffer.WriteByte(0xce)
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(value)))
+	case value >= 0:
+		buffer.WriteByte(0xcf)
+		buffer.Write(binary.BigEndian.AppendUint64(nil, uint64(value)))
+	case value >= math.MinInt8:
+		buffer.Write([]byte{0xd0, byte(value)})
+	case value >= math.MinInt16:
+		buffer.WriteByte(0xd1)
+		buffer.Write(binary.BigEndian.AppendUint16(nil, uint16(value)))
+	case value >= math.MinInt32:
+		buffer.WriteByte(0xd2)
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(value)))
+	default:
+		buffer.WriteByte(0xd3)
+		buffer.Write(binary.BigEndian.AppendUint64(nil, uint64(value)))
+	}
+}
+
+// Коды заголовков MessagePack для длин в 1, 2 и 4 байта; 0 - форма недоступна
+var (
+	msgPackStrCodes   = [3]byte{0xd9, 0xda, 0xdb}
+	msgPackBinCodes   = [3]byte{0xc4, 0xc5, 0xc6}
+	msgPackArrayCodes = [3]byte{0, 0xdc, 0xdd}
+	msgPackMapCodes   = [3]byte{0, 0xde, 0xdf}
+)
+
+// writeMsgPackLength записывает заголовок строки, массива или словаря: короткую
+// форму fixed|длина для длин меньше fixedLimit или код из codes и длину за ним
+func writeMsgPackLength(buffer *bytes.Buffer, length int, fixed byte, fixedLimit int, codes [3]byte) error {
+	switch {
+	case length < fixedLimit:
+		buffer.WriteByte(fixed | byte(length))
+	case length > math.MaxUint32:
+		return fmt.Errorf("длина %d превышает допустимую в MessagePack", length)
+	case codes[0] != 0 && length <= math.MaxUint8:
+		buffer.Write([]byte{codes[0], byte(length)})
+	case length <= math.MaxUint16:
+		buffer.WriteByte(codes[1])
+		buffer.Write(binary.BigEndian.AppendUint16(nil, uint16(length)))
+	default:
+		buffer.WriteByte(codes[2])
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(length)))
+	}
+	return nil
+}
+
+// decodeMsgPack разбирает одно значение MessagePack, занимающее все данные.
+// Поддерживаются типы переносимого представления; числа с плавающей точкой
+// и расширения (ext) отклоняются.
+func decodeMsgPack(data []byte) (any, error) {
+	decoder := &portableDecoder{data: data}
+	value, err := decoder.msgPackValue(0)
+	if err != nil {
+		return nil, err
+	}
+	if decoder.pos != len(data) {
+		return nil, fmt.Errorf("лишние данные после значения: %d байт", len(data)-decoder.pos)
+	}
+	return value, nil
+}
+
+// msgPackValue разбирает значение на текущей позиции; depth - глубина вложенности
+func (d *portableDecoder) msgPackValue(depth int) (any, error) {
+	code, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code >= 0x80 && code <= 0x8f:
+		return d.msgPackMap(uint64(code&0x0f), depth)
+	case code >= 0x90 && code <= 0x9f:
+		return d.msgPackArray(uint64(code&0x0f), depth)
+	case code >= 0xa0 && code <= 0xbf:
+		return d.string(uint64(code & 0x1f))
+	}
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6: // bin читается как строка
+		length, err := d.uint(1 << (code - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.string(length)
+	case 0xd9, 0xda, 0xdb:
+		length, err := d.uint(1 << (code - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.string(length)
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		value, err := d.uint(1 << (code - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if value > math.MaxInt64 {
+			return nil, fmt.Errorf("целое число %d вне допустимого диапазона", value)
+		}
+		return int64(value), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (code - 0xd0)
+		value, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		shift := 64 - 8*size // Расширение знака
+		return int64(value<<shift) >> shift, nil
+	case 0xdc, 0xdd:
+		length, err := d.uint(2 << (code - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.msgPackArray(length, depth)
+	case 0xde, 0xdf:
+		length, err := d.uint(2 << (code - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.msgPackMap(length, depth)
+	case 0xca, 0xcb:
+		return nil, fmt.Errorf("числа с плавающей точкой не поддерживаются")
+	case 0xc7, 0xc8, 0xc9, 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return nil, fmt.Errorf("расширения (ext) не поддерживаются")
+	}
+	return nil, fmt.Errorf("некорректный код типа %#x", code)
+}
+
+// msgPackArray разбирает length элементов массива
+func (d *portableDecoder) msgPackArray(length uint64, depth int) (any, error) {
+	if err := d.enter(length, 1, depth); err != nil {
+		return nil, err
+	}
+	items := make([]any, 0, length)
+	for range length {
+		item, err := d.msgPackValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// msgPackMap разбирает length пар словаря со строковыми ключами
+func (d *portableDecoder) msgPackMap(length uint64, depth int) (any, error) {
+	if err := d.enter(length, 2, depth); err != nil {
+		return nil, err
+	}
+	values := make(map[string]any, length)
+	for range length {
+		key, err := d.msgPackValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.msgPackValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := addPortableKey(values, key, value); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
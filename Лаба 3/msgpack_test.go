@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// portableVector - значение и их запись, общие для тестов MessagePack и CBOR
+type portableVector struct {
+	value any
+	hex   string
+}
+
+// checkPortableVectors проверяет кодирование и разбор значений по известной записи
+func checkPortableVectors(t *testing.T, vectors []portableVector, encode func(any) ([]byte, error), decode func([]byte) (any, error)) {
+	t.Helper()
+	for _, vector := range vectors {
+		encoded, err := encode(vector.value)
+		if err != nil {
+			t.Errorf("%#v: ошибка кодирования: %v", vector.value, err)
+			continue
+		}
+		if hex.EncodeToString(encoded) != vector.hex {
+			t.Errorf("%#v: получено %x, ожидалось %s", vector.value, encoded, vector.hex)
+		}
+		data, _ := hex.DecodeString(vector.hex)
+		decoded, err := decode(data)
+		if err != nil {
+			t.Errorf("%s: ошибка разбора: %v", vector.hex, err)
+		} else if !reflect.DeepEqual(decoded, vector.value) {
+			t.Errorf("%s: получено %#v, ожидалось %#v", vector.hex, decoded, vector.value)
+		}
+	}
+}
+
+// checkPortableMalformed проверяет, что некорректные данные отклоняются с ожидаемым сообщением
+func checkPortableMalformed(t *testing.T, cases map[string]string, decode func([]byte) (any, error)) {
+	t.Helper()
+	for input, message := range cases {
+		data, err := hex.DecodeString(input)
+		if err != nil {
+			t.Fatalf("некорректная запись теста %s", input)
+		}
+		if _, err := decode(data); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: ожидалась ошибка %q, получено %v", input, message, err)
+		}
+	}
+}
+
+// TestMsgPackVectors проверяет запись значений по спецификации MessagePack
+func TestMsgPackVectors(t *testing.T) {
+	checkPortableVectors(t, []portableVector{
+		{int64(0), "00"},
+		{int64(127), "7f"},
+		{int64(128), "cc80"},
+		{int64(256), "cd0100"},
+		{int64(65536), "ce00010000"},
+		{int64(1) << 32, "cf0000000100000000"},
+		{int64(-1), "ff"},
+		{int64(-32), "e0"},
+		{int64(-33), "d0df"},
+		{int64(-129), "d1ff7f"},
+		{int64(-32769), "d2ffff7fff"},
+		{int64(-1) << 40, "d3ffffff0000000000"},
+		{"", "a0"},
+		{"a", "a161"},
+		{strings.Repeat("x", 32), "d920" + strings.Repeat("78", 32)},
+		{"\xff", "c401ff"},
+		{nil, "c0"},
+		{true, "c3"},
+		{false, "c2"},
+		{[]any{}, "90"},
+		{[]any{int64(1), "b"}, "9201a162"},
+		{map[string]any{}, "80"},
+		{map[string]any{"bb": int64(1), "a": nil}, "82a161c0a2626201"},
+	}, encodeMsgPack, decodeMsgPack)
+}
+
+// TestMsgPackLongContainers проверяет заголовки длинных массивов и словарей
+func TestMsgPackLongContainers(t *testing.T) {
+	items := make([]any, 16)
+	for i := range items {
+		items[i] = int64(i)
+	}
+	encoded, _ := encodeMsgPack(items)
+	if encoded[0] != 0xdc || encoded[1] != 0 || encoded[2] != 16 {
+		t.Errorf("Заголовок массива из 16 элементов: %x", encoded[:3])
+	}
+	if decoded, err := decodeMsgPack(encoded); err != nil || !reflect.DeepEqual(decoded, items) {
+		t.Errorf("Массив из 16 элементов не прочитан: %v", err)
+	}
+}
+
+// TestMsgPackMalformed проверяет отклонение некорректных и неподдерживаемых данных
+func TestMsgPackMalformed(t *testing.T) {
+	checkPortableMalformed(t, map[string]string{
+		"":                   "неожиданный конец данных",
+		"0000":               "лишние данные",
+		"c1":                 "некорректный код типа",
+		"cb0000000000000000": "плавающей точкой",
+		"d40000":             "расширения",
+		"a261":               "больше оставшихся данных",
+		"dbffffffff":         "превышает допустимую",
+		"dd7fffffff":         "больше оставшихся данных",
+		"cfffffffffffffffff": "вне допустимого диапазона",
+		"810101":             "ключ словаря должен быть строкой",
+		"82a16101a16102":     `ключ "a" повторяется`,
+	}, decodeMsgPack)
+
+	deep := []byte(strings.Repeat("\x91", maxPortableDepth+1) + "\xc0")
+	if _, err := decodeMsgPack(deep); err == nil || !strings.Contains(err.Error(), "глубина") {
+		t.Errorf("Ожидалась ошибка глубины вложенности, получено %v", err)
+	}
+}
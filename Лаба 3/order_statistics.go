@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// OrderStatistics описывает запросы по диапазонам и порядковые статистики
+// упорядоченных деревьев. Ранг значения x - количество значений, не больших x,
+// поэтому для значения из дерева Select(Rank(x)) == x.
+type OrderStatistics interface {
+	Range(lo, hi int) []int
+	Rank(x int) int
+	Select(k int) (int, error)
+	CountRange(lo, hi int) int
+}
+
+// orderNode описывает узел дерева поиска, хранящий размер своего поддерева
+type orderNode[N any] interface {
+	comparable
+	key() int
+	size() int
+	left() N
+	right() N
+}
+
+// orderCountBelow возвращает количество значений меньше x (или не больше x при inclusive) за O(h)
+func orderCountBelow[N orderNode[N]](root N, x int, inclusive bool) int {
+	var null N
+	count := 0
+	for current := root; current != null; {
+		if current.key() < x || (inclusive && current.key() == x) {
+			count += current.left().size() + 1
+			current = current.right()
+		} else {
+			current = current.left()
+		}
+	}
+	return count
+}
+
+// orderSelect возвращает k-е по возрастанию значение (k от 1) за O(h)
+func orderSelect[N orderNode[N]](root N, k int) (int, error) {
+	var null N
+	if k < 1 || k > root.size() {
+		return 0, fmt.Errorf("порядковый номер %d вне диапазона 1..%d", k, root.size())
+	}
+	current := root
+	for current != null {
+		leftSize := current.left().size()
+		switch {
+		case k <= leftSize:
+			current = current.left()
+		case k == leftSize+1:
+			return current.key(), nil
+		default:
+			k -= leftSize + 1
+			current = current.right()
+		}
+	}
+	return 0, fmt.Errorf("размеры поддеревьев не согласованы")
+}
+
+// orderRange возвращает значения из отрезка [lo, hi] по возрастанию,
+// посещая только поддеревья, пересекающиеся с отрезком: O(h + k)
+func orderRange[N orderNode[N]](root N, lo, hi int) []int {
+	var null N
+	values := []int{}
+	var walk func(node N)
+	walk = func(node N) {
+		if node == null {
+			return
+		}
+		if node.key() > lo {
+			walk(node.left())
+		}
+		if node.key() >= lo && node.key() <= hi {
+			values = append(values, node.key())
+		}
+		if node.key() < hi {
+			walk(node.right())
+		}
+	}
+	walk(root)
+	return values
+}
+
+// orderCountRange возвращает количество значений из отрезка [lo, hi] за O(h)
+func orderCountRange[N orderNode[N]](root N, lo, hi int) int {
+	if lo > hi {
+		return 0
+	}
+	return orderCountBelow(root, hi, true) - orderCountBelow(root, lo, false)
+}
+
+// key, size, left и right реализуют orderNode для узлов бинарного дерева
+func (n *TreeNode) key() int         { return n.Digit }
+func (n *TreeNode) left() *TreeNode  { return n.Left }
+func (n *TreeNode) right() *TreeNode { return n.Right }
+func (n *TreeNode) size() int {
+	if n == nil {
+		return 0
+	}
+	return n.Size
+}
+
+// updateSize пересчитывает размер поддерева узла по размерам потомков
+func (n *TreeNode) updateSize() {
+	n.Size = n.Left.size() + n.Right.size() + 1
+}
+
+// updateSizes пересчитывает размеры всех поддеревьев
+func (bt *BinaryTree) updateSizes() {
+	bt.subtreeHeights(func(node *TreeNode, left, right int) {
+		node.updateSize()
+	})
+}
+
+// ensureSizes пересчитывает размеры, если корень дерева поиска был задан напрямую
+func (bt *BinaryTree) ensureSizes() {
+	if bt.Ordered && bt.Root != nil && bt.Root.Size == 0 {
+		bt.updateSizes()
+	}
+}
+
+// Range возвращает значения из отрезка [lo, hi] по возрастанию.
+// В упорядоченном режиме запрос выполняется за O(log n + k), иначе за O(n log n).
+func (bt *BinaryTree) Range(lo, hi int) []int {
+	if bt.Ordered {
+		return orderRange(bt.Root, lo, hi)
+	}
+	values := []int{}
+	for _, value := range slices.Sorted(bt.LevelOrder()) {
+		if value >= lo && value <= hi {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Rank возвращает количество значений, не больших x
+func (bt *BinaryTree) Rank(x int) int {
+	if bt.Ordered {
+		bt.ensureSizes()
+		return orderCountBelow(bt.Root, x, true)
+	}
+	return bt.CountRange(math.MinInt, x)
+}
+
+// Select возвращает k-е по возрастанию значение (k от 1)
+func (bt *BinaryTree) Select(k int) (int, error) {
+	if bt.Ordered {
+		bt.ensureSizes()
+		return orderSelect(bt.Root, k)
+	}
+	values := slices.Sorted(bt.LevelOrder())
+	if k < 1 || k > len(values) {
+		return 0, fmt.Errorf("порядковый номер %d вне диапазона 1..%d", k, len(values))
+	}
+	return values[k-1], nil
+}
+
+// CountRange возвращает количество значений из отрезка [lo, hi]
+func (bt *BinaryTree) CountRange(lo, hi int) int {
+	if bt.Ordered {
+		bt.ensureSizes()
+		return orderCountRange(bt.Root, lo, hi)
+	}
+	count := 0
+	for value := range bt.LevelOrder() {
+		if value >= lo && value <= hi {
+			count++
+		}
+	}
+	return count
+}
+
+// key, size, left и right реализуют orderNode для узлов AVL-дерева
+func (n *AVLNode) key() int        { return n.Digit }
+func (n *AVLNode) left() *AVLNode  { return n.Left }
+func (n *AVLNode) right() *AVLNode { return n.Right }
+func (n *AVLNode) size() int {
+	if n == nil {
+		return 0
+	}
+	return n.Size
+}
+
+// Range возвращает значения из отрезка [lo, hi] по возрастанию за O(log n + k)
+func (t *AVLTree) Range(lo, hi int) []int {
+	return orderRange(t.Root, lo, hi)
+}
+
+// Rank возвращает количество значений, не больших x, за O(log n)
+func (t *AVLTree) Rank(x int) int {
+	return orderCountBelow(t.Root, x, true)
+}
+
+// Select возвращает k-е по возрастанию значение (k от 1) за O(log n)
+func (t *AVLTree) Select(k int) (int, error) {
+	return orderSelect(t.Root, k)
+}
+
+// CountRange возвращает количество значений из отрезка [lo, hi] за O(log n)
+func (t *AVLTree) CountRange(lo, hi int) int {
+	return orderCountRange(t.Root, lo, hi)
+}
+
+// key, size, left и right реализуют orderNode для узлов красно-черного дерева
+func (n *RBNode) key() int       { return n.Digit }
+func (n *RBNode) left() *RBNode  { return n.Left }
+func (n *RBNode) right() *RBNode { return n.Right }
+func (n *RBNode) size() int {
+	if n == nil {
+		return 0
+	}
+	return n.Size
+}
+
+// Range возвращает значения из отрезка [lo, hi] по возрастанию за O(log n + k)
+func (t *RedBlackTree) Range(lo, hi int) []int {
+	return orderRange(t.Root, lo, hi)
+}
+
+// Rank возвращает количество значений, не больших x, за O(log n)
+func (t *RedBlackTree) Rank(x int) int {
+	return orderCountBelow(t.Root, x, true)
+}
+
+// Select возвращает k-е по возрастанию значение (k от 1) за O(log n)
+func (t *RedBlackTree) Select(k int) (int, error) {
+	return orderSelect(t.Root, k)
+}
+
+// CountRange возвращает количество значений из отрезка [lo, hi] за O(log n)
+func (t *RedBlackTree) CountRange(lo, hi int) int {
+	return orderCountRange(t.Root, lo, hi)
+}
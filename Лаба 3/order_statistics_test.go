@@ -0,0 +1,132 @@
+package main
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// orderTree - дерево с порядковыми статистиками, вставкой и удалением
+type orderTree interface {
+	OrderStatistics
+	Insert(value int)
+	Delete(value int) bool
+}
+
+// checkOrderStatistics сравнивает ответы дерева с отсортированным срезом
+func checkOrderStatistics(t *testing.T, name string, tree OrderStatistics, sorted []int) {
+	t.Helper()
+	for k, value := range sorted {
+		if got, err := tree.Select(k + 1); err != nil || got != value {
+			t.Fatalf("%s: Select(%d) = %d, %v; want %d", name, k+1, got, err, value)
+		}
+		if rank := tree.Rank(value); rank != k+1 {
+			t.Fatalf("%s: Rank(%d) = %d; want %d", name, value, rank, k+1)
+		}
+	}
+	if _, err := tree.Select(0); err == nil {
+		t.Fatalf("%s: Select(0) error = nil", name)
+	}
+	if _, err := tree.Select(len(sorted) + 1); err == nil {
+		t.Fatalf("%s: Select(%d) error = nil", name, len(sorted)+1)
+	}
+	for _, bounds := range [][2]int{{-10, 10}, {0, 0}, {5, 60}, {100, 50}, {-1000, 1000}} {
+		lo, hi := bounds[0], bounds[1]
+		var expected []int
+		for _, value := range sorted {
+			if value >= lo && value <= hi {
+				expected = append(expected, value)
+			}
+		}
+		if got := tree.Range(lo, hi); !slices.Equal(got, expected) {
+			t.Fatalf("%s: Range(%d, %d) = %v; want %v", name, lo, hi, got, expected)
+		}
+		if got := tree.CountRange(lo, hi); got != len(expected) {
+			t.Fatalf("%s: CountRange(%d, %d) = %d; want %d", name, lo, hi, got, len(expected))
+		}
+	}
+}
+
+// TestOrderStatisticsRandom проверяет запросы после случайных вставок и удалений
+func TestOrderStatisticsRandom(t *testing.T) {
+	trees := map[string]orderTree{
+		"bst": NewBinarySearchTree(),
+		"avl": NewAVLTree(),
+		"rb":  NewRedBlackTree(),
+	}
+	for name, tree := range trees {
+		rng := rand.New(rand.NewSource(11))
+		present := make(map[int]bool)
+		for step := 0; step < 600; step++ {
+			value := rng.Intn(200) - 100
+			if rng.Intn(3) == 0 {
+				tree.Delete(value)
+				delete(present, value)
+			} else {
+				tree.Insert(value)
+				present[value] = true
+			}
+			if step%50 == 0 {
+				var sorted []int
+				for value := range present {
+					sorted = append(sorted, value)
+				}
+				slices.Sort(sorted)
+				checkOrderStatistics(t, name, tree, sorted)
+			}
+		}
+	}
+}
+
+// TestOrderStatisticsLevelOrder проверяет запросы для дерева, заполненного по уровням
+func TestOrderStatisticsLevelOrder(t *testing.T) {
+	bt := NewBinaryTree()
+	for _, value := range []int{40, 10, 30, 20, 50} {
+		bt.Insert(value)
+	}
+	checkOrderStatistics(t, "cbt", bt, []int{10, 20, 30, 40, 50})
+	if rank := bt.Rank(35); rank != 3 {
+		t.Errorf("Rank(35) = %d; want 3", rank)
+	}
+}
+
+// TestOrderStatisticsAfterDeserialize проверяет пересчет размеров после загрузки
+func TestOrderStatisticsAfterDeserialize(t *testing.T) {
+	bst := NewBinarySearchTree()
+	avl := NewAVLTree()
+	rb := NewRedBlackTree()
+	for _, value := range []int{8, 3, 10, 1, 6, 14} {
+		bst.Insert(value)
+		avl.Insert(value)
+		rb.Insert(value)
+	}
+	sorted := []int{1, 3, 6, 8, 10, 14}
+
+	text, _ := bst.SerializeText()
+	restoredBST := NewBinaryTree()
+	restoredBST.DeserializeText(text)
+	checkOrderStatistics(t, "bst text", restoredBST, sorted)
+
+	data, _ := bst.SerializeBinary()
+	restoredBST = NewBinaryTree()
+	restoredBST.DeserializeBinary(data)
+	checkOrderStatistics(t, "bst binary", restoredBST, sorted)
+
+	text, _ = avl.SerializeText()
+	restoredAVL := NewAVLTree()
+	if err := restoredAVL.DeserializeText(text); err != nil {
+		t.Fatalf("AVL DeserializeText() error = %v", err)
+	}
+	checkOrderStatistics(t, "avl text", restoredAVL, sorted)
+
+	text, _ = rb.SerializeText()
+	restoredRB := NewRedBlackTree()
+	if err := restoredRB.DeserializeText(text); err != nil {
+		t.Fatalf("RB DeserializeText() error = %v", err)
+	}
+	checkOrderStatistics(t, "rb text", restoredRB, sorted)
+
+	// Дерево поиска, собранное вручную, получает размеры при первом запросе
+	manual := &BinaryTree{Ordered: true, Root: &TreeNode{Digit: 2, Left: &TreeNode{Digit: 1}, Right: &TreeNode{Digit: 3}}}
+	checkOrderStatistics(t, "manual", manual, []int{1, 2, 3})
+}
@@ -0,0 +1,570 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PersistentNode представляет неизменяемый узел персистентного дерева.
+// Узлы никогда не меняются после создания, поэтому версии могут разделять поддеревья.
+type PersistentNode struct {
+	Digit int
+	Left  *PersistentNode
+	Right *PersistentNode
+}
+
+// PersistentVersion представляет одну версию дерева и операцию, которая ее создала
+type PersistentVersion struct {
+	Root    *PersistentNode
+	Size    int
+	Ordered bool
+	Op      string // Операция в формате журнала: insert, delete, checkout или reset
+}
+
+// Форматы reset в журнале версий: "reset cbt [1,null,2,3]" задает форму дерева
+// обходом по уровням с пропусками, а "reset cbt 1,2,3" - значения для вставки
+// в указанном режиме (так записывались журналы до хранения формы).
+
+// PersistentTree представляет персистентное дерево с копированием пути:
+// каждая вставка и удаление создает новую версию, которая копирует только узлы
+// на пути от корня до измененного места и разделяет остальные узлы с предыдущей.
+// Режимы совпадают с BinaryTree: заполнение по уровням или дерево поиска.
+type PersistentTree struct {
+	versions []PersistentVersion
+	current  int
+}
+
+// NewPersistentTree создает персистентное дерево без версий
+func NewPersistentTree() *PersistentTree {
+	return &PersistentTree{current: -1}
+}
+
+// Len возвращает количество версий
+func (pt *PersistentTree) Len() int {
+	return len(pt.versions)
+}
+
+// Current возвращает номер текущей версии (-1, если версий нет)
+func (pt *PersistentTree) Current() int {
+	return pt.current
+}
+
+// Version возвращает версию с указанным номером
+func (pt *PersistentTree) Version(n int) (PersistentVersion, error) {
+	if n < 0 || n >= len(pt.versions) {
+		return PersistentVersion{}, fmt.Errorf("версия %d не существует", n)
+	}
+	return pt.versions[n], nil
+}
+
+// push добавляет новую версию и делает ее текущей
+func (pt *PersistentTree) push(version PersistentVersion) int {
+	pt.versions = append(pt.versions, version)
+	pt.current = len(pt.versions) - 1
+	return pt.current
+}
+
+// Reset создает версию с деревом, построенным вставкой значений в указанном режиме
+func (pt *PersistentTree) Reset(values []int, ordered bool) int {
+	op := "reset " + treeModeName(ordered)
+	if len(values) > 0 {
+		op += " " + joinInts(values)
+	}
+	version := PersistentVersion{Ordered: ordered, Op: op}
+	for _, value := range values {
+		version.Root, version.Size = persistentInsert(version.Root, version.Size, value, ordered)
+	}
+	return pt.push(version)
+}
+
+// ResetTree создает версию с копией дерева bt той же формы и в том же режиме
+func (pt *PersistentTree) ResetTree(bt *BinaryTree) int {
+	op := "reset " + treeModeName(bt.Ordered)
+	if bt.Root != nil {
+		op += " " + bt.LevelOrderString()
+	}
+	version := PersistentVersion{Ordered: bt.Ordered, Op: op}
+	var copyNode func(node *TreeNode) *PersistentNode
+	copyNode = func(node *TreeNode) *PersistentNode {
+		if node == nil {
+			return nil
+		}
+		version.Size++
+		return &PersistentNode{Digit: node.Digit, Left: copyNode(node.Left), Right: copyNode(node.Right)}
+	}
+	version.Root = copyNode(bt.Root)
+	return pt.push(version)
+}
+
+// Insert создает новую версию с добавленным значением и возвращает ее номер.
+// Если значение уже есть в дереве поиска, новая версия не создается.
+func (pt *PersistentTree) Insert(value int) (int, error) {
+	base, err := pt.pathCopyBase()
+	if err != nil {
+		return 0, err
+	}
+	root, size := persistentInsert(base.Root, base.Size, value, base.Ordered)
+	if root == base.Root {
+		return pt.current, nil
+	}
+	return pt.push(PersistentVersion{Root: root, Size: size, Ordered: base.Ordered, Op: fmt.Sprintf("insert %d", value)}), nil
+}
+
+// Delete создает новую версию без значения и возвращает ее номер
+func (pt *PersistentTree) Delete(value int) (int, error) {
+	base, err := pt.pathCopyBase()
+	if err != nil {
+		return 0, err
+	}
+	var root *PersistentNode
+	var found bool
+	if base.Ordered {
+		root, found = persistentDeleteOrdered(base.Root, value)
+	} else {
+		root, found = persistentDeleteLevelOrder(base.Root, base.Size, value)
+	}
+	if !found {
+		return 0, fmt.Errorf("значение %d не найдено в дереве", value)
+	}
+	return pt.push(PersistentVersion{Root: root, Size: base.Size - 1, Ordered: base.Ordered, Op: fmt.Sprintf("delete %d", value)}), nil
+}
+
+// pathCopyBase возвращает текущую версию для вставки или удаления с копированием пути.
+// В режиме заполнения по уровням путь задается номером позиции, поэтому
+// неполное дерево (например, из TBUILD) отклоняется.
+func (pt *PersistentTree) pathCopyBase() (PersistentVersion, error) {
+	base, err := pt.Version(pt.current)
+	if err != nil {
+		return base, fmt.Errorf("нет текущей версии")
+	}
+	if !base.Ordered && !persistentComplete(base.Root) {
+		return base, fmt.Errorf("версия %d - неполное дерево: вставка и удаление по позиции недоступны", pt.current)
+	}
+	return base, nil
+}
+
+// Checkout делает версию n текущей. Чтобы история не терялась, создается новая
+// версия, разделяющая корень с версией n; дальнейшие изменения продолжаются от нее.
+func (pt *PersistentTree) Checkout(n int) (int, error) {
+	version, err := pt.Version(n)
+	if err != nil {
+		return 0, err
+	}
+	version.Op = fmt.Sprintf("checkout %d", n)
+	return pt.push(version), nil
+}
+
+// Tree возвращает изменяемую копию версии n в виде BinaryTree
+func (pt *PersistentTree) Tree(n int) (*BinaryTree, error) {
+	version, err := pt.Version(n)
+	if err != nil {
+		return nil, err
+	}
+	var clone func(node *PersistentNode) *TreeNode
+	clone = func(node *PersistentNode) *TreeNode {
+		if node == nil {
+			return nil
+		}
+		return &TreeNode{Digit: node.Digit, Left: clone(node.Left), Right: clone(node.Right)}
+	}
+	bt := &BinaryTree{Root: clone(version.Root), Ordered: version.Ordered}
+	if bt.Ordered {
+		bt.updateSizes()
+	}
+	return bt, nil
+}
+
+// Diff возвращает значения, добавленные и удаленные при переходе от версии a к версии b.
+// Версии обходятся одновременно по одинаковым позициям, и поддерево, которое
+// в обеих версиях стоит на одном месте и разделяется, пропускается без обхода.
+// Поэтому для соседних версий обходятся в основном скопированные пути.
+func (pt *PersistentTree) Diff(a, b int) (added, removed []int, err error) {
+	from, err := pt.Version(a)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := pt.Version(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	counts := make(map[int]int)
+	persistentDiffWalk(from.Root, to.Root, counts)
+	for value, count := range counts {
+		for ; count > 0; count-- {
+			added = append(added, value)
+		}
+		for ; count < 0; count++ {
+			removed = append(removed, value)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+	return added, removed, nil
+}
+
+// SharedNodes возвращает количество узлов версии b, общих с версией a
+func (pt *PersistentTree) SharedNodes(a, b int) (int, error) {
+	from, err := pt.Version(a)
+	if err != nil {
+		return 0, err
+	}
+	to, err := pt.Version(b)
+	if err != nil {
+		return 0, err
+	}
+	fromNodes := persistentNodeSet(from.Root)
+	shared := 0
+	for node := range persistentNodeSet(to.Root) {
+		if fromNodes[node] {
+			shared++
+		}
+	}
+	return shared, nil
+}
+
+// Record сохраняет состояние дерева после команды как новую версию, если его
+// значения или форма изменились. Для TINSERT и TDEL выполняется вставка или удаление
+// с копированием пути; если результат не совпал с деревом (или команда другая),
+// создается reset с копией дерева. Для неполного дерева в режиме заполнения
+// по уровням (например, из TBUILD) Insert и Delete отказывают, и тоже создается reset.
+func (pt *PersistentTree) Record(command string, bt *BinaryTree) bool {
+	base, err := pt.Version(pt.current)
+	if err == nil && base.Ordered == bt.Ordered {
+		if persistentSameTree(base.Root, bt.Root) {
+			return false
+		}
+		mark := len(pt.versions)
+		if tokens := strings.Split(command, " "); len(tokens) == 2 {
+			if value, err := strconv.Atoi(tokens[1]); err == nil {
+				switch tokens[0] {
+				case "TINSERT":
+					pt.Insert(value)
+				case "TDEL":
+					pt.Delete(value)
+				}
+			}
+		}
+		if len(pt.versions) > mark {
+			if persistentSameTree(pt.versions[mark].Root, bt.Root) {
+				return true
+			}
+			pt.versions = pt.versions[:mark]
+			pt.current = mark - 1
+		}
+	}
+	pt.ResetTree(bt)
+	return true
+}
+
+// PrintVersions печатает список версий с операциями и количеством узлов,
+// общих с предыдущей версией; текущая версия помечена звездочкой
+func (pt *PersistentTree) PrintVersions() {
+	if len(pt.versions) == 0 {
+		fmt.Println("История версий пуста.")
+		return
+	}
+	for n, version := range pt.versions {
+		marker := " "
+		if n == pt.current {
+			marker = "*"
+		}
+		fmt.Printf("%s %d: %s (узлов: %d", marker, n, version.Op, version.Size)
+		if n > 0 {
+			shared, _ := pt.SharedNodes(n-1, n)
+			fmt.Printf(", общих с предыдущей: %d", shared)
+		}
+		fmt.Println(")")
+	}
+}
+
+// LoadFromFile восстанавливает историю версий, повторяя операции журнала
+func (pt *PersistentTree) LoadFromFile(filename string) error {
+	file, err := openSnapshotFile(filename)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть файл: %v", err)
+	}
+	defer file.Close()
+
+	restored := NewPersistentTree()
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if err := restored.apply(scanner.Text()); err != nil {
+			return fmt.Errorf("ошибка в строке %d журнала версий: %v", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения файла: %v", err)
+	}
+	*pt = *restored
+	return nil
+}
+
+// apply выполняет одну операцию журнала версий
+func (pt *PersistentTree) apply(op string) error {
+	tokens := strings.Split(op, " ")
+	switch {
+	case tokens[0] == "reset" && (len(tokens) == 3 || len(tokens) == 2):
+		ordered := tokens[1] == "bst"
+		if !ordered && tokens[1] != "cbt" {
+			return fmt.Errorf("неизвестный режим дерева: %s", tokens[1])
+		}
+		if len(tokens) == 3 && strings.HasPrefix(tokens[2], "[") {
+			bt, err := ParseLevelOrder(tokens[2])
+			if err != nil {
+				return err
+			}
+			bt.Ordered = ordered
+			pt.ResetTree(bt)
+			return nil
+		}
+		values := []int{}
+		if len(tokens) == 3 {
+			var err error
+			if values, err = ParseIntList(tokens[2]); err != nil {
+				return err
+			}
+		}
+		pt.Reset(values, ordered)
+		return nil
+	case len(tokens) == 2:
+		value, err := strconv.Atoi(tokens[1])
+		if err != nil {
+			return fmt.Errorf("недопустимое значение: %v", err)
+		}
+		switch tokens[0] {
+		case "insert":
+			_, err = pt.Insert(value)
+		case "delete":
+			_, err = pt.Delete(value)
+		case "checkout":
+			_, err = pt.Checkout(value)
+		default:
+			err = fmt.Errorf("неизвестная операция: %s", tokens[0])
+		}
+		return err
+	}
+	return fmt.Errorf("некорректная операция: %s", op)
+}
+
+// SaveToFile сохраняет журнал операций, из которого восстанавливаются все версии
+func (pt *PersistentTree) SaveToFile(filename string) error {
+	file, err := createSnapshotFile(filename)
+	if err != nil {
+		return fmt.Errorf("не удалось создать файл: %v", err)
+	}
+	defer file.Close()
+
+	for _, version := range pt.versions {
+		if _, err := file.WriteString(version.Op + "\n"); err != nil {
+			return fmt.Errorf("ошибка записи в файл: %v", err)
+		}
+	}
+	return file.Commit()
+}
+
+// treeModeName возвращает название режима дерева, как во флаге --tree
+func treeModeName(ordered bool) string {
+	if ordered {
+		return "bst"
+	}
+	return "cbt"
+}
+
+// persistentInsert вставляет значение с копированием пути и возвращает новый корень и размер
+func persistentInsert(root *PersistentNode, size int, value int, ordered bool) (*PersistentNode, int) {
+	if !ordered {
+		// Дерево, заполненное по уровням, полное: новый узел занимает позицию size+1
+		return updatePath(root, size+1, func(*PersistentNode) *PersistentNode {
+			return &PersistentNode{Digit: value}
+		}), size + 1
+	}
+	var insert func(node *PersistentNode) *PersistentNode
+	insert = func(node *PersistentNode) *PersistentNode {
+		if node == nil {
+			return &PersistentNode{Digit: value}
+		}
+		copied := *node
+		switch {
+		case value < node.Digit:
+			if copied.Left = insert(node.Left); copied.Left == node.Left {
+				return node
+			}
+		case value > node.Digit:
+			if copied.Right = insert(node.Right); copied.Right == node.Right {
+				return node
+			}
+		default:
+			return node
+		}
+		return &copied
+	}
+	newRoot := insert(root)
+	if newRoot == root {
+		return root, size
+	}
+	return newRoot, size + 1
+}
+
+// persistentDeleteOrdered удаляет значение из дерева поиска с копированием пути
+func persistentDeleteOrdered(node *PersistentNode, value int) (*PersistentNode, bool) {
+	if node == nil {
+		return nil, false
+	}
+	copied := *node
+	var found bool
+	switch {
+	case value < node.Digit:
+		copied.Left, found = persistentDeleteOrdered(node.Left, value)
+	case value > node.Digit:
+		copied.Right, found = persistentDeleteOrdered(node.Right, value)
+	default:
+		if node.Left == nil {
+			return node.Right, true
+		}
+		if node.Right == nil {
+			return node.Left, true
+		}
+		// Заменяем значение наименьшим из правого поддерева и удаляем его узел
+		successor := node.Right
+		for successor.Left != nil {
+			successor = successor.Left
+		}
+		copied.Digit = successor.Digit
+		copied.Right, _ = persistentDeleteOrdered(node.Right, successor.Digit)
+		return &copied, true
+	}
+	if !found {
+		return node, false
+	}
+	return &copied, true
+}
+
+// persistentDeleteLevelOrder удаляет значение с заменой на последний узел
+// в порядке обхода по уровням, как BinaryTree.Delete
+func persistentDeleteLevelOrder(root *PersistentNode, size int, value int) (*PersistentNode, bool) {
+	target := 0
+	for index, node := range persistentLevelOrderNodes(root) {
+		if node.Digit == value {
+			target = index + 1
+			break
+		}
+	}
+	if target == 0 {
+		return root, false
+	}
+	var last int
+	updatePath(root, size, func(node *PersistentNode) *PersistentNode {
+		last = node.Digit
+		return node
+	})
+	root = updatePath(root, target, func(node *PersistentNode) *PersistentNode {
+		copied := *node
+		copied.Digit = last
+		return &copied
+	})
+	return updatePath(root, size, func(*PersistentNode) *PersistentNode { return nil }), true
+}
+
+// updatePath копирует путь от корня до позиции index (нумерация по уровням с 1)
+// и заменяет поддерево в этой позиции результатом fn. Биты номера после старшей
+// единицы задают направление на каждом уровне: 0 - влево, 1 - вправо.
+func updatePath(root *PersistentNode, index int, fn func(node *PersistentNode) *PersistentNode) *PersistentNode {
+	var update func(node *PersistentNode, shift int) *PersistentNode
+	update = func(node *PersistentNode, shift int) *PersistentNode {
+		if shift < 0 {
+			return fn(node)
+		}
+		copied := *node
+		if index>>shift&1 == 0 {
+			copied.Left = update(node.Left, shift-1)
+		} else {
+			copied.Right = update(node.Right, shift-1)
+		}
+		return &copied
+	}
+	return update(root, bits.Len(uint(index))-2)
+}
+
+// persistentLevelOrderNodes возвращает узлы в порядке обхода по уровням
+func persistentLevelOrderNodes(root *PersistentNode) []*PersistentNode {
+	var nodes []*PersistentNode
+	if root != nil {
+		nodes = append(nodes, root)
+	}
+	for i := 0; i < len(nodes); i++ {
+		if nodes[i].Left != nil {
+			nodes = append(nodes, nodes[i].Left)
+		}
+		if nodes[i].Right != nil {
+			nodes = append(nodes, nodes[i].Right)
+		}
+	}
+	return nodes
+}
+
+// persistentLevelOrder возвращает значения в порядке обхода по уровням
+func persistentLevelOrder(root *PersistentNode) []int {
+	values := []int{}
+	for _, node := range persistentLevelOrderNodes(root) {
+		values = append(values, node.Digit)
+	}
+	return values
+}
+
+// persistentSameTree проверяет, что версия совпадает с деревом по форме и значениям
+func persistentSameTree(root *PersistentNode, node *TreeNode) bool {
+	if root == nil || node == nil {
+		return root == nil && node == nil
+	}
+	return root.Digit == node.Digit && persistentSameTree(root.Left, node.Left) && persistentSameTree(root.Right, node.Right)
+}
+
+// persistentComplete проверяет, что дерево полное: при обходе по уровням
+// после первого отсутствующего потомка не встречается ни одного узла
+func persistentComplete(root *PersistentNode) bool {
+	queue := []*PersistentNode{root}
+	gap := false
+	for i := 0; i < len(queue); i++ {
+		if queue[i] == nil {
+			gap = true
+			continue
+		}
+		if gap {
+			return false
+		}
+		queue = append(queue, queue[i].Left, queue[i].Right)
+	}
+	return true
+}
+
+// persistentNodeSet возвращает множество узлов дерева
+func persistentNodeSet(root *PersistentNode) map[*PersistentNode]bool {
+	set := make(map[*PersistentNode]bool)
+	for _, node := range persistentLevelOrderNodes(root) {
+		set[node] = true
+	}
+	return set
+}
+
+// persistentDiffWalk обходит узлы from и to на одинаковых позициях, вычитая значения
+// from из counts и прибавляя значения to. Общее поддерево дает нулевой вклад и не обходится.
+func persistentDiffWalk(from, to *PersistentNode, counts map[int]int) {
+	if from == to {
+		return
+	}
+	var fromLeft, fromRight, toLeft, toRight *PersistentNode
+	if from != nil {
+		counts[from.Digit]--
+		fromLeft, fromRight = from.Left, from.Right
+	}
+	if to != nil {
+		counts[to.Digit]++
+		toLeft, toRight = to.Left, to.Right
+	}
+	persistentDiffWalk(fromLeft, toLeft, counts)
+	persistentDiffWalk(fromRight, toRight, counts)
+}
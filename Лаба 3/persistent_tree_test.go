@@ -0,0 +1,225 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestPersistentTreeMatchesBinaryTree проверяет, что версии совпадают с изменяемым деревом
+// и что старые версии не меняются после новых операций
+func TestPersistentTreeMatchesBinaryTree(t *testing.T) {
+	for _, ordered := range []bool{false, true} {
+		bt := &BinaryTree{Ordered: ordered}
+		pt := NewPersistentTree()
+		pt.Reset(nil, ordered)
+		snapshots := map[int][]int{0: {}}
+		rng := rand.New(rand.NewSource(3))
+		for step := 0; step < 300; step++ {
+			value := rng.Intn(40)
+			if rng.Intn(3) == 0 {
+				found := bt.Delete(value)
+				if _, err := pt.Delete(value); (err == nil) != found {
+					t.Fatalf("ordered=%v: Delete(%d) error = %v; want found = %v", ordered, value, err, found)
+				}
+			} else {
+				bt.Insert(value)
+				pt.Insert(value)
+			}
+			version, _ := pt.Version(pt.Current())
+			values := slices.Collect(bt.LevelOrder())
+			if !slices.Equal(persistentLevelOrder(version.Root), values) || version.Size != len(values) {
+				t.Fatalf("ordered=%v: version %d = %v; want %v", ordered, pt.Current(), persistentLevelOrder(version.Root), values)
+			}
+			snapshots[pt.Current()] = values
+		}
+		for n := 0; n < pt.Len(); n++ {
+			tree, err := pt.Tree(n)
+			if err != nil || tree.Ordered != ordered {
+				t.Fatalf("Tree(%d) = %v, %v", n, tree, err)
+			}
+			if values := slices.Collect(tree.LevelOrder()); !slices.Equal(values, snapshots[n]) {
+				t.Fatalf("ordered=%v: version %d = %v after later operations; want %v", ordered, n, values, snapshots[n])
+			}
+		}
+	}
+}
+
+// TestPersistentTreeSharing проверяет разделение узлов между версиями
+func TestPersistentTreeSharing(t *testing.T) {
+	pt := NewPersistentTree()
+	pt.Reset([]int{50, 25, 75, 10, 30, 60, 90}, true)
+	v1, _ := pt.Insert(95)
+	// Копируется только путь 50 -> 75 -> 90, остальные 4 узла общие
+	if shared, _ := pt.SharedNodes(0, v1); shared != 4 {
+		t.Errorf("SharedNodes(0, %d) = %d; want 4", v1, shared)
+	}
+	// Diff обходит только скопированный путь, общие поддеревья 25 и 60 пропускаются
+	before, _ := pt.Version(0)
+	after, _ := pt.Version(v1)
+	visited := make(map[int]int)
+	persistentDiffWalk(before.Root, after.Root, visited)
+	if len(visited) != 4 {
+		t.Errorf("Diff(0, %d) обошел значения %v; want только путь 50, 75, 90, 95", v1, visited)
+	}
+	v2, _ := pt.Delete(25)
+	if shared, _ := pt.SharedNodes(v1, v2); shared != 5 {
+		t.Errorf("SharedNodes(%d, %d) = %d; want 5", v1, v2, shared)
+	}
+	if same, _ := pt.Insert(95); same != v2 {
+		t.Errorf("Insert() of existing value created version %d", same)
+	}
+
+	added, removed, err := pt.Diff(0, v2)
+	if err != nil || !slices.Equal(added, []int{95}) || !slices.Equal(removed, []int{25}) {
+		t.Errorf("Diff(0, %d) = %v, %v, %v; want [95], [25]", v2, added, removed, err)
+	}
+	if _, _, err := pt.Diff(0, 42); err == nil {
+		t.Errorf("Diff() with missing version error = nil")
+	}
+	if _, err := pt.Delete(1000); err == nil {
+		t.Errorf("Delete() of missing value error = nil")
+	}
+}
+
+// TestPersistentTreeCheckout проверяет переход к старой версии и продолжение истории от нее
+func TestPersistentTreeCheckout(t *testing.T) {
+	pt := NewPersistentTree()
+	if _, err := pt.Insert(1); err == nil {
+		t.Errorf("Insert() without versions error = nil")
+	}
+	pt.Reset([]int{1, 2, 3}, false)
+	pt.Insert(4)
+	pt.Delete(1)
+	n, err := pt.Checkout(0)
+	if err != nil || n != 3 || pt.Current() != 3 {
+		t.Fatalf("Checkout(0) = %d, %v", n, err)
+	}
+	pt.Insert(5)
+	version, _ := pt.Version(pt.Current())
+	if !slices.Equal(persistentLevelOrder(version.Root), []int{1, 2, 3, 5}) {
+		t.Errorf("Insert() after Checkout() = %v", persistentLevelOrder(version.Root))
+	}
+	if _, err := pt.Checkout(10); err == nil {
+		t.Errorf("Checkout(10) error = nil")
+	}
+}
+
+// TestPersistentTreeRecord проверяет запись версий по командам
+func TestPersistentTreeRecord(t *testing.T) {
+	bt := NewBinarySearchTree()
+	pt := NewPersistentTree()
+	if !pt.Record("load", bt) || pt.Len() != 1 {
+		t.Fatalf("Record() did not create the initial version")
+	}
+	if pt.Record("TFIND 1", bt) {
+		t.Errorf("Record() created a version for an unchanged tree")
+	}
+	bt.Insert(8)
+	pt.Record("TINSERT 8", bt)
+	bt.Insert(3)
+	bt.Set(0, 9)
+	pt.Record("TSET 0 9", bt)
+	ops := []string{}
+	for n := 0; n < pt.Len(); n++ {
+		version, _ := pt.Version(n)
+		ops = append(ops, version.Op)
+	}
+	if !slices.Equal(ops, []string{"reset bst", "insert 8", "reset bst [9,3]"}) {
+		t.Errorf("Record() ops = %v", ops)
+	}
+}
+
+// TestPersistentTreeRecordShape проверяет, что версии хранят форму неполного дерева из TBUILD
+func TestPersistentTreeRecordShape(t *testing.T) {
+	bt := NewBinaryTree()
+	for _, value := range []int{1, 2, 3} {
+		bt.Insert(value)
+	}
+	pt := NewPersistentTree()
+	pt.Record("load", bt)
+	built, _ := ParseLevelOrder("[1,null,2,3]")
+	bt.Replace(built)
+	if !pt.Record("TBUILD level [1,null,2,3]", bt) || pt.Len() != 2 {
+		t.Fatalf("Record() не создал версию для дерева с теми же значениями и другой формой")
+	}
+	bt.Insert(4)
+	pt.Record("TINSERT 4", bt)
+	bt.Delete(2)
+	pt.Record("TDEL 2", bt)
+
+	for n, want := range []string{"[1,2,3]", "[1,null,2,3]", "[1,4,2,null,null,3]", "[1,4,3]"} {
+		tree, err := pt.Tree(n)
+		if err != nil || tree.LevelOrderString() != want {
+			t.Errorf("Tree(%d) = %v, %v; want %s", n, tree.LevelOrderString(), err, want)
+		}
+	}
+	if added, removed, _ := pt.Diff(1, 2); !slices.Equal(added, []int{4}) || len(removed) != 0 {
+		t.Errorf("Diff(1, 2) = %v, %v; want [4], []", added, removed)
+	}
+	if added, removed, _ := pt.Diff(0, 1); len(added) != 0 || len(removed) != 0 {
+		t.Errorf("Diff(0, 1) = %v, %v; want [], []", added, removed)
+	}
+
+	filename := filepath.Join(t.TempDir(), "versions.txt")
+	pt.SaveToFile(filename)
+	loaded := NewPersistentTree()
+	if err := loaded.LoadFromFile(filename); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if tree, _ := loaded.Tree(1); tree.LevelOrderString() != "[1,null,2,3]" {
+		t.Errorf("После загрузки Tree(1) = %s", tree.LevelOrderString())
+	}
+}
+
+// TestPersistentTreeSaveLoad проверяет восстановление истории из журнала операций
+func TestPersistentTreeSaveLoad(t *testing.T) {
+	filename := "test_versions.txt"
+	defer os.Remove(filename)
+
+	pt := NewPersistentTree()
+	pt.Reset([]int{5, 3}, true)
+	pt.Insert(8)
+	pt.Delete(5)
+	pt.Checkout(1)
+	if err := pt.SaveToFile(filename); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	loaded := NewPersistentTree()
+	if err := loaded.LoadFromFile(filename); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if loaded.Len() != pt.Len() || loaded.Current() != pt.Current() {
+		t.Fatalf("LoadFromFile() restored %d versions; want %d", loaded.Len(), pt.Len())
+	}
+	for n := 0; n < pt.Len(); n++ {
+		want, _ := pt.Version(n)
+		got, _ := loaded.Version(n)
+		if got.Op != want.Op || !slices.Equal(persistentLevelOrder(got.Root), persistentLevelOrder(want.Root)) {
+			t.Errorf("version %d = %v; want %v", n, got, want)
+		}
+	}
+	if shared, _ := loaded.SharedNodes(1, 2); shared == 0 {
+		t.Errorf("replayed versions do not share nodes")
+	}
+
+	for _, content := range []string{"reset avl 1\n", "insert 1\n", "reset cbt 1\nmove 2\n", "reset cbt\ndelete 1\n",
+		"reset cbt [1,null,2,null,3]\ninsert 5\n", "reset cbt [1,null,2,null,3]\ndelete 3\n"} {
+		os.WriteFile(filename, []byte(content), 0644)
+		if err := loaded.LoadFromFile(filename); err == nil {
+			t.Errorf("LoadFromFile(%q) error = nil; want error", content)
+		}
+	}
+	os.WriteFile(filename, []byte("reset cbt [1,null,2,null,3]\ncheckout 0\n"), 0644)
+	if err := loaded.LoadFromFile(filename); err != nil || loaded.Len() != 2 {
+		t.Errorf("LoadFromFile() неполного дерева: %d версий, %v", loaded.Len(), err)
+	}
+	if _, err := loaded.Insert(5); err == nil {
+		t.Errorf("Insert() в неполное дерево error = nil; want error")
+	}
+	if err := loaded.LoadFromFile("nonexistent_versions.txt"); err == nil {
+		t.Errorf("LoadFromFile() error = nil; want error for missing file")
+	}
+}
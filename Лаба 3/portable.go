@@ -0,0 +1,210 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+)
+
+// Переносимое представление структур для форматов MessagePack и CBOR. Структура
+// сначала превращается в значение из небольшого набора типов, который есть
+// в обоих форматах и в любом языке: nil, bool, int64, string, []any и
+// map[string]any. Списочные структуры - массив строк в порядке SerializeText,
+// хэш-таблица - словарь, бинарное дерево - вложенные узлы {"Digit", "Left",
+// "Right"}, как в JSON. AVL- и красно-черное дерево - массив чисел в порядке
+// обхода по уровням, как в бинарном формате: вставка в этом порядке
+// восстанавливает ту же форму дерева.
+
+// portableCodec - структура, у которой есть переносимое представление
+type portableCodec interface {
+	portable() any
+	setPortable(value any) error
+}
+
+// serializeMsgPack кодирует структуру в MessagePack
+func serializeMsgPack(structure portableCodec) ([]byte, error) {
+	return encodeMsgPack(structure.portable())
+}
+
+// deserializeMsgPack восстанавливает структуру из MessagePack; при ошибке структура не меняется
+func deserializeMsgPack(structure portableCodec, data []byte) error {
+	value, err := decodeMsgPack(data)
+	if err == nil {
+		err = structure.setPortable(value)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка десериализации из MessagePack: %v", err)
+	}
+	return nil
+}
+
+// serializeCBOR кодирует структуру в CBOR
+func serializeCBOR(structure portableCodec) ([]byte, error) {
+	return encodeCBOR(structure.portable())
+}
+
+// deserializeCBOR восстанавливает структуру из CBOR; при ошибке структура не меняется
+func deserializeCBOR(structure portableCodec, data []byte) error {
+	value, err := decodeCBOR(data)
+	if err == nil {
+		err = structure.setPortable(value)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка десериализации из CBOR: %v", err)
+	}
+	return nil
+}
+
+// maxPortableDepth - наибольшая вложенность массивов и словарей при разборе,
+// как у encoding/json: глубже данные считаются поврежденными
+const maxPortableDepth = 10000
+
+// sortedPortableKeys возвращает ключи словаря сначала по длине, затем по байтам.
+// Для строк это совпадает с детерминированным порядком ключей CBOR (RFC 8949, 4.2.1).
+func sortedPortableKeys(values map[string]any) []string {
+	return slices.SortedFunc(maps.Keys(values), func(a, b string) int {
+		if len(a) != len(b) {
+			return cmp.Compare(len(a), len(b))
+		}
+		return cmp.Compare(a, b)
+	})
+}
+
+// portableKind возвращает название типа переносимого значения для сообщений об ошибках
+func portableKind(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "логическое значение"
+	case int64:
+		return "целое число"
+	case string:
+		return "строка"
+	case []any:
+		return "массив"
+	case map[string]any:
+		return "словарь"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// portableStrings превращает последовательность строк в переносимый массив
+func portableStrings(values iter.Seq[string]) []any {
+	result := []any{}
+	for value := range values {
+		result = append(result, value)
+	}
+	return result
+}
+
+// stringsFromPortable разбирает переносимый массив строк
+func stringsFromPortable(value any) ([]string, error) {
+	items, ok := value.([]any)
+	if !ok && value != nil { // null, как и в JSON, - пустая структура
+		return nil, fmt.Errorf("ожидался массив строк, получено: %s", portableKind(value))
+	}
+	result := make([]string, len(items))
+	for i, item := range items {
+		text, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("элемент %d: ожидалась строка, получено: %s", i, portableKind(item))
+		}
+		result[i] = text
+	}
+	return result, nil
+}
+
+// portableInts превращает числа в переносимый массив
+func portableInts(values []int) []any {
+	result := make([]any, len(values))
+	for i, value := range values {
+		result[i] = int64(value)
+	}
+	return result
+}
+
+// intsFromPortable разбирает переносимый массив чисел
+func intsFromPortable(value any) ([]int, error) {
+	items, ok := value.([]any)
+	if !ok && value != nil { // null, как и в JSON, - пустая структура
+		return nil, fmt.Errorf("ожидался массив чисел, получено: %s", portableKind(value))
+	}
+	result := make([]int, len(items))
+	for i, item := range items {
+		number, ok := item.(int64)
+		if !ok {
+			return nil, fmt.Errorf("элемент %d: ожидалось целое число, получено: %s", i, portableKind(item))
+		}
+		result[i] = int(number)
+	}
+	return result, nil
+}
+
+// portableDecoder - общее состояние разбора MessagePack и CBOR
+type portableDecoder struct {
+	data []byte
+	pos  int
+}
+
+// byte читает один байт
+func (d *portableDecoder) byte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, fmt.Errorf("неожиданный конец данных")
+	}
+	d.pos++
+	return d.data[d.pos-1], nil
+}
+
+// uint читает беззнаковое число из size байт в порядке big-endian
+func (d *portableDecoder) uint(size int) (uint64, error) {
+	if len(d.data)-d.pos < size {
+		return 0, fmt.Errorf("неожиданный конец данных")
+	}
+	var value uint64
+	for _, b := range d.data[d.pos : d.pos+size] {
+		value = value<<8 | uint64(b)
+	}
+	d.pos += size
+	return value, nil
+}
+
+// string читает строку из length байт
+func (d *portableDecoder) string(length uint64) (string, error) {
+	if length > maxStringLength {
+		return "", fmt.Errorf("длина строки %d превышает допустимую %d", length, maxStringLength)
+	}
+	if length > uint64(len(d.data)-d.pos) {
+		return "", fmt.Errorf("длина строки %d больше оставшихся данных", length)
+	}
+	value := string(d.data[d.pos : d.pos+int(length)])
+	d.pos += int(length)
+	return value, nil
+}
+
+// enter проверяет вложенность и то, что length элементов по minSize байт
+// помещаются в оставшиеся данные, до выделения памяти под них
+func (d *portableDecoder) enter(length uint64, minSize int, depth int) error {
+	if depth >= maxPortableDepth {
+		return fmt.Errorf("превышена глубина вложенности %d", maxPortableDepth)
+	}
+	if length > uint64(len(d.data)-d.pos)/uint64(minSize) {
+		return fmt.Errorf("количество элементов %d больше оставшихся данных", length)
+	}
+	return nil
+}
+
+// addPortableKey добавляет пару в словарь; ключи должны быть строками и не повторяться
+func addPortableKey(values map[string]any, key, value any) error {
+	text, ok := key.(string)
+	if !ok {
+		return fmt.Errorf("ключ словаря должен быть строкой, получено: %s", portableKind(key))
+	}
+	if _, exists := values[text]; exists {
+		return fmt.Errorf("ключ %q повторяется", text)
+	}
+	values[text] = value
+	return nil
+}
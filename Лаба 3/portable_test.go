@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// portableStructure - структура с кодированием в MessagePack и CBOR
+type portableStructure interface {
+	SerializeMsgPack() ([]byte, error)
+	DeserializeMsgPack(data []byte) error
+	SerializeCBOR() ([]byte, error)
+	DeserializeCBOR(data []byte) error
+}
+
+// portableStructures возвращает структуры набора по названиям, как в EXPORT и IMPORT
+func (s *testStructures) portableStructures() map[string]portableStructure {
+	return map[string]portableStructure{
+		"array": s.array, "stack": s.stack, "queue": s.queue, "singly_list": s.singlyList,
+		"doubly_list": s.doublyList, "hash_table": s.hashTable, "binary_tree": s.cbTree,
+		"avl_tree": s.avlTree, "rb_tree": s.rbTree,
+	}
+}
+
+// portableFormats - пары функций сериализации и десериализации для каждого формата
+var portableFormats = map[string]struct {
+	serialize   func(portableStructure) ([]byte, error)
+	deserialize func(portableStructure, []byte) error
+}{
+	"MessagePack": {portableStructure.SerializeMsgPack, portableStructure.DeserializeMsgPack},
+	"CBOR":        {portableStructure.SerializeCBOR, portableStructure.DeserializeCBOR},
+}
+
+// TestPortableRoundTrip проверяет сохранение и восстановление всех структур в обоих форматах
+func TestPortableRoundTrip(t *testing.T) {
+	original := filledStructures()
+	original.queue.Push("\xff\xfe") // Некорректный UTF-8 записывается как байтовая строка
+	for formatName, format := range portableFormats {
+		for name, structure := range original.portableStructures() {
+			data, err := format.serialize(structure)
+			if err != nil {
+				t.Fatalf("%s/%s: ошибка сериализации: %v", name, formatName, err)
+			}
+			restored := emptyStructures()
+			if err := format.deserialize(restored.portableStructures()[name], data); err != nil {
+				t.Fatalf("%s/%s: ошибка десериализации: %v", name, formatName, err)
+			}
+			if restored.dump(name) != original.dump(name) {
+				t.Errorf("%s/%s: восстановлено %s, ожидалось %s", name, formatName, restored.dump(name), original.dump(name))
+			}
+			again, _ := format.serialize(restored.portableStructures()[name])
+			if string(again) != string(data) {
+				t.Errorf("%s/%s: повторная сериализация отличается", name, formatName)
+			}
+		}
+	}
+}
+
+// TestPortableLayout проверяет форму данных, которую читают программы на других языках
+func TestPortableLayout(t *testing.T) {
+	s := emptyStructures()
+	s.stack.Push("x")
+	s.stack.Push("y")
+	s.hashTable.HSet("k", "v")
+	s.cbTree.Insert(2)
+	s.avlTree.Insert(1)
+	s.avlTree.Insert(-1)
+	cases := []struct {
+		structure portableStructure
+		msgPack   string
+		cbor      string
+	}{
+		{s.stack, "92a179a178", "8261796178"},
+		{s.hashTable, "81a16ba176", "a1616b6176"},
+		{s.avlTree, "9201ff", "820120"},
+		// Ключи упорядочены сначала по длине: Left, Digit, Right
+		{s.cbTree, "82a4526f6f7483a44c656674c0a5446967697402a55269676874c0a74f726465726564c3",
+			"a264526f6f74a3644c656674f665446967697402655269676874f6674f726465726564f5"},
+	}
+	for _, tc := range cases {
+		msgPack, _ := tc.structure.SerializeMsgPack()
+		if hex.EncodeToString(msgPack) != tc.msgPack {
+			t.Errorf("%T: MessagePack %x, ожидалось %s", tc.structure, msgPack, tc.msgPack)
+		}
+		cbor, _ := tc.structure.SerializeCBOR()
+		if hex.EncodeToString(cbor) != tc.cbor {
+			t.Errorf("%T: CBOR %x, ожидалось %s", tc.structure, cbor, tc.cbor)
+		}
+	}
+}
+
+// TestPortableRejectsWrongShape проверяет отклонение данных другой формы без изменения структуры
+func TestPortableRejectsWrongShape(t *testing.T) {
+	cases := []struct {
+		structure string
+		value     any
+		message   string
+	}{
+		{"stack", map[string]any{}, "ожидался массив строк"},
+		{"queue", []any{"a", int64(1)}, "элемент 1: ожидалась строка"},
+		{"array", []any{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}, "превышает емкость массива"},
+		{"hash_table", map[string]any{"k": int64(1)}, `значение ключа "k": ожидалась строка`},
+		{"hash_table", []any{}, "ожидался словарь"},
+		{"avl_tree", []any{int64(1), int64(1)}, "значение 1 повторяется"},
+		{"rb_tree", []any{"1"}, "ожидалось целое число"},
+		{"binary_tree", map[string]any{"Root": nil, "Size": int64(1)}, `неизвестное поле дерева "Size"`},
+		{"binary_tree", map[string]any{"Root": map[string]any{"Left": nil}}, "поле Digit"},
+		{"binary_tree", map[string]any{"Ordered": true, "Root": map[string]any{"Digit": int64(1),
+			"Left": map[string]any{"Digit": int64(5)}}}, "нарушает порядок дерева поиска"},
+	}
+	for _, tc := range cases {
+		for formatName, encode := range map[string]func(any) ([]byte, error){"MessagePack": encodeMsgPack, "CBOR": encodeCBOR} {
+			data, err := encode(tc.value)
+			if err != nil {
+				t.Fatalf("ошибка кодирования %#v: %v", tc.value, err)
+			}
+			s := filledStructures()
+			before := s.dump(tc.structure)
+			err = portableFormats[formatName].deserialize(s.portableStructures()[tc.structure], data)
+			if err == nil || !strings.Contains(err.Error(), tc.message) {
+				t.Errorf("%s/%s: ожидалась ошибка %q, получено %v", tc.structure, formatName, tc.message, err)
+			}
+			if s.dump(tc.structure) != before {
+				t.Errorf("%s/%s: структура изменена при ошибке", tc.structure, formatName)
+			}
+		}
+	}
+}
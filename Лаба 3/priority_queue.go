@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// HeapKind определяет порядок приоритетов в куче
+type HeapKind int
+
+const (
+	MinHeap HeapKind = iota // Сверху элемент с наименьшим приоритетом
+	MaxHeap                 // Сверху элемент с наибольшим приоритетом
+)
+
+// String возвращает название вида кучи
+func (k HeapKind) String() string {
+	if k == MaxHeap {
+		return "max"
+	}
+	return "min"
+}
+
+// ParseHeapKind разбирает название вида кучи
+func ParseHeapKind(name string) (HeapKind, error) {
+	switch strings.ToLower(name) {
+	case "min":
+		return MinHeap, nil
+	case "max":
+		return MaxHeap, nil
+	}
+	return MinHeap, fmt.Errorf("неизвестный вид кучи: %s", name)
+}
+
+// PQItem представляет элемент очереди с приоритетом
+type PQItem struct {
+	Value    string
+	Priority int
+}
+
+// PriorityQueue представляет очередь с приоритетом на основе двоичной кучи.
+// Куча хранится в массиве в порядке обхода по уровням, как полное дерево
+// BinaryTree: потомки элемента i находятся по индексам 2i+1 и 2i+2.
+// Значения уникальны, что позволяет изменять приоритет по значению.
+type PriorityQueue struct {
+	Kind      HeapKind       // Public
+	items     []PQItem       // Элементы кучи
+	positions map[string]int // Индекс элемента в items по значению
+}
+
+// NewPriorityQueue создает пустую очередь с приоритетом (Public)
+func NewPriorityQueue(kind HeapKind) *PriorityQueue {
+	return &PriorityQueue{Kind: kind, positions: make(map[string]int)}
+}
+
+// NewPriorityQueueFromSlice строит очередь из элементов за O(n) (Public).
+// При повторяющихся значениях сохраняется последний приоритет.
+func NewPriorityQueueFromSlice(items []PQItem, kind HeapKind) *PriorityQueue {
+	pq := NewPriorityQueue(kind)
+	for _, item := range items {
+		if i, ok := pq.positions[item.Value]; ok {
+			pq.items[i].Priority = item.Priority
+			continue
+		}
+		pq.positions[item.Value] = len(pq.items)
+		pq.items = append(pq.items, item)
+	}
+	pq.heapify()
+	return pq
+}
+
+// Len возвращает количество элементов в очереди (Public)
+func (pq *PriorityQueue) Len() int {
+	return len(pq.items)
+}
+
+// Push добавляет элемент; если значение уже есть, меняет его приоритет (Public)
+func (pq *PriorityQueue) Push(value string, priority int) {
+	if i, ok := pq.positions[value]; ok {
+		pq.items[i].Priority = priority
+		pq.fix(i)
+		return
+	}
+	pq.items = append(pq.items, PQItem{Value: value, Priority: priority})
+	pq.positions[value] = len(pq.items) - 1
+	pq.siftUp(len(pq.items) - 1)
+}
+
+// Peek возвращает верхний элемент, не удаляя его (Public)
+func (pq *PriorityQueue) Peek() (PQItem, error) {
+	if len(pq.items) == 0 {
+		return PQItem{}, fmt.Errorf("очередь с приоритетом пуста")
+	}
+	return pq.items[0], nil
+}
+
+// Pop удаляет и возвращает верхний элемент: с наименьшим приоритетом
+// для MinHeap или с наибольшим для MaxHeap (Public)
+func (pq *PriorityQueue) Pop() (PQItem, error) {
+	if len(pq.items) == 0 {
+		return PQItem{}, fmt.Errorf("очередь с приоритетом пуста")
+	}
+	top := pq.items[0]
+	last := len(pq.items) - 1
+	pq.swap(0, last)
+	pq.items = pq.items[:last]
+	delete(pq.positions, top.Value)
+	if last > 0 {
+		pq.siftDown(0)
+	}
+	return top, nil
+}
+
+// DecreaseKey продвигает элемент к вершине кучи, задавая ему новый приоритет (Public).
+// Для MinHeap приоритет не может увеличиться, для MaxHeap - уменьшиться.
+func (pq *PriorityQueue) DecreaseKey(value string, priority int) error {
+	i, ok := pq.positions[value]
+	if !ok {
+		return fmt.Errorf("значение %s не найдено в очереди", value)
+	}
+	if pq.less(pq.items[i].Priority, priority) {
+		return fmt.Errorf("новый приоритет %d хуже текущего %d", priority, pq.items[i].Priority)
+	}
+	pq.items[i].Priority = priority
+	pq.siftUp(i)
+	return nil
+}
+
+// Priority возвращает приоритет значения (Public)
+func (pq *PriorityQueue) Priority(value string) (int, bool) {
+	i, ok := pq.positions[value]
+	if !ok {
+		return 0, false
+	}
+	return pq.items[i].Priority, true
+}
+
+// Items возвращает копию элементов в порядке хранения в куче (Public)
+func (pq *PriorityQueue) Items() []PQItem {
+	return append([]PQItem(nil), pq.items...)
+}
+
+// Print выводит элементы в порядке хранения в куче (Public)
+func (pq *PriorityQueue) Print() {
+	for _, item := range pq.items {
+		fmt.Printf("%s(%d) ", item.Value, item.Priority)
+	}
+	fmt.Println()
+}
+
+// HeapSort сортирует элементы по возрастанию приоритета на месте за O(n log n)
+func HeapSort(items []PQItem) {
+	siftDown := func(i, n int) {
+		for {
+			largest := i
+			left, right := 2*i+1, 2*i+2
+			if left < n && items[left].Priority > items[largest].Priority {
+				largest = left
+			}
+			if right < n && items[right].Priority > items[largest].Priority {
+				largest = right
+			}
+			if largest == i {
+				return
+			}
+			items[i], items[largest] = items[largest], items[i]
+			i = largest
+		}
+	}
+	for i := len(items)/2 - 1; i >= 0; i-- {
+		siftDown(i, len(items))
+	}
+	for end := len(items) - 1; end > 0; end-- {
+		items[0], items[end] = items[end], items[0]
+		siftDown(0, end)
+	}
+}
+
+// less проверяет, должен ли приоритет a находиться выше приоритета b (Private)
+func (pq *PriorityQueue) less(a, b int) bool {
+	if pq.Kind == MaxHeap {
+		return a > b
+	}
+	return a < b
+}
+
+// swap меняет местами два элемента кучи и обновляет их позиции (Private)
+func (pq *PriorityQueue) swap(i, j int) {
+	pq.items[i], pq.items[j] = pq.items[j], pq.items[i]
+	pq.positions[pq.items[i].Value] = i
+	pq.positions[pq.items[j].Value] = j
+}
+
+// siftUp поднимает элемент, пока он выше родителя по приоритету (Private)
+func (pq *PriorityQueue) siftUp(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !pq.less(pq.items[i].Priority, pq.items[parent].Priority) {
+			return
+		}
+		pq.swap(i, parent)
+		i = parent
+	}
+}
+
+// siftDown опускает элемент, пока один из потомков выше него по приоритету (Private)
+func (pq *PriorityQueue) siftDown(i int) {
+	n := len(pq.items)
+	for {
+		top := i
+		left, right := 2*i+1, 2*i+2
+		if left < n && pq.less(pq.items[left].Priority, pq.items[top].Priority) {
+			top = left
+		}
+		if right < n && pq.less(pq.items[right].Priority, pq.items[top].Priority) {
+			top = right
+		}
+		if top == i {
+			return
+		}
+		pq.swap(i, top)
+		i = top
+	}
+}
+
+// fix восстанавливает свойство кучи после изменения приоритета элемента i (Private)
+func (pq *PriorityQueue) fix(i int) {
+	value := pq.items[i].Value
+	pq.siftUp(i)
+	pq.siftDown(pq.positions[value])
+}
+
+// heapify восстанавливает свойство кучи для всего массива за O(n) (Private)
+func (pq *PriorityQueue) heapify() {
+	for i := len(pq.items)/2 - 1; i >= 0; i-- {
+		pq.siftDown(i)
+	}
+}
+
+// SaveToFile сохраняет очередь в файл (Public).
+// Первая строка - заголовок "heap вид", далее строки "значение приоритет" в порядке кучи.
+func (pq *PriorityQueue) SaveToFile(filename string) error {
+	file, err := createSnapshotFile(filename)
+	if err != nil {
+		return fmt.Errorf("не удалось создать файл: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(fmt.Sprintf("heap %s\n", pq.Kind)); err != nil {
+		return fmt.Errorf("ошибка записи в файл: %v", err)
+	}
+	for _, item := range pq.items {
+		_, err := file.WriteString(fmt.Sprintf("%s %d\n", item.Value, item.Priority))
+		if err != nil {
+			return fmt.Errorf("ошибка записи в файл: %v", err)
+		}
+	}
+	return file.Commit()
+}
+
+// LoadFromFile загружает очередь из файла (Public).
+// Строки, не соответствующие формату, пропускаются.
+func (pq *PriorityQueue) LoadFromFile(filename string) error {
+	file, err := openSnapshotFile(filename)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть файл: %v", err)
+	}
+	defer file.Close()
+
+	var items []PQItem
+	kind := pq.Kind
+	scanner := bufio.NewScanner(file)
+	first := true
+	for scanner.Scan() {
+		parts := strings.Split(scanner.Text(), " ")
+		if first {
+			first = false
+			if len(parts) == 2 && parts[0] == "heap" {
+				kind, err = ParseHeapKind(parts[1])
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if len(parts) != 2 {
+			continue
+		}
+		priority, err := strconv.Atoi(parts[1])
+		if err != nil {
+			continue
+		}
+		items = append(items, PQItem{Value: parts[0], Priority: priority})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения файла: %v", err)
+	}
+	*pq = *NewPriorityQueueFromSlice(items, kind)
+	return nil
+}
+
+// EncodeTo записывает очередь в w в бинарном формате списочных структур (Public):
+// первая строка - вид кучи, далее пары строк "значение", "приоритет" в порядке кучи
+func (pq *PriorityQueue) EncodeTo(w io.Writer) error {
+	return encodeStrings(w, func(yield func(string) bool) {
+		if !yield(pq.Kind.String()) {
+			return
+		}
+		for _, item := range pq.items {
+			if !yield(item.Value) || !yield(strconv.Itoa(item.Priority)) {
+				return
+			}
+		}
+	})
+}
+
+// DecodeFrom читает очередь из r в бинарном формате по одному элементу (Public).
+// При ошибке очередь не меняется.
+func (pq *PriorityQueue) DecodeFrom(r io.Reader) error {
+	var items []PQItem
+	kind := pq.Kind
+	first := true
+	var value *string
+	var parseErr error
+	err := decodeStrings(r, func(field string) bool {
+		switch {
+		case first:
+			first = false
+			kind, parseErr = ParseHeapKind(field)
+		case value == nil:
+			value = &field
+		default:
+			priority, err := strconv.Atoi(field)
+			if err != nil {
+				parseErr = fmt.Errorf("недопустимый приоритет %q", field)
+				return false
+			}
+			items = append(items, PQItem{Value: *value, Priority: priority})
+			value = nil
+		}
+		return parseErr == nil
+	})
+	if err != nil {
+		return err
+	}
+	if parseErr != nil {
+		return parseErr
+	}
+	if value != nil {
+		return fmt.Errorf("недостаточно данных: значение %q без приоритета", *value)
+	}
+	*pq = *NewPriorityQueueFromSlice(items, kind)
+	return nil
+}
+
+// SerializeBinary сериализует очередь в бинарный формат (см. EncodeTo) (Public)
+func (pq *PriorityQueue) SerializeBinary() ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := pq.EncodeTo(&buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// DeserializeBinary десериализует очередь из бинарного формата (Public)
+func (pq *PriorityQueue) DeserializeBinary(data []byte) error {
+	return pq.DecodeFrom(bytes.NewReader(data))
+}
+
+// priorityQueueJSON - текстовое представление очереди: вид кучи и элементы в порядке кучи
+type priorityQueueJSON struct {
+	Kind  string
+	Items []PQItem
+}
+
+// SerializeText сериализует очередь в текстовый формат (JSON) (Public)
+func (pq *PriorityQueue) SerializeText() (string, error) {
+	items := pq.items
+	if items == nil {
+		items = []PQItem{}
+	}
+	data, err := json.Marshal(priorityQueueJSON{Kind: pq.Kind.String(), Items: items})
+	if err != nil {
+		return "", fmt.Errorf("ошибка сериализации в текстовый формат: %v", err)
+	}
+	return string(data), nil
+}
+
+// DeserializeText десериализует очередь из текстового формата (JSON) (Public).
+// При ошибке очередь не меняется.
+func (pq *PriorityQueue) DeserializeText(data string) error {
+	var temp priorityQueueJSON
+	if err := json.Unmarshal([]byte(data), &temp); err != nil {
+		return fmt.Errorf("ошибка десериализации из текстового формата: %v", err)
+	}
+	kind, err := ParseHeapKind(temp.Kind)
+	if err != nil {
+		return err
+	}
+	*pq = *NewPriorityQueueFromSlice(temp.Items, kind)
+	return nil
+}
+
+// MarshalBinary реализует encoding.BinaryMarshaler (Public)
+func (pq *PriorityQueue) MarshalBinary() ([]byte, error) {
+	return pq.SerializeBinary()
+}
+
+// UnmarshalBinary реализует encoding.BinaryUnmarshaler (Public)
+func (pq *PriorityQueue) UnmarshalBinary(data []byte) error {
+	return pq.DeserializeBinary(data)
+}
+
+// MarshalText реализует encoding.TextMarshaler; текстовый формат - JSON (Public)
+func (pq *PriorityQueue) MarshalText() ([]byte, error) {
+	text, err := pq.SerializeText()
+	return []byte(text), err
+}
+
+// UnmarshalText реализует encoding.TextUnmarshaler (Public)
+func (pq *PriorityQueue) UnmarshalText(data []byte) error {
+	return pq.DeserializeText(string(data))
+}
+
+// MarshalJSON реализует json.Marshaler (Public)
+func (pq *PriorityQueue) MarshalJSON() ([]byte, error) {
+	return pq.MarshalText()
+}
+
+// UnmarshalJSON реализует json.Unmarshaler (Public)
+func (pq *PriorityQueue) UnmarshalJSON(data []byte) error {
+	return pq.UnmarshalText(data)
+}
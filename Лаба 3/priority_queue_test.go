@@ -0,0 +1,205 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// checkHeap проверяет свойство кучи и согласованность позиций
+func checkHeap(t *testing.T, pq *PriorityQueue) {
+	t.Helper()
+	for i, item := range pq.items {
+		if pq.positions[item.Value] != i {
+			t.Fatalf("positions[%s] = %d; want %d", item.Value, pq.positions[item.Value], i)
+		}
+		if i > 0 && pq.less(item.Priority, pq.items[(i-1)/2].Priority) {
+			t.Fatalf("heap property violated at index %d", i)
+		}
+	}
+	if len(pq.positions) != len(pq.items) {
+		t.Fatalf("len(positions) = %d; want %d", len(pq.positions), len(pq.items))
+	}
+}
+
+func TestPriorityQueuePushPop(t *testing.T) {
+	for _, kind := range []HeapKind{MinHeap, MaxHeap} {
+		pq := NewPriorityQueue(kind)
+		if _, err := pq.Pop(); err == nil {
+			t.Errorf("%s: Pop() on empty queue error = nil", kind)
+		}
+		if _, err := pq.Peek(); err == nil {
+			t.Errorf("%s: Peek() on empty queue error = nil", kind)
+		}
+
+		rng := rand.New(rand.NewSource(7))
+		var priorities []int
+		for i := 0; i < 200; i++ {
+			p := rng.Intn(1000)
+			priorities = append(priorities, p)
+			pq.Push("v"+strconv.Itoa(i), p)
+			checkHeap(t, pq)
+		}
+		sort.Ints(priorities)
+		if kind == MaxHeap {
+			sort.Sort(sort.Reverse(sort.IntSlice(priorities)))
+		}
+		for _, expected := range priorities {
+			top, _ := pq.Peek()
+			item, err := pq.Pop()
+			if err != nil || item.Priority != expected || item != top {
+				t.Fatalf("%s: Pop() = %v, %v; want priority %d", kind, item, err, expected)
+			}
+			checkHeap(t, pq)
+		}
+		if pq.Len() != 0 {
+			t.Errorf("%s: Len() = %d; want 0", kind, pq.Len())
+		}
+	}
+}
+
+func TestPriorityQueuePushExistingValue(t *testing.T) {
+	pq := NewPriorityQueue(MinHeap)
+	pq.Push("a", 5)
+	pq.Push("b", 3)
+	pq.Push("a", 1)
+	if pq.Len() != 2 {
+		t.Errorf("Len() = %d; want 2", pq.Len())
+	}
+	if item, _ := pq.Peek(); item.Value != "a" || item.Priority != 1 {
+		t.Errorf("Peek() = %v; want a(1)", item)
+	}
+	pq.Push("a", 10)
+	checkHeap(t, pq)
+	if item, _ := pq.Peek(); item.Value != "b" {
+		t.Errorf("Peek() = %v; want b after raising a's priority", item)
+	}
+}
+
+func TestPriorityQueueDecreaseKey(t *testing.T) {
+	pq := NewPriorityQueue(MinHeap)
+	for i, p := range []int{10, 20, 30, 40, 50} {
+		pq.Push("v"+strconv.Itoa(i), p)
+	}
+	if err := pq.DecreaseKey("v4", 5); err != nil {
+		t.Fatalf("DecreaseKey() error = %v", err)
+	}
+	checkHeap(t, pq)
+	if item, _ := pq.Peek(); item.Value != "v4" {
+		t.Errorf("Peek() = %v; want v4", item)
+	}
+	if err := pq.DecreaseKey("v0", 100); err == nil {
+		t.Errorf("DecreaseKey() with larger priority error = nil; want error")
+	}
+	if err := pq.DecreaseKey("missing", 1); err == nil {
+		t.Errorf("DecreaseKey() for missing value error = nil; want error")
+	}
+	if p, ok := pq.Priority("v4"); !ok || p != 5 {
+		t.Errorf("Priority(v4) = %d, %v; want 5", p, ok)
+	}
+	if _, ok := pq.Priority("missing"); ok {
+		t.Errorf("Priority(missing) ok = true; want false")
+	}
+
+	maxPQ := NewPriorityQueue(MaxHeap)
+	maxPQ.Push("a", 1)
+	maxPQ.Push("b", 2)
+	if err := maxPQ.DecreaseKey("a", 3); err != nil {
+		t.Errorf("MaxHeap DecreaseKey() error = %v", err)
+	}
+	if item, _ := maxPQ.Peek(); item.Value != "a" {
+		t.Errorf("MaxHeap Peek() = %v; want a", item)
+	}
+	if err := maxPQ.DecreaseKey("b", 0); err == nil {
+		t.Errorf("MaxHeap DecreaseKey() with smaller priority error = nil; want error")
+	}
+}
+
+func TestPriorityQueueFromSlice(t *testing.T) {
+	items := []PQItem{{"a", 9}, {"b", 4}, {"c", 7}, {"d", 1}, {"a", 3}}
+	pq := NewPriorityQueueFromSlice(items, MinHeap)
+	checkHeap(t, pq)
+	if pq.Len() != 4 {
+		t.Errorf("Len() = %d; want 4 after merging duplicates", pq.Len())
+	}
+	var order []string
+	for pq.Len() > 0 {
+		item, _ := pq.Pop()
+		order = append(order, item.Value)
+	}
+	if len(order) != 4 || order[0] != "d" || order[1] != "a" || order[2] != "b" || order[3] != "c" {
+		t.Errorf("pop order = %v; want [d a b c]", order)
+	}
+}
+
+func TestHeapSort(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	items := make([]PQItem, 100)
+	for i := range items {
+		items[i] = PQItem{Value: strconv.Itoa(i), Priority: rng.Intn(50) - 25}
+	}
+	HeapSort(items)
+	for i := 1; i < len(items); i++ {
+		if items[i-1].Priority > items[i].Priority {
+			t.Fatalf("HeapSort() result is not sorted at index %d", i)
+		}
+	}
+	HeapSort(nil)
+}
+
+func TestPriorityQueueSaveLoad(t *testing.T) {
+	filename := "test_pq.txt"
+	defer os.Remove(filename)
+
+	pq := NewPriorityQueue(MaxHeap)
+	pq.Push("low", 1)
+	pq.Push("high", 9)
+	pq.Push("mid", 5)
+	if err := pq.SaveToFile(filename); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+
+	loaded := NewPriorityQueue(MinHeap)
+	if err := loaded.LoadFromFile(filename); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if loaded.Kind != MaxHeap || loaded.Len() != 3 {
+		t.Errorf("LoadFromFile() kind = %s, len = %d; want max, 3", loaded.Kind, loaded.Len())
+	}
+	checkHeap(t, loaded)
+	if item, _ := loaded.Pop(); item.Value != "high" {
+		t.Errorf("Pop() = %v; want high", item)
+	}
+
+	// Файл без заголовка и с посторонними строками
+	os.WriteFile(filename, []byte("HELLO\nx 3\ny notanumber\nz 1\n"), 0644)
+	loaded = NewPriorityQueue(MinHeap)
+	if err := loaded.LoadFromFile(filename); err != nil {
+		t.Errorf("LoadFromFile() error = %v", err)
+	}
+	if item, _ := loaded.Peek(); loaded.Len() != 2 || item.Value != "z" {
+		t.Errorf("LoadFromFile() = %v; want [z x]", loaded.Items())
+	}
+
+	os.WriteFile(filename, []byte("heap median\n"), 0644)
+	if err := loaded.LoadFromFile(filename); err == nil {
+		t.Errorf("LoadFromFile() error = nil; want invalid heap kind error")
+	}
+	if err := loaded.LoadFromFile("nonexistent_pq.txt"); err == nil {
+		t.Errorf("LoadFromFile() error = nil; want error for missing file")
+	}
+}
+
+func TestPriorityQueuePrint(t *testing.T) {
+	pq := NewPriorityQueue(MinHeap)
+	pq.Push("a", 2)
+	pq.Push("b", 1)
+	if output := captureStdout(pq.Print); output != "b(1) a(2) \n" {
+		t.Errorf("Print() = %q", output)
+	}
+	if _, err := ParseHeapKind("MAX"); err != nil {
+		t.Errorf("ParseHeapKind(MAX) error = %v", err)
+	}
+}
@@ -2,10 +2,10 @@ package main
 
 import (
 	"bufio"
-	"encoding/binary"
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 )
 
 // Queue представляет структуру очереди
@@ -60,7 +60,7 @@ func (q *Queue) Print() {
 
 // SaveToFile сохраняет очередь в файл (Public)
 func (q *Queue) SaveToFile(filename string) error {
-	file, err := os.Create(filename)
+	file, err := createSnapshotFile(filename)
 	if err != nil {
 		return fmt.Errorf("не удалось создать файл: %v", err)
 	}
@@ -74,12 +74,12 @@ func (q *Queue) SaveToFile(filename string) error {
 		}
 		temp = temp.Next
 	}
-	return nil
+	return file.Commit()
 }
 
 // LoadFromFile загружает очередь из файла (Public)
 func (q *Queue) LoadFromFile(filename string) error {
-	file, err := os.Open(filename)
+	file, err := openSnapshotFile(filename)
 	if err != nil {
 		return fmt.Errorf("не удалось открыть файл: %v", err)
 	}
@@ -134,49 +134,131 @@ func (q *Queue) DeserializeText(data string) error {
 
 // SerializeBinary сериализует очередь в бинарный формат
 func (q *Queue) SerializeBinary() ([]byte, error) {
-	var result []byte
-	current := q.Front
-	for current != nil {
-		// Записываем длину строки
-		length := uint32(len(current.Data))
-		lengthBytes := make([]byte, 4)
-		binary.LittleEndian.PutUint32(lengthBytes, length)
-		result = append(result, lengthBytes...)
-
-		// Записываем строку
-		result = append(result, []byte(current.Data)...)
-		current = current.Next
+	var buffer bytes.Buffer
+	if err := q.EncodeTo(&buffer); err != nil {
+		return nil, err
 	}
-	return result, nil
+	return buffer.Bytes(), nil
 }
 
-// DeserializeBinary десериализует очередь из бинарного формата
+// DeserializeBinary десериализует очередь из бинарного формата.
+// Данные без заголовка, их тип не проверяется; снимок с проверкой типа читает DeserializeSnapshot.
 func (q *Queue) DeserializeBinary(data []byte) error {
-	q.Front = nil
-	q.End = nil
-	q.Size = 0
-	offset := 0
+	return q.DecodeFrom(bytes.NewReader(data))
+}
 
-	for offset < len(data) {
-		// Проверяем, достаточно ли байт для чтения длины строки
-		if offset+4 > len(data) {
-			return fmt.Errorf("недостаточно данных для чтения длины строки")
-		}
+// EncodeTo записывает очередь в w в бинарном формате поэлементно (Public)
+func (q *Queue) EncodeTo(w io.Writer) error {
+	return encodeStrings(w, nodeValues(q.Front))
+}
+
+// DecodeFrom читает очередь из r в бинарном формате поэлементно.
+// При ошибке очередь не меняется (Public)
+func (q *Queue) DecodeFrom(r io.Reader) error {
+	restored := NewQueue()
+	if err := decodeStrings(r, func(value string) bool {
+		restored.Push(value)
+		return true
+	}); err != nil {
+		return err
+	}
+	*q = *restored
+	return nil
+}
+
+// MarshalBinary реализует encoding.BinaryMarshaler (Public)
+func (q *Queue) MarshalBinary() ([]byte, error) {
+	return q.SerializeBinary()
+}
 
-		// Читаем длину строки
-		length := binary.LittleEndian.Uint32(data[offset : offset+4])
-		offset += 4
+// UnmarshalBinary реализует encoding.BinaryUnmarshaler (Public)
+func (q *Queue) UnmarshalBinary(data []byte) error {
+	return q.DeserializeBinary(data)
+}
 
-		// Проверяем, достаточно ли байт для чтения строки
-		if offset+int(length) > len(data) {
-			return fmt.Errorf("недостаточно данных для чтения строки")
-		}
+// MarshalText реализует encoding.TextMarshaler; текстовый формат - JSON (Public)
+func (q *Queue) MarshalText() ([]byte, error) {
+	text, err := q.SerializeText()
+	return []byte(text), err
+}
+
+// UnmarshalText реализует encoding.TextUnmarshaler (Public)
+func (q *Queue) UnmarshalText(data []byte) error {
+	return q.DeserializeText(string(data))
+}
 
-		// Читаем строку
-		strData := string(data[offset : offset+int(length)])
-		offset += int(length)
-		q.Push(strData)
+// MarshalJSON реализует json.Marshaler (Public)
+func (q *Queue) MarshalJSON() ([]byte, error) {
+	return q.MarshalText()
+}
+
+// UnmarshalJSON реализует json.Unmarshaler (Public)
+func (q *Queue) UnmarshalJSON(data []byte) error {
+	return q.UnmarshalText(data)
+}
+
+// portable возвращает переносимое представление очереди: массив строк, как в SerializeText (Private)
+func (q *Queue) portable() any {
+	return portableStrings(nodeValues(q.Front))
+}
+
+// setPortable восстанавливает очередь из переносимого представления; при ошибке очередь не меняется (Private)
+func (q *Queue) setPortable(value any) error {
+	values, err := stringsFromPortable(value)
+	if err != nil {
+		return err
+	}
+	restored := NewQueue()
+	for _, value := range values {
+		restored.Push(value)
 	}
+	*q = *restored
+	return nil
+}
+
+// SerializeMsgPack сериализует очередь в MessagePack (Public)
+func (q *Queue) SerializeMsgPack() ([]byte, error) {
+	return serializeMsgPack(q)
+}
 
+// DeserializeMsgPack десериализует очередь из MessagePack (Public)
+func (q *Queue) DeserializeMsgPack(data []byte) error {
+	return deserializeMsgPack(q, data)
+}
+
+// SerializeCBOR сериализует очередь в CBOR (Public)
+func (q *Queue) SerializeCBOR() ([]byte, error) {
+	return serializeCBOR(q)
+}
+
+// DeserializeCBOR десериализует очередь из CBOR (Public)
+func (q *Queue) DeserializeCBOR(data []byte) error {
+	return deserializeCBOR(q, data)
+}
+
+// SerializeSnapshot сериализует очередь в снимок с заголовком, типом и контрольной суммой
+func (q *Queue) SerializeSnapshot() ([]byte, error) {
+	payload, err := q.SerializeBinary()
+	if err != nil {
+		return nil, err
+	}
+	return encodeSnapshot(SnapshotQueue, q.Size, payload), nil
+}
+
+// DeserializeSnapshot восстанавливает очередь из снимка. Снимок другой структуры,
+// другой версии или с неверной контрольной суммой отклоняется без изменения данных.
+func (q *Queue) DeserializeSnapshot(data []byte) error {
+	count, payload, err := decodeSnapshot(data, SnapshotQueue)
+	if err != nil {
+		return err
+	}
+	temp := NewQueue()
+	if err := temp.DeserializeBinary(payload); err != nil {
+		return err
+	}
+	if err := checkSnapshotCount(SnapshotQueue, temp.Size, count); err != nil {
+		return err
+	}
+	*q = *temp
 	return nil
 }
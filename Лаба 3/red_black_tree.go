@@ -0,0 +1,511 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strconv"
+)
+
+// RBNode представляет узел красно-черного дерева
+type RBNode struct {
+	Digit int
+	Red   bool
+	Size  int `json:"-"` // Размер поддерева для порядковых статистик
+	Left  *RBNode
+	Right *RBNode
+}
+
+// RedBlackTree представляет левостороннее красно-черное дерево (LLRB).
+// Красные связи всегда направлены влево, что упрощает вставку и удаление.
+type RedBlackTree struct {
+	Root           *RBNode
+	LeftRotations  int `json:"-"` // Количество левых поворотов, для тестов
+	RightRotations int `json:"-"` // Количество правых поворотов, для тестов
+}
+
+// NewRedBlackTree создает новое красно-черное дерево
+func NewRedBlackTree() *RedBlackTree {
+	return &RedBlackTree{}
+}
+
+// isRed проверяет, является ли узел красным (nil считается черным)
+func isRed(node *RBNode) bool {
+	return node != nil && node.Red
+}
+
+// rotateLeft выполняет левый поворот вокруг узла
+func (t *RedBlackTree) rotateLeft(node *RBNode) *RBNode {
+	t.LeftRotations++
+	pivot := node.Right
+	node.Right = pivot.Left
+	pivot.Left = node
+	pivot.Red = node.Red
+	node.Red = true
+	pivot.Size = node.Size
+	node.Size = node.Left.size() + node.Right.size() + 1
+	return pivot
+}
+
+// rotateRight выполняет правый поворот вокруг узла
+func (t *RedBlackTree) rotateRight(node *RBNode) *RBNode {
+	t.RightRotations++
+	pivot := node.Left
+	node.Left = pivot.Right
+	pivot.Right = node
+	pivot.Red = node.Red
+	node.Red = true
+	pivot.Size = node.Size
+	node.Size = node.Left.size() + node.Right.size() + 1
+	return pivot
+}
+
+// flipColors меняет цвета узла и его потомков на противоположные
+func (t *RedBlackTree) flipColors(node *RBNode) {
+	node.Red = !node.Red
+	node.Left.Red = !node.Left.Red
+	node.Right.Red = !node.Right.Red
+}
+
+// balance восстанавливает инварианты LLRB на пути вверх
+func (t *RedBlackTree) balance(node *RBNode) *RBNode {
+	node.Size = node.Left.size() + node.Right.size() + 1
+	if isRed(node.Right) && !isRed(node.Left) {
+		node = t.rotateLeft(node)
+	}
+	if isRed(node.Left) && isRed(node.Left.Left) {
+		node = t.rotateRight(node)
+	}
+	if isRed(node.Left) && isRed(node.Right) {
+		t.flipColors(node)
+	}
+	return node
+}
+
+// Insert добавляет значение в дерево. Повторяющиеся значения не добавляются.
+func (t *RedBlackTree) Insert(digit int) {
+	t.Root = t.insert(t.Root, digit)
+	t.Root.Red = false
+}
+
+// insert вспомогательная функция для рекурсивной вставки
+func (t *RedBlackTree) insert(node *RBNode, digit int) *RBNode {
+	if node == nil {
+		return &RBNode{Digit: digit, Red: true, Size: 1}
+	}
+	switch {
+	case digit < node.Digit:
+		node.Left = t.insert(node.Left, digit)
+	case digit > node.Digit:
+		node.Right = t.insert(node.Right, digit)
+	}
+	return t.balance(node)
+}
+
+// moveRedLeft делает левого потомка или его левого потомка красным перед спуском влево
+func (t *RedBlackTree) moveRedLeft(node *RBNode) *RBNode {
+	t.flipColors(node)
+	if isRed(node.Right.Left) {
+		node.Right = t.rotateRight(node.Right)
+		node = t.rotateLeft(node)
+		t.flipColors(node)
+	}
+	return node
+}
+
+// moveRedRight делает правого потомка или его левого потомка красным перед спуском вправо
+func (t *RedBlackTree) moveRedRight(node *RBNode) *RBNode {
+	t.flipColors(node)
+	if isRed(node.Left.Left) {
+		node = t.rotateRight(node)
+		t.flipColors(node)
+	}
+	return node
+}
+
+// deleteMin удаляет наименьший узел поддерева
+func (t *RedBlackTree) deleteMin(node *RBNode) *RBNode {
+	if node.Left == nil {
+		return nil
+	}
+	if !isRed(node.Left) && !isRed(node.Left.Left) {
+		node = t.moveRedLeft(node)
+	}
+	node.Left = t.deleteMin(node.Left)
+	return t.balance(node)
+}
+
+// Delete удаляет значение из дерева и сообщает, было ли оно найдено
+func (t *RedBlackTree) Delete(digit int) bool {
+	if !t.FindValue(digit) {
+		return false
+	}
+	if !isRed(t.Root.Left) && !isRed(t.Root.Right) {
+		t.Root.Red = true
+	}
+	t.Root = t.delete(t.Root, digit)
+	if t.Root != nil {
+		t.Root.Red = false
+	}
+	return true
+}
+
+// delete вспомогательная функция для рекурсивного удаления существующего значения
+func (t *RedBlackTree) delete(node *RBNode, digit int) *RBNode {
+	if digit < node.Digit {
+		if !isRed(node.Left) && !isRed(node.Left.Left) {
+			node = t.moveRedLeft(node)
+		}
+		node.Left = t.delete(node.Left, digit)
+	} else {
+		if isRed(node.Left) {
+			node = t.rotateRight(node)
+		}
+		if digit == node.Digit && node.Right == nil {
+			return nil
+		}
+		if !isRed(node.Right) && !isRed(node.Right.Left) {
+			node = t.moveRedRight(node)
+		}
+		if digit == node.Digit {
+			successor := node.Right
+			for successor.Left != nil {
+				successor = successor.Left
+			}
+			node.Digit = successor.Digit
+			node.Right = t.deleteMin(node.Right)
+		} else {
+			node.Right = t.delete(node.Right, digit)
+		}
+	}
+	return t.balance(node)
+}
+
+// FindValue ищет значение в дереве за O(log n)
+func (t *RedBlackTree) FindValue(value int) bool {
+	current := t.Root
+	for current != nil {
+		switch {
+		case value < current.Digit:
+			current = current.Left
+		case value > current.Digit:
+			current = current.Right
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// Height возвращает высоту дерева
+func (t *RedBlackTree) Height() int {
+	return rbHeight(t.Root)
+}
+
+// rbHeight вспомогательная функция для вычисления высоты поддерева
+func rbHeight(node *RBNode) int {
+	if node == nil {
+		return 0
+	}
+	left, right := rbHeight(node.Left), rbHeight(node.Right)
+	if left > right {
+		return left + 1
+	}
+	return right + 1
+}
+
+// CheckInvariants проверяет порядок ключей и свойства красно-черного дерева:
+// корень черный, у красного узла нет красных потомков, красные связи левые,
+// на всех путях от корня до листьев одинаковое число черных узлов.
+func (t *RedBlackTree) CheckInvariants() error {
+	if isRed(t.Root) {
+		return fmt.Errorf("корень дерева красный")
+	}
+	_, err := t.checkNode(t.Root, nil, nil)
+	return err
+}
+
+// checkNode рекурсивно проверяет инварианты поддерева и возвращает его черную высоту
+func (t *RedBlackTree) checkNode(node *RBNode, low, high *int) (int, error) {
+	if node == nil {
+		return 1, nil
+	}
+	if (low != nil && node.Digit <= *low) || (high != nil && node.Digit >= *high) {
+		return 0, fmt.Errorf("нарушен порядок ключей в узле %d", node.Digit)
+	}
+	if node.Red && (isRed(node.Left) || isRed(node.Right)) {
+		return 0, fmt.Errorf("у красного узла %d есть красный потомок", node.Digit)
+	}
+	if isRed(node.Right) {
+		return 0, fmt.Errorf("правая красная связь у узла %d", node.Digit)
+	}
+	left, err := t.checkNode(node.Left, low, &node.Digit)
+	if err != nil {
+		return 0, err
+	}
+	right, err := t.checkNode(node.Right, &node.Digit, high)
+	if err != nil {
+		return 0, err
+	}
+	if left != right {
+		return 0, fmt.Errorf("разная черная высота поддеревьев узла %d", node.Digit)
+	}
+	if node.Size != node.Left.size()+node.Right.size()+1 {
+		return 0, fmt.Errorf("неверный размер поддерева узла %d", node.Digit)
+	}
+	if !node.Red {
+		left++
+	}
+	return left, nil
+}
+
+// updateRBSizes пересчитывает размеры поддеревьев, не сохраняемые в JSON
+func updateRBSizes(node *RBNode) int {
+	if node == nil {
+		return 0
+	}
+	node.Size = updateRBSizes(node.Left) + updateRBSizes(node.Right) + 1
+	return node.Size
+}
+
+// Display печатает дерево, повернутое на 90 градусов; красные узлы помечены (R)
+func (t *RedBlackTree) Display() {
+	if t.Root == nil {
+		fmt.Println("Дерево пустое.")
+		return
+	}
+	t.printNode(t.Root, 0)
+}
+
+// printNode вспомогательная функция для печати дерева
+func (t *RedBlackTree) printNode(node *RBNode, level int) {
+	if node != nil {
+		t.printNode(node.Right, level+1)
+		for i := 0; i < level; i++ {
+			fmt.Print("   ")
+		}
+		if node.Red {
+			fmt.Printf("%d(R)\n", node.Digit)
+		} else {
+			fmt.Println(node.Digit)
+		}
+		t.printNode(node.Left, level+1)
+	}
+}
+
+// Clear удаляет все узлы из дерева
+func (t *RedBlackTree) Clear() {
+	t.Root = nil
+}
+
+// levelOrder возвращает итератор по значениям узлов в порядке обхода по уровням
+func (t *RedBlackTree) levelOrder() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		if t.Root == nil {
+			return
+		}
+		queue := []*RBNode{t.Root}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			if !yield(current.Digit) {
+				return
+			}
+			if current.Left != nil {
+				queue = append(queue, current.Left)
+			}
+			if current.Right != nil {
+				queue = append(queue, current.Right)
+			}
+		}
+	}
+}
+
+// levelOrderValues возвращает значения узлов в порядке обхода по уровням
+func (t *RedBlackTree) levelOrderValues() []int {
+	return slices.Collect(t.levelOrder())
+}
+
+// LoadFromFile загружает дерево из файла
+func (t *RedBlackTree) LoadFromFile(file string) error {
+	t.Clear()
+	f, err := openSnapshotFile(file)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть файл: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		value, err := strconv.Atoi(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("недопустимое значение в файле: %v", err)
+		}
+		t.Insert(value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения файла: %v", err)
+	}
+	return nil
+}
+
+// SaveToFile сохраняет дерево в файл по уровням
+func (t *RedBlackTree) SaveToFile(file string) error {
+	f, err := createSnapshotFile(file)
+	if err != nil {
+		return fmt.Errorf("не удалось создать файл: %v", err)
+	}
+	defer f.Close()
+
+	for value := range t.levelOrder() {
+		if _, err := f.WriteString(fmt.Sprintf("%d\n", value)); err != nil {
+			return fmt.Errorf("ошибка записи в файл: %v", err)
+		}
+	}
+	return f.Commit()
+}
+
+// redBlackTreeJSON - поля дерева без методов; через этот тип SerializeText и
+// DeserializeText обходят MarshalJSON и UnmarshalJSON самого дерева
+type redBlackTreeJSON RedBlackTree
+
+// SerializeText сериализует дерево в текстовый формат (JSON)
+func (t *RedBlackTree) SerializeText() (string, error) {
+	data, err := json.Marshal((*redBlackTreeJSON)(t))
+	if err != nil {
+		return "", fmt.Errorf("ошибка сериализации в текстовый формат: %v", err)
+	}
+	return string(data), nil
+}
+
+// DeserializeText десериализует дерево из текстового формата (JSON) и проверяет инварианты
+func (t *RedBlackTree) DeserializeText(data string) error {
+	var temp RedBlackTree
+	if err := json.Unmarshal([]byte(data), (*redBlackTreeJSON)(&temp)); err != nil {
+		return fmt.Errorf("ошибка десериализации из текстового формата: %v", err)
+	}
+	updateRBSizes(temp.Root)
+	if err := temp.CheckInvariants(); err != nil {
+		return fmt.Errorf("некорректное красно-черное дерево: %v", err)
+	}
+	t.Root = temp.Root
+	return nil
+}
+
+// SerializeBinary сериализует дерево в бинарный формат (значения по уровням, 4 байта со знаком).
+// Значение, не помещающееся в 4 байта, возвращает ошибку, а не записывается усеченным.
+func (t *RedBlackTree) SerializeBinary() ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := t.EncodeTo(&buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// DeserializeBinary десериализует дерево из бинарного формата.
+// Повторяющиеся значения отклоняются; при ошибке дерево не меняется.
+func (t *RedBlackTree) DeserializeBinary(data []byte) error {
+	return t.DecodeFrom(bytes.NewReader(data))
+}
+
+// EncodeTo записывает дерево в w в бинарном формате по одному узлу
+func (t *RedBlackTree) EncodeTo(w io.Writer) error {
+	return encodeInt32s(w, t.levelOrder())
+}
+
+// DecodeFrom читает дерево из r в бинарном формате, вставляя значения по мере чтения.
+// Повторяющиеся значения отклоняются; при ошибке дерево не меняется.
+func (t *RedBlackTree) DecodeFrom(r io.Reader) error {
+	temp := NewRedBlackTree()
+	err := decodeInt32s(r, func(value int) error {
+		if temp.FindValue(value) {
+			return fmt.Errorf("значение %d повторяется", value)
+		}
+		temp.Insert(value)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	t.Root = temp.Root
+	return nil
+}
+
+// MarshalBinary реализует encoding.BinaryMarshaler
+func (t *RedBlackTree) MarshalBinary() ([]byte, error) {
+	return t.SerializeBinary()
+}
+
+// UnmarshalBinary реализует encoding.BinaryUnmarshaler
+func (t *RedBlackTree) UnmarshalBinary(data []byte) error {
+	return t.DeserializeBinary(data)
+}
+
+// MarshalText реализует encoding.TextMarshaler; текстовый формат - JSON
+func (t *RedBlackTree) MarshalText() ([]byte, error) {
+	text, err := t.SerializeText()
+	return []byte(text), err
+}
+
+// UnmarshalText реализует encoding.TextUnmarshaler
+func (t *RedBlackTree) UnmarshalText(data []byte) error {
+	return t.DeserializeText(string(data))
+}
+
+// MarshalJSON реализует json.Marshaler
+func (t *RedBlackTree) MarshalJSON() ([]byte, error) {
+	return t.MarshalText()
+}
+
+// UnmarshalJSON реализует json.Unmarshaler
+func (t *RedBlackTree) UnmarshalJSON(data []byte) error {
+	return t.UnmarshalText(data)
+}
+
+// portable возвращает переносимое представление дерева: значения в порядке обхода по уровням
+func (t *RedBlackTree) portable() any {
+	return portableInts(t.levelOrderValues())
+}
+
+// setPortable восстанавливает дерево из переносимого представления.
+// Повторяющиеся значения отклоняются; при ошибке дерево не меняется.
+func (t *RedBlackTree) setPortable(value any) error {
+	values, err := intsFromPortable(value)
+	if err != nil {
+		return err
+	}
+	temp := NewRedBlackTree()
+	for _, value := range values {
+		if temp.FindValue(value) {
+			return fmt.Errorf("значение %d повторяется", value)
+		}
+		temp.Insert(value)
+	}
+	t.Root = temp.Root
+	return nil
+}
+
+// SerializeMsgPack сериализует дерево в MessagePack
+func (t *RedBlackTree) SerializeMsgPack() ([]byte, error) {
+	return serializeMsgPack(t)
+}
+
+// DeserializeMsgPack десериализует дерево из MessagePack
+func (t *RedBlackTree) DeserializeMsgPack(data []byte) error {
+	return deserializeMsgPack(t, data)
+}
+
+// SerializeCBOR сериализует дерево в CBOR
+func (t *RedBlackTree) SerializeCBOR() ([]byte, error) {
+	return serializeCBOR(t)
+}
+
+// DeserializeCBOR десериализует дерево из CBOR
+func (t *RedBlackTree) DeserializeCBOR(data []byte) error {
+	return deserializeCBOR(t, data)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// rbInOrder собирает значения красно-черного дерева в порядке возрастания
+func rbInOrder(node *RBNode, values []int) []int {
+	if node == nil {
+		return values
+	}
+	values = rbInOrder(node.Left, values)
+	values = append(values, node.Digit)
+	return rbInOrder(node.Right, values)
+}
+
+// TestRBRotations проверяет повороты при вставке
+func TestRBRotations(t *testing.T) {
+	tree := NewRedBlackTree()
+	tree.Insert(1)
+	tree.Insert(2) // правая красная связь -> левый поворот
+	if tree.LeftRotations != 1 || tree.RightRotations != 0 {
+		t.Errorf("rotations = %d/%d; want 1/0", tree.LeftRotations, tree.RightRotations)
+	}
+	tree.Insert(3) // разделение 4-узла без поворотов
+	if tree.LeftRotations != 1 || tree.RightRotations != 0 || tree.Root.Digit != 2 {
+		t.Errorf("rotations = %d/%d, root = %d; want 1/0, root 2", tree.LeftRotations, tree.RightRotations, tree.Root.Digit)
+	}
+
+	tree = NewRedBlackTree()
+	tree.Insert(3)
+	tree.Insert(2)
+	tree.Insert(1) // две левые красные связи подряд -> правый поворот
+	if tree.LeftRotations != 0 || tree.RightRotations != 1 || tree.Root.Digit != 2 {
+		t.Errorf("rotations = %d/%d, root = %d; want 0/1, root 2", tree.LeftRotations, tree.RightRotations, tree.Root.Digit)
+	}
+	if isRed(tree.Root) || isRed(tree.Root.Left) || isRed(tree.Root.Right) {
+		t.Errorf("после разделения все узлы должны быть черными")
+	}
+}
+
+// TestRBSortedInput проверяет, что отсортированный ввод не вырождает дерево
+func TestRBSortedInput(t *testing.T) {
+	tree := NewRedBlackTree()
+	for i := 1; i <= 1023; i++ {
+		tree.Insert(i)
+	}
+	if tree.Height() > 20 {
+		t.Errorf("Height() = %d; want at most 2*log2(n+1) = 20", tree.Height())
+	}
+	if err := tree.CheckInvariants(); err != nil {
+		t.Errorf("CheckInvariants() = %v", err)
+	}
+}
+
+// TestRBInsertDeleteRandom проверяет инварианты после случайных вставок и удалений
+func TestRBInsertDeleteRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tree := NewRedBlackTree()
+	present := map[int]bool{}
+	for i := 0; i < 2000; i++ {
+		v := rng.Intn(500)
+		if rng.Intn(3) == 0 {
+			if tree.Delete(v) != present[v] {
+				t.Fatalf("Delete(%d) disagrees with reference set", v)
+			}
+			delete(present, v)
+		} else {
+			tree.Insert(v)
+			present[v] = true
+		}
+		if err := tree.CheckInvariants(); err != nil {
+			t.Fatalf("step %d: CheckInvariants() = %v", i, err)
+		}
+	}
+
+	var expected []int
+	for v := range present {
+		expected = append(expected, v)
+	}
+	sort.Ints(expected)
+	if got := rbInOrder(tree.Root, nil); !reflect.DeepEqual(got, expected) {
+		t.Errorf("in-order values differ from reference set")
+	}
+
+	for _, v := range expected {
+		tree.Delete(v)
+		if err := tree.CheckInvariants(); err != nil {
+			t.Fatalf("Delete(%d): CheckInvariants() = %v", v, err)
+		}
+	}
+	if tree.Root != nil {
+		t.Errorf("Root = %v; want empty tree after deleting everything", tree.Root)
+	}
+}
+
+// TestRBCheckInvariantsDetectsErrors проверяет обнаружение нарушенных инвариантов
+func TestRBCheckInvariantsDetectsErrors(t *testing.T) {
+	cases := map[string]*RedBlackTree{
+		"red root":      {Root: &RBNode{Digit: 1, Red: true}},
+		"order":         {Root: &RBNode{Digit: 5, Left: &RBNode{Digit: 7, Red: true}}},
+		"red-red":       {Root: &RBNode{Digit: 5, Left: &RBNode{Digit: 3, Red: true, Left: &RBNode{Digit: 1, Red: true}}}},
+		"right red":     {Root: &RBNode{Digit: 5, Right: &RBNode{Digit: 7, Red: true}}},
+		"black heights": {Root: &RBNode{Digit: 5, Left: &RBNode{Digit: 3}}},
+	}
+	for name, tree := range cases {
+		if tree.CheckInvariants() == nil {
+			t.Errorf("%s: CheckInvariants() = nil; want error", name)
+		}
+	}
+}
+
+// TestRBSaveLoadSerialize проверяет сохранение, загрузку и сериализацию красно-черного дерева
+func TestRBSaveLoadSerialize(t *testing.T) {
+	tree := NewRedBlackTree()
+	for _, v := range []int{50, 20, 70, -10, 30, 60, 80, 25} {
+		tree.Insert(v)
+	}
+	expected := rbInOrder(tree.Root, nil)
+
+	filename := "test_rb.txt"
+	if err := tree.SaveToFile(filename); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	defer os.Remove(filename)
+	loaded := NewRedBlackTree()
+	if err := loaded.LoadFromFile(filename); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if got := rbInOrder(loaded.Root, nil); !reflect.DeepEqual(got, expected) {
+		t.Errorf("LoadFromFile() values = %v; want %v", got, expected)
+	}
+	if err := loaded.LoadFromFile("nonexistent_rb.txt"); err == nil {
+		t.Errorf("LoadFromFile() error = nil; want error for missing file")
+	}
+
+	text, err := tree.SerializeText()
+	if err != nil {
+		t.Fatalf("SerializeText() error = %v", err)
+	}
+	fromText := NewRedBlackTree()
+	if err := fromText.DeserializeText(text); err != nil {
+		t.Fatalf("DeserializeText() error = %v", err)
+	}
+	if got := rbInOrder(fromText.Root, nil); !reflect.DeepEqual(got, expected) {
+		t.Errorf("DeserializeText() values = %v; want %v", got, expected)
+	}
+	if err := fromText.DeserializeText(`{"Root":{"Digit":1,"Red":true}}`); err == nil {
+		t.Errorf("DeserializeText() error = nil; want invariant error")
+	}
+
+	data, err := tree.SerializeBinary()
+	if err != nil {
+		t.Fatalf("SerializeBinary() error = %v", err)
+	}
+	fromBinary := NewRedBlackTree()
+	if err := fromBinary.DeserializeBinary(data); err != nil {
+		t.Fatalf("DeserializeBinary() error = %v", err)
+	}
+	if got := rbInOrder(fromBinary.Root, nil); !reflect.DeepEqual(got, expected) {
+		t.Errorf("DeserializeBinary() values = %v; want %v", got, expected)
+	}
+	if err := fromBinary.DeserializeBinary([]byte{1}); err == nil {
+		t.Errorf("DeserializeBinary() error = nil; want error for truncated data")
+	}
+
+	large := NewRedBlackTree()
+	large.Insert(1)
+	large.Insert(1 << 40)
+	if _, err := large.SerializeBinary(); err == nil {
+		t.Errorf("SerializeBinary() error = nil; want error for value out of int32 range")
+	}
+}
+
+// TestRBDisplay проверяет печать красно-черного дерева
+func TestRBDisplay(t *testing.T) {
+	tree := NewRedBlackTree()
+	if output := captureStdout(tree.Display); output != "Дерево пустое.\n" {
+		t.Errorf("Display() = %q", output)
+	}
+	tree.Insert(2)
+	tree.Insert(1)
+	if output := captureStdout(tree.Display); output != "2\n   1(R)\n" {
+		t.Errorf("Display() = %q", output)
+	}
+}
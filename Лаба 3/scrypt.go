@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// Функция выработки ключа из пароля scrypt (RFC 7914). Пакет golang.org/x/crypto
+// недоступен, поэтому алгоритм реализован здесь по тексту RFC: PBKDF2-HMAC-SHA256,
+// перемешивание блоков функцией Salsa20/8 и ROMix с таблицей из N блоков.
+
+// scryptKey вырабатывает ключ длиной keyLen из пароля и соли. Стоимость задается
+// параметрами N = 2^logN (память и время), r (размер блока) и p (параллельность).
+func scryptKey(password, salt []byte, logN, r, p, keyLen int) ([]byte, error) {
+	if logN < 1 || logN > 30 || r < 1 || p < 1 || uint64(r)*uint64(p) >= 1<<30 {
+		return nil, fmt.Errorf("некорректные параметры scrypt: N=2^%d, r=%d, p=%d", logN, r, p)
+	}
+	n := 1 << logN
+	blockWords := 32 * r // Блок - 128*r байт, или 32*r слов по 4 байта
+	if uint64(n)*uint64(blockWords) > 1<<32 {
+		return nil, fmt.Errorf("параметры scrypt требуют слишком много памяти: N=2^%d, r=%d", logN, r)
+	}
+
+	blocks := pbkdf2SHA256(password, salt, 1, p*128*r)
+	table := make([]uint32, n*blockWords)
+	work := make([]uint32, 2*blockWords)
+	for i := 0; i < p; i++ {
+		scryptROMix(blocks[i*128*r:(i+1)*128*r], r, n, table, work)
+	}
+	return pbkdf2SHA256(password, blocks, 1, keyLen), nil
+}
+
+// scryptROMix перемешивает блок: заполняет таблицу из n последовательных состояний,
+// затем n раз смешивает состояние с элементом таблицы, выбранным по самому состоянию
+func scryptROMix(block []byte, r, n int, table, work []uint32) {
+	blockWords := 32 * r
+	x, y := work[:blockWords], work[blockWords:]
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(block[i*4:])
+	}
+	for i := 0; i < n; i++ {
+		copy(table[i*blockWords:], x)
+		scryptBlockMix(x, y, r)
+	}
+	for i := 0; i < n; i++ {
+		j := int(x[(2*r-1)*16] & uint32(n-1)) // Integerify: первое слово последнего подблока
+		for k, value := range table[j*blockWords : (j+1)*blockWords] {
+			x[k] ^= value
+		}
+		scryptBlockMix(x, y, r)
+	}
+	for i, value := range x {
+		binary.LittleEndian.PutUint32(block[i*4:], value)
+	}
+}
+
+// scryptBlockMix перемешивает 2*r подблоков по 16 слов функцией Salsa20/8.
+// Результат складывается сначала из четных, затем из нечетных подблоков; y - рабочая память.
+func scryptBlockMix(b, y []uint32, r int) {
+	var x [16]uint32
+	copy(x[:], b[(2*r-1)*16:])
+	for i := 0; i < 2*r; i++ {
+		for k := range x {
+			x[k] ^= b[i*16+k]
+		}
+		salsa208(&x)
+		copy(y[i*16:], x[:])
+	}
+	for i := 0; i < r; i++ {
+		copy(b[i*16:(i+1)*16], y[2*i*16:])
+		copy(b[(r+i)*16:(r+i+1)*16], y[(2*i+1)*16:])
+	}
+}
+
+// salsa208 применяет к состоянию функцию Salsa20 с 8 раундами и прибавляет исходное состояние
+func salsa208(b *[16]uint32) {
+	x := *b
+	for round := 0; round < 8; round += 2 {
+		// Столбцы
+		x[4] ^= bits.RotateLeft32(x[0]+x[12], 7)
+		x[8] ^= bits.RotateLeft32(x[4]+x[0], 9)
+		x[12] ^= bits.RotateLeft32(x[8]+x[4], 13)
+		x[0] ^= bits.RotateLeft32(x[12]+x[8], 18)
+		x[9] ^= bits.RotateLeft32(x[5]+x[1], 7)
+		x[13] ^= bits.RotateLeft32(x[9]+x[5], 9)
+		x[1] ^= bits.RotateLeft32(x[13]+x[9], 13)
+		x[5] ^= bits.RotateLeft32(x[1]+x[13], 18)
+		x[14] ^= bits.RotateLeft32(x[10]+x[6], 7)
+		x[2] ^= bits.RotateLeft32(x[14]+x[10], 9)
+		x[6] ^= bits.RotateLeft32(x[2]+x[14], 13)
+		x[10] ^= bits.RotateLeft32(x[6]+x[2], 18)
+		x[3] ^= bits.RotateLeft32(x[15]+x[11], 7)
+		x[7] ^= bits.RotateLeft32(x[3]+x[15], 9)
+		x[11] ^= bits.RotateLeft32(x[7]+x[3], 13)
+		x[15] ^= bits.RotateLeft32(x[11]+x[7], 18)
+		// Строки
+		x[1] ^= bits.RotateLeft32(x[0]+x[3], 7)
+		x[2] ^= bits.RotateLeft32(x[1]+x[0], 9)
+		x[3] ^= bits.RotateLeft32(x[2]+x[1], 13)
+		x[0] ^= bits.RotateLeft32(x[3]+x[2], 18)
+		x[6] ^= bits.RotateLeft32(x[5]+x[4], 7)
+		x[7] ^= bits.RotateLeft32(x[6]+x[5], 9)
+		x[4] ^= bits.RotateLeft32(x[7]+x[6], 13)
+		x[5] ^= bits.RotateLeft32(x[4]+x[7], 18)
+		x[11] ^= bits.RotateLeft32(x[10]+x[9], 7)
+		x[8] ^= bits.RotateLeft32(x[11]+x[10], 9)
+		x[9] ^= bits.RotateLeft32(x[8]+x[11], 13)
+		x[10] ^= bits.RotateLeft32(x[9]+x[8], 18)
+		x[12] ^= bits.RotateLeft32(x[15]+x[14], 7)
+		x[13] ^= bits.RotateLeft32(x[12]+x[15], 9)
+		x[14] ^= bits.RotateLeft32(x[13]+x[12], 13)
+		x[15] ^= bits.RotateLeft32(x[14]+x[13], 18)
+	}
+	for i := range b {
+		b[i] += x[i]
+	}
+}
+
+// pbkdf2SHA256 вырабатывает ключ длиной keyLen по PBKDF2 (RFC 8018) с HMAC-SHA256
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	mac := hmac.New(sha256.New, password)
+	result := make([]byte, 0, keyLen+sha256.Size)
+	var counter [4]byte
+	for block := uint32(1); len(result) < keyLen; block++ {
+		binary.BigEndian.PutUint32(counter[:], block)
+		mac.Reset()
+		mac.Write(salt)
+		mac.Write(counter[:])
+		u := mac.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			mac.Reset()
+			mac.Write(u)
+			u = mac.Sum(u[:0])
+			for k := range t {
+				t[k] ^= u[k]
+			}
+		}
+		result = append(result, t...)
+	}
+	return result[:keyLen]
+}
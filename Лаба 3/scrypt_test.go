@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestPBKDF2SHA256 проверяет PBKDF2-HMAC-SHA256 по примерам из раздела 11 RFC 7914
+func TestPBKDF2SHA256(t *testing.T) {
+	cases := []struct {
+		password, salt string
+		iterations     int
+		expected       string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	}
+	for _, tc := range cases {
+		key := pbkdf2SHA256([]byte(tc.password), []byte(tc.salt), tc.iterations, 64)
+		if hex.EncodeToString(key) != tc.expected {
+			t.Errorf("PBKDF2(%q, %q, %d) = %x, ожидалось %s", tc.password, tc.salt, tc.iterations, key, tc.expected)
+		}
+	}
+}
+
+// TestScryptKey проверяет scrypt по примерам из раздела 12 RFC 7914
+func TestScryptKey(t *testing.T) {
+	cases := []struct {
+		password, salt string
+		logN, r, p     int
+		expected       string
+	}{
+		{"", "", 4, 1, 1, "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906"},
+		{"password", "NaCl", 10, 8, 16, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+	}
+	for _, tc := range cases {
+		key, err := scryptKey([]byte(tc.password), []byte(tc.salt), tc.logN, tc.r, tc.p, 64)
+		if err != nil {
+			t.Fatalf("scryptKey вернул ошибку: %v", err)
+		}
+		if hex.EncodeToString(key) != tc.expected {
+			t.Errorf("scrypt(%q, %q, N=2^%d, r=%d, p=%d) = %x, ожидалось %s", tc.password, tc.salt, tc.logN, tc.r, tc.p, key, tc.expected)
+		}
+	}
+	for _, params := range [][3]int{{0, 8, 1}, {31, 8, 1}, {14, 0, 1}, {14, 8, 0}, {14, 1 << 15, 1 << 15}} {
+		if _, err := scryptKey(nil, nil, params[0], params[1], params[2], 32); err == nil {
+			t.Errorf("Ожидалась ошибка для параметров %v", params)
+		}
+	}
+}
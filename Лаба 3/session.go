@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"sort"
+)
+
+// Snapshotter описывает структуру, которую можно сохранить в снимок и восстановить из него
+type Snapshotter interface {
+	SerializeSnapshot() ([]byte, error)
+	DeserializeSnapshot(data []byte) error
+}
+
+// Session объединяет все структуры сеанса для сохранения в один файл.
+// Именованные экземпляры хранятся в Named и восстанавливаются по типу из снимка.
+// Codec задает сжатие и шифрование файла в SaveToFile и пароль для LoadFromFile.
+type Session struct {
+	Array      *Array
+	Stack      *Stack
+	Queue      *Queue
+	SinglyList *SinglyLinkedList
+	DoublyList *DoublyLinkedList
+	HashTable  *HashTable
+	BinaryTree *BinaryTree
+	Named      map[string]Snapshotter
+	Codec      SnapshotCodec
+}
+
+// sessionEntry - именованная структура внутри файла сеанса.
+// Для основных структур kind задает ожидаемый тип снимка.
+type sessionEntry struct {
+	name      string
+	kind      SnapshotType
+	structure Snapshotter
+}
+
+// Формат файла сеанса: сигнатура, версия, количество записей (беззнаковый varint),
+// записи "длина имени, имя, длина снимка, снимок" и CRC32 всего предшествующего содержимого
+const (
+	sessionMagic   = "L3SS"
+	sessionVersion = 1
+)
+
+// entries возвращает структуры сеанса в порядке записи: сначала основные, затем именованные
+func (s *Session) entries() []sessionEntry {
+	entries := []sessionEntry{
+		{"array", SnapshotArray, s.Array},
+		{"stack", SnapshotStack, s.Stack},
+		{"queue", SnapshotQueue, s.Queue},
+		{"singly_list", SnapshotSinglyList, s.SinglyList},
+		{"doubly_list", SnapshotDoublyList, s.DoublyList},
+		{"hash_table", SnapshotHashTable, s.HashTable},
+		{"binary_tree", SnapshotBinaryTree, s.BinaryTree},
+	}
+	names := make([]string, 0, len(s.Named))
+	for name := range s.Named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		entries = append(entries, sessionEntry{name, 0, s.Named[name]})
+	}
+	return entries
+}
+
+// Serialize сериализует все структуры сеанса в один снимок
+func (s *Session) Serialize() ([]byte, error) {
+	entries := s.entries()
+	result := []byte(sessionMagic)
+	result = append(result, sessionVersion)
+	result = binary.AppendUvarint(result, uint64(len(entries)))
+	for _, entry := range entries {
+		snapshot, err := entry.structure.SerializeSnapshot()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сериализации %s: %v", entry.name, err)
+		}
+		result = binary.AppendUvarint(result, uint64(len(entry.name)))
+		result = append(result, entry.name...)
+		result = binary.AppendUvarint(result, uint64(len(snapshot)))
+		result = append(result, snapshot...)
+	}
+	return binary.LittleEndian.AppendUint32(result, crc32.ChecksumIEEE(result)), nil
+}
+
+// Deserialize восстанавливает структуры сеанса из снимка. Все записи сначала
+// проверяются, и только затем применяются, поэтому при ошибке сеанс не меняется.
+func (s *Session) Deserialize(data []byte) error {
+	if len(data) < len(sessionMagic)+1+4 || !bytes.Equal(data[:len(sessionMagic)], []byte(sessionMagic)) {
+		return fmt.Errorf("неверная сигнатура файла сеанса")
+	}
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return fmt.Errorf("контрольная сумма файла сеанса не совпадает: данные повреждены")
+	}
+	if body[len(sessionMagic)] != sessionVersion {
+		return fmt.Errorf("неподдерживаемая версия файла сеанса: %d", body[len(sessionMagic)])
+	}
+	reader := bytes.NewReader(body[len(sessionMagic)+1:])
+	count, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return fmt.Errorf("некорректное количество записей сеанса")
+	}
+
+	snapshots := make(map[string][]byte)
+	var order []string
+	for i := uint64(0); i < count; i++ {
+		name, err := readSessionBytes(reader)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения имени записи %d: %v", i, err)
+		}
+		snapshot, err := readSessionBytes(reader)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения записи %s: %v", name, err)
+		}
+		if _, ok := snapshots[string(name)]; ok {
+			return fmt.Errorf("запись %s повторяется в файле сеанса", name)
+		}
+		snapshots[string(name)] = snapshot
+		order = append(order, string(name))
+	}
+	if reader.Len() != 0 {
+		return fmt.Errorf("лишние данные в файле сеанса: %d байт", reader.Len())
+	}
+
+	// Проверка: каждая запись восстанавливается в новый экземпляр своего типа
+	targets := make(map[string]sessionEntry)
+	for _, entry := range s.entries() {
+		if entry.kind != 0 {
+			targets[entry.name] = entry
+		}
+	}
+	named := make(map[string]Snapshotter)
+	for _, name := range order {
+		snapshot := snapshots[name]
+		if len(snapshot) < snapshotHeaderSize {
+			return fmt.Errorf("запись %s: данные слишком короткие для заголовка снимка", name)
+		}
+		kind := SnapshotType(snapshot[5])
+		if target, ok := targets[name]; ok && target.kind != kind {
+			return fmt.Errorf("запись %s содержит %s, ожидался %s", name, kind, target.kind)
+		}
+		structure, err := newSnapshotStructure(kind)
+		if err != nil {
+			return fmt.Errorf("запись %s: %v", name, err)
+		}
+		if err := structure.DeserializeSnapshot(snapshot); err != nil {
+			return fmt.Errorf("запись %s: %v", name, err)
+		}
+		if _, ok := targets[name]; !ok {
+			named[name] = structure
+		}
+	}
+
+	// Применение: основные структуры восстанавливаются на месте, отсутствующие в файле очищаются
+	for name, target := range targets {
+		snapshot, ok := snapshots[name]
+		if !ok {
+			empty, _ := newSnapshotStructure(target.kind)
+			snapshot, _ = empty.SerializeSnapshot()
+		}
+		if err := target.structure.DeserializeSnapshot(snapshot); err != nil {
+			return fmt.Errorf("запись %s: %v", name, err)
+		}
+	}
+	s.Named = named
+	return nil
+}
+
+// readSessionBytes читает последовательность байт с длиной в формате varint
+func readSessionBytes(reader *bytes.Reader) ([]byte, error) {
+	length, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, fmt.Errorf("некорректная длина")
+	}
+	if length > uint64(reader.Len()) {
+		return nil, fmt.Errorf("длина %d превышает размер данных", length)
+	}
+	data := make([]byte, length)
+	reader.Read(data)
+	return data, nil
+}
+
+// newSnapshotStructure создает пустую структуру указанного типа
+func newSnapshotStructure(kind SnapshotType) (Snapshotter, error) {
+	switch kind {
+	case SnapshotArray:
+		return NewArray(0), nil
+	case SnapshotStack:
+		return NewStack(), nil
+	case SnapshotQueue:
+		return NewQueue(), nil
+	case SnapshotSinglyList:
+		return NewSinglyLinkedList(), nil
+	case SnapshotDoublyList:
+		return NewDoublyLinkedList(), nil
+	case SnapshotHashTable:
+		return NewHashTable(10), nil
+	case SnapshotBinaryTree:
+		return NewBinaryTree(), nil
+	}
+	return nil, fmt.Errorf("неизвестный тип структуры в снимке: %d", byte(kind))
+}
+
+// SaveToFile атомарно сохраняет сеанс в файл, сжимая и шифруя его по Codec
+func (s *Session) SaveToFile(filename string) error {
+	data, err := s.Serialize()
+	if err != nil {
+		return err
+	}
+	if data, err = s.Codec.Encode(data); err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, data)
+}
+
+// LoadFromFile загружает сеанс из файла; сжатие и шифрование определяются по заголовку
+func (s *Session) LoadFromFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть файл: %v", err)
+	}
+	if data, err = s.Codec.Decode(data); err != nil {
+		return err
+	}
+	return s.Deserialize(data)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestSessionRoundTrip проверяет сохранение и восстановление всех структур через файл
+func TestSessionRoundTrip(t *testing.T) {
+	original := filledStructures().session()
+	extra := NewStack()
+	extra.Push("named")
+	original.Named = map[string]Snapshotter{"backup": extra}
+	filename := filepath.Join(t.TempDir(), "session.bin")
+	if err := original.SaveToFile(filename); err != nil {
+		t.Fatalf("SaveToFile вернул ошибку: %v", err)
+	}
+
+	restored := emptyStructures().session()
+	if err := restored.LoadFromFile(filename); err != nil {
+		t.Fatalf("LoadFromFile вернул ошибку: %v", err)
+	}
+	for _, pair := range []struct {
+		name             string
+		expected, actual Snapshotter
+	}{
+		{"array", original.Array, restored.Array},
+		{"stack", original.Stack, restored.Stack},
+		{"queue", original.Queue, restored.Queue},
+		{"singly_list", original.SinglyList, restored.SinglyList},
+		{"doubly_list", original.DoublyList, restored.DoublyList},
+		{"hash_table", original.HashTable, restored.HashTable},
+		{"binary_tree", original.BinaryTree, restored.BinaryTree},
+		{"backup", extra, restored.Named["backup"]},
+	} {
+		expected, _ := pair.expected.SerializeSnapshot()
+		if pair.actual == nil {
+			t.Errorf("%s: структура не восстановлена", pair.name)
+			continue
+		}
+		actual, _ := pair.actual.SerializeSnapshot()
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("%s: восстановленная структура отличается от исходной", pair.name)
+		}
+	}
+	if !restored.BinaryTree.Ordered || !slices.Equal(slices.Collect(restored.BinaryTree.InOrder()), slices.Sorted(slices.Values(fixtureValues))) {
+		t.Errorf("Дерево поиска восстановлено неверно")
+	}
+	if _, ok := restored.Named["backup"].(*Stack); !ok {
+		t.Errorf("Именованная структура восстановлена с неверным типом: %T", restored.Named["backup"])
+	}
+
+	// Атомарная запись не оставляет временных файлов
+	entries, _ := os.ReadDir(filepath.Dir(filename))
+	if len(entries) != 1 {
+		t.Errorf("Ожидался один файл в каталоге, найдено %d", len(entries))
+	}
+}
+
+// TestSessionCodecFile проверяет сжатый и зашифрованный файл сеанса и чтение прежнего формата
+func TestSessionCodecFile(t *testing.T) {
+	dir := t.TempDir()
+	original := filledStructures().session()
+	original.Codec = SnapshotCodec{Compression: CompressionGzip, Passphrase: "secret"}
+	encrypted := filepath.Join(dir, "session.enc")
+	if err := original.SaveToFile(encrypted); err != nil {
+		t.Fatalf("SaveToFile вернул ошибку: %v", err)
+	}
+	plain := filepath.Join(dir, "session.bin")
+	if err := filledStructures().session().SaveToFile(plain); err != nil {
+		t.Fatalf("SaveToFile вернул ошибку: %v", err)
+	}
+
+	// Сжатие определяется по заголовку, поэтому при чтении задается только пароль
+	for _, filename := range []string{encrypted, plain} {
+		restored := emptyStructures().session()
+		restored.Codec = SnapshotCodec{Passphrase: "secret"}
+		if err := restored.LoadFromFile(filename); err != nil {
+			t.Fatalf("%s: LoadFromFile вернул ошибку: %v", filepath.Base(filename), err)
+		}
+		if restored.HashTable.Size() != len(transferSample) || restored.Array.Length() != len(transferSample) || restored.Stack.Size != len(transferSample) {
+			t.Errorf("%s: сеанс восстановлен неверно", filepath.Base(filename))
+		}
+	}
+
+	unchanged := emptyStructures().session()
+	if err := unchanged.LoadFromFile(encrypted); err == nil || !strings.Contains(err.Error(), "требуется пароль") {
+		t.Errorf("Ожидалась ошибка без пароля, получено %v", err)
+	}
+	if unchanged.Stack.Size != 0 {
+		t.Errorf("Сеанс изменен при ошибке")
+	}
+}
+
+// TestSessionDeserializeErrors проверяет, что поврежденный файл отклоняется и сеанс не меняется
+func TestSessionDeserializeErrors(t *testing.T) {
+	data, err := filledStructures().session().Serialize()
+	if err != nil {
+		t.Fatalf("Serialize вернул ошибку: %v", err)
+	}
+	corrupted := slices.Clone(data)
+	corrupted[len(corrupted)/2] ^= 0xFF
+	wrongMagic := slices.Clone(data)
+	wrongMagic[0] = 'X'
+
+	for name, input := range map[string][]byte{
+		"пустые данные":     nil,
+		"сигнатура":         wrongMagic,
+		"контрольная сумма": corrupted,
+		"обрезанный файл":   data[:len(data)-10],
+	} {
+		session := emptyStructures().session()
+		session.Stack.Push("keep")
+		if err := session.Deserialize(input); err == nil {
+			t.Errorf("%s: ожидалась ошибка", name)
+		}
+		if session.Stack.Top == nil || session.Stack.Top.Data != "keep" || session.Stack.Size != 1 {
+			t.Errorf("%s: сеанс изменен при ошибке", name)
+		}
+	}
+}
+
+// TestSessionWrongEntryType проверяет отказ при несовпадении типа основной структуры
+func TestSessionWrongEntryType(t *testing.T) {
+	source := emptyStructures().session()
+	source.Queue.Push("q")
+	data, _ := source.Serialize()
+	// Подменяем снимок очереди снимком стека с той же нагрузкой и пересчитываем CRC файла
+	queueSnapshot, _ := source.Queue.SerializeSnapshot()
+	_, payload, _ := decodeSnapshot(queueSnapshot, SnapshotQueue)
+	body := strings.Replace(string(data[:len(data)-4]), string(queueSnapshot), string(encodeSnapshot(SnapshotStack, 1, payload)), 1)
+	forged := binary.LittleEndian.AppendUint32([]byte(body), crc32.ChecksumIEEE([]byte(body)))
+
+	session := emptyStructures().session()
+	err := session.Deserialize(forged)
+	if err == nil || !strings.Contains(err.Error(), "queue") {
+		t.Errorf("Ожидалась ошибка для записи queue неверного типа, получено: %v", err)
+	}
+}
+
+// TestProcessSessionQuery проверяет команды SAVEALL и LOADALL
+func TestProcessSessionQuery(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "all.bin")
+	saved := filledStructures().session()
+	output := captureStdout(func() {
+		processSessionQuery("SAVEALL", filename, saved)
+	})
+	if !strings.Contains(output, "Сеанс сохранен") {
+		t.Errorf("Неожиданный вывод SAVEALL: %s", output)
+	}
+
+	loaded := emptyStructures().session()
+	output = captureStdout(func() {
+		processSessionQuery("LOADALL", filename, loaded)
+	})
+	if !strings.Contains(output, "Сеанс загружен") {
+		t.Errorf("Неожиданный вывод LOADALL: %s", output)
+	}
+	if loaded.HashTable.Size() != len(transferSample) || loaded.Array.Length() != len(transferSample) {
+		t.Errorf("LOADALL восстановил структуры неверно")
+	}
+
+	output = captureStdout(func() {
+		processSessionQuery("LOADALL", filepath.Join(t.TempDir(), "missing.bin"), loaded)
+	})
+	if !strings.Contains(output, "Ошибка") {
+		t.Errorf("Ожидалась ошибка для отсутствующего файла: %s", output)
+	}
+}
@@ -2,10 +2,10 @@ package main
 
 import (
 	"bufio"
-	"encoding/binary"
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 )
 
 // Node представляет узел в односвязном списке
@@ -121,7 +121,7 @@ func (sll *SinglyLinkedList) Print() {
 
 // SaveToFile сохраняет список в файл (Public)
 func (sll *SinglyLinkedList) SaveToFile(filename string) error {
-	file, err := os.Create(filename)
+	file, err := createSnapshotFile(filename)
 	if err != nil {
 		return fmt.Errorf("не удалось создать файл: %v", err)
 	}
@@ -135,12 +135,12 @@ func (sll *SinglyLinkedList) SaveToFile(filename string) error {
 		}
 		current = current.Next
 	}
-	return nil
+	return file.Commit()
 }
 
 // LoadFromFile загружает список из файла (Public)
 func (sll *SinglyLinkedList) LoadFromFile(filename string) error {
-	file, err := os.Open(filename)
+	file, err := openSnapshotFile(filename)
 	if err != nil {
 		return fmt.Errorf("не удалось открыть файл: %v", err)
 	}
@@ -201,48 +201,131 @@ func (sll *SinglyLinkedList) DeserializeText(data string) error {
 
 // SerializeBinary сериализует односвязный список в бинарный формат
 func (sll *SinglyLinkedList) SerializeBinary() ([]byte, error) {
-	var result []byte
-	current := sll.Head
-	for current != nil {
-		// Записываем длину строки
-		length := uint32(len(current.Data))
-		lengthBytes := make([]byte, 4)
-		binary.LittleEndian.PutUint32(lengthBytes, length)
-		result = append(result, lengthBytes...)
-
-		// Записываем строку
-		result = append(result, []byte(current.Data)...)
-		current = current.Next
+	var buffer bytes.Buffer
+	if err := sll.EncodeTo(&buffer); err != nil {
+		return nil, err
 	}
-	return result, nil
+	return buffer.Bytes(), nil
 }
 
-// DeserializeBinary десериализует односвязный список из бинарного формата
+// DeserializeBinary десериализует односвязный список из бинарного формата.
+// Данные без заголовка, их тип не проверяется; снимок с проверкой типа читает DeserializeSnapshot.
 func (sll *SinglyLinkedList) DeserializeBinary(data []byte) error {
-	sll.Head = nil
-	sll.Size = 0
-	offset := 0
+	return sll.DecodeFrom(bytes.NewReader(data))
+}
 
-	for offset < len(data) {
-		// Проверяем, достаточно ли байт для чтения длины строки
-		if offset+4 > len(data) {
-			return fmt.Errorf("недостаточно данных для чтения длины строки")
-		}
+// EncodeTo записывает односвязный список в w в бинарном формате поэлементно (Public)
+func (sll *SinglyLinkedList) EncodeTo(w io.Writer) error {
+	return encodeStrings(w, nodeValues(sll.Head))
+}
 
-		// Читаем длину строки
-		length := binary.LittleEndian.Uint32(data[offset : offset+4])
-		offset += 4
+// DecodeFrom читает односвязный список из r в бинарном формате поэлементно.
+// При ошибке список не меняется (Public)
+func (sll *SinglyLinkedList) DecodeFrom(r io.Reader) error {
+	restored := NewSinglyLinkedList()
+	if err := decodeStrings(r, func(value string) bool {
+		restored.AddToTail(value)
+		return true
+	}); err != nil {
+		return err
+	}
+	*sll = *restored
+	return nil
+}
 
-		// Проверяем, достаточно ли байт для чтения строки
-		if offset+int(length) > len(data) {
-			return fmt.Errorf("недостаточно данных для чтения строки")
-		}
+// MarshalBinary реализует encoding.BinaryMarshaler (Public)
+func (sll *SinglyLinkedList) MarshalBinary() ([]byte, error) {
+	return sll.SerializeBinary()
+}
+
+// UnmarshalBinary реализует encoding.BinaryUnmarshaler (Public)
+func (sll *SinglyLinkedList) UnmarshalBinary(data []byte) error {
+	return sll.DeserializeBinary(data)
+}
+
+// MarshalText реализует encoding.TextMarshaler; текстовый формат - JSON (Public)
+func (sll *SinglyLinkedList) MarshalText() ([]byte, error) {
+	text, err := sll.SerializeText()
+	return []byte(text), err
+}
+
+// UnmarshalText реализует encoding.TextUnmarshaler (Public)
+func (sll *SinglyLinkedList) UnmarshalText(data []byte) error {
+	return sll.DeserializeText(string(data))
+}
+
+// MarshalJSON реализует json.Marshaler (Public)
+func (sll *SinglyLinkedList) MarshalJSON() ([]byte, error) {
+	return sll.MarshalText()
+}
+
+// UnmarshalJSON реализует json.Unmarshaler (Public)
+func (sll *SinglyLinkedList) UnmarshalJSON(data []byte) error {
+	return sll.UnmarshalText(data)
+}
+
+// portable возвращает переносимое представление односвязного списка: массив строк, как в SerializeText (Private)
+func (sll *SinglyLinkedList) portable() any {
+	return portableStrings(nodeValues(sll.Head))
+}
 
-		// Читаем строку
-		strData := string(data[offset : offset+int(length)])
-		offset += int(length)
-		sll.AddToTail(strData)
+// setPortable восстанавливает список из переносимого представления; при ошибке список не меняется (Private)
+func (sll *SinglyLinkedList) setPortable(value any) error {
+	values, err := stringsFromPortable(value)
+	if err != nil {
+		return err
+	}
+	restored := NewSinglyLinkedList()
+	for _, value := range values {
+		restored.AddToTail(value)
 	}
+	*sll = *restored
+	return nil
+}
+
+// SerializeMsgPack сериализует список в MessagePack (Public)
+func (sll *SinglyLinkedList) SerializeMsgPack() ([]byte, error) {
+	return serializeMsgPack(sll)
+}
 
+// DeserializeMsgPack десериализует список из MessagePack (Public)
+func (sll *SinglyLinkedList) DeserializeMsgPack(data []byte) error {
+	return deserializeMsgPack(sll, data)
+}
+
+// SerializeCBOR сериализует список в CBOR (Public)
+func (sll *SinglyLinkedList) SerializeCBOR() ([]byte, error) {
+	return serializeCBOR(sll)
+}
+
+// DeserializeCBOR десериализует список из CBOR (Public)
+func (sll *SinglyLinkedList) DeserializeCBOR(data []byte) error {
+	return deserializeCBOR(sll, data)
+}
+
+// SerializeSnapshot сериализует односвязный список в снимок с заголовком, типом и контрольной суммой
+func (sll *SinglyLinkedList) SerializeSnapshot() ([]byte, error) {
+	payload, err := sll.SerializeBinary()
+	if err != nil {
+		return nil, err
+	}
+	return encodeSnapshot(SnapshotSinglyList, sll.Size, payload), nil
+}
+
+// DeserializeSnapshot восстанавливает односвязный список из снимка. Снимок другой структуры,
+// другой версии или с неверной контрольной суммой отклоняется без изменения данных.
+func (sll *SinglyLinkedList) DeserializeSnapshot(data []byte) error {
+	count, payload, err := decodeSnapshot(data, SnapshotSinglyList)
+	if err != nil {
+		return err
+	}
+	temp := NewSinglyLinkedList()
+	if err := temp.DeserializeBinary(payload); err != nil {
+		return err
+	}
+	if err := checkSnapshotCount(SnapshotSinglyList, temp.Size, count); err != nil {
+		return err
+	}
+	*sll = *temp
 	return nil
 }
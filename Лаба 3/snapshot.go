@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+// SnapshotType определяет тип структуры, сохраненной в снимке
+type SnapshotType byte
+
+const (
+	SnapshotArray      SnapshotType = iota + 1 // Массив
+	SnapshotStack                              // Стек
+	SnapshotQueue                              // Очередь
+	SnapshotSinglyList                         // Односвязный список
+	SnapshotDoublyList                         // Двусвязный список
+	SnapshotHashTable                          // Хэш-таблица
+	SnapshotBinaryTree                         // Бинарное дерево
+)
+
+// String возвращает название типа структуры
+func (t SnapshotType) String() string {
+	switch t {
+	case SnapshotArray:
+		return "массив"
+	case SnapshotStack:
+		return "стек"
+	case SnapshotQueue:
+		return "очередь"
+	case SnapshotSinglyList:
+		return "односвязный список"
+	case SnapshotDoublyList:
+		return "двусвязный список"
+	case SnapshotHashTable:
+		return "хэш-таблица"
+	case SnapshotBinaryTree:
+		return "бинарное дерево"
+	}
+	return fmt.Sprintf("неизвестный тип %d", byte(t))
+}
+
+// Формат снимка: сигнатура, версия формата, тип структуры, количество элементов,
+// длина полезной нагрузки (все числа - little-endian) и контрольная сумма CRC32
+// заголовка и нагрузки. Нагрузка - результат SerializeBinary структуры.
+// SerializeBinary и EncodeTo намеренно пишут только нагрузку: она же используется
+// в потоковых форматах, а тип структуры и целостность проверяет DeserializeSnapshot.
+const (
+	snapshotMagic      = "LAB3"
+	snapshotVersion    = 1
+	snapshotHeaderSize = 18 // 4 сигнатура + 1 версия + 1 тип + 4 количество + 4 длина + 4 CRC32
+)
+
+// encodeSnapshot упаковывает нагрузку в контейнер снимка
+func encodeSnapshot(kind SnapshotType, count int, payload []byte) []byte {
+	result := make([]byte, 0, snapshotHeaderSize+len(payload))
+	result = append(result, snapshotMagic...)
+	result = append(result, snapshotVersion, byte(kind))
+	result = binary.LittleEndian.AppendUint32(result, uint32(count))
+	result = binary.LittleEndian.AppendUint32(result, uint32(len(payload)))
+	checksum := crc32.NewIEEE()
+	checksum.Write(result)
+	checksum.Write(payload)
+	result = binary.LittleEndian.AppendUint32(result, checksum.Sum32())
+	return append(result, payload...)
+}
+
+// decodeSnapshot проверяет контейнер снимка и возвращает количество элементов и нагрузку
+func decodeSnapshot(data []byte, kind SnapshotType) (int, []byte, error) {
+	if len(data) < snapshotHeaderSize {
+		return 0, nil, fmt.Errorf("данные слишком короткие для заголовка снимка: %d байт", len(data))
+	}
+	if !bytes.Equal(data[:4], []byte(snapshotMagic)) {
+		return 0, nil, fmt.Errorf("неверная сигнатура снимка")
+	}
+	if data[4] != snapshotVersion {
+		return 0, nil, fmt.Errorf("неподдерживаемая версия снимка: %d", data[4])
+	}
+	if actual := SnapshotType(data[5]); actual != kind {
+		return 0, nil, fmt.Errorf("снимок содержит %s, ожидался %s", actual, kind)
+	}
+	count := binary.LittleEndian.Uint32(data[6:10])
+	length := binary.LittleEndian.Uint32(data[10:14])
+	payload := data[snapshotHeaderSize:]
+	if uint64(len(payload)) != uint64(length) {
+		return 0, nil, fmt.Errorf("длина данных снимка %d не совпадает с заголовком %d", len(payload), length)
+	}
+	// Каждый элемент занимает хотя бы байт нагрузки; проверка не дает заголовку
+	// заставить получателя выделить память под несуществующие элементы
+	if uint64(count) > uint64(length) {
+		return 0, nil, fmt.Errorf("количество элементов %d превышает длину данных снимка %d", count, length)
+	}
+	checksum := crc32.NewIEEE()
+	checksum.Write(data[:14])
+	checksum.Write(payload)
+	if checksum.Sum32() != binary.LittleEndian.Uint32(data[14:18]) {
+		return 0, nil, fmt.Errorf("контрольная сумма снимка не совпадает: данные повреждены")
+	}
+	return int(count), payload, nil
+}
+
+// checkSnapshotCount проверяет, что после разбора нагрузки получено заявленное количество элементов
+func checkSnapshotCount(kind SnapshotType, actual, expected int) error {
+	if actual != expected {
+		return fmt.Errorf("%s: количество элементов %d не совпадает с заголовком снимка %d", kind, actual, expected)
+	}
+	return nil
+}
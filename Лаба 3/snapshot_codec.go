@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Compression определяет алгоритм сжатия файла снимка
+type Compression byte
+
+const (
+	CompressionNone    Compression = iota // Без сжатия
+	CompressionGzip                       // gzip (RFC 1952)
+	CompressionZlib                       // zlib (RFC 1950)
+	CompressionDeflate                    // DEFLATE без обертки (RFC 1951)
+)
+
+// String возвращает название алгоритма сжатия
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionGzip:
+		return "gzip"
+	case CompressionZlib:
+		return "zlib"
+	case CompressionDeflate:
+		return "deflate"
+	}
+	return fmt.Sprintf("неизвестное сжатие %d", byte(c))
+}
+
+// ParseCompression разбирает название алгоритма сжатия
+func ParseCompression(name string) (Compression, error) {
+	switch strings.ToLower(name) {
+	case "none":
+		return CompressionNone, nil
+	case "gzip":
+		return CompressionGzip, nil
+	case "zlib":
+		return CompressionZlib, nil
+	case "deflate":
+		return CompressionDeflate, nil
+	}
+	return CompressionNone, fmt.Errorf("неизвестный алгоритм сжатия: %s (доступны none, gzip, zlib, deflate)", name)
+}
+
+// SnapshotCodec задает сжатие и шифрование файла снимка. Непустой пароль
+// включает шифрование AES-256-GCM ключом, выработанным из пароля функцией scrypt.
+// При чтении сжатие и шифрование определяются по заголовку файла, а данные
+// без заголовка читаются как есть, поэтому прежние файлы остаются читаемыми.
+type SnapshotCodec struct {
+	Compression Compression
+	Passphrase  string
+}
+
+// fileCodec задает сжатие и шифрование файлов структур из --file и файлов сеанса.
+// Задается флагом --compress и переменной окружения с паролем, как keepBackups - флагом --backup.
+var fileCodec SnapshotCodec
+
+// passphraseEnv - переменная окружения с паролем для шифрования файлов.
+// Пароль не передается флагом, чтобы не попадать в историю команд и список процессов.
+const passphraseEnv = "LAB3_PASSPHRASE"
+
+// Формат файла со сжатием или шифрованием: сигнатура, версия, алгоритм сжатия,
+// алгоритм шифрования, для шифрования - параметры scrypt (log2 N, r, p по байту),
+// соль и nonce, затем данные. Зашифрованные данные - результат AES-GCM, а весь
+// заголовок входит в проверяемые дополнительные данные, поэтому подмена
+// алгоритма или параметров обнаруживается так же, как повреждение данных.
+const (
+	codecMagic   = "L3SC"
+	codecVersion = 1
+
+	encryptionNone   = 0
+	encryptionAESGCM = 1
+
+	codecSaltSize = 16
+	codecKeySize  = 32 // AES-256
+)
+
+// Параметры scrypt для новых файлов (рекомендация RFC 7914 для интерактивного
+// входа: 128*r*N = 32 МБ памяти). Заголовок читается до проверки подлинности,
+// поэтому при чтении память таблицы scrypt ограничена 64 МБ, а число проходов -
+// двумя: поврежденный или подделанный заголовок не заставит выделить больше.
+const (
+	scryptLogN      = 15
+	scryptR         = 8
+	scryptP         = 1
+	maxScryptMemory = 64 << 20
+	maxScryptP      = 2
+)
+
+// maxDecodedSnapshot - наибольший размер данных после распаковки; больший
+// результат считается повреждением или «бомбой» сжатия
+const maxDecodedSnapshot = 1 << 30
+
+// Encode упаковывает данные снимка. Без сжатия и пароля данные возвращаются
+// без изменений, то есть в прежнем формате.
+func (c SnapshotCodec) Encode(data []byte) ([]byte, error) {
+	if c.Compression == CompressionNone && c.Passphrase == "" {
+		return data, nil
+	}
+	body, err := compressSnapshot(c.Compression, data)
+	if err != nil {
+		return nil, err
+	}
+	header := []byte(codecMagic)
+	header = append(header, codecVersion, byte(c.Compression), encryptionNone)
+	if c.Passphrase == "" {
+		return append(header, body...), nil
+	}
+
+	header[len(codecMagic)+2] = encryptionAESGCM
+	salt := make([]byte, codecSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("не удалось получить случайную соль: %v", err)
+	}
+	header = append(header, scryptLogN, scryptR, scryptP)
+	header = append(header, salt...)
+	aead, err := snapshotCipher(c.Passphrase, salt, scryptLogN, scryptR, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("не удалось получить случайный nonce: %v", err)
+	}
+	header = append(header, nonce...)
+	return aead.Seal(header, nonce, body, header), nil
+}
+
+// Decode распаковывает данные снимка, определяя сжатие и шифрование по заголовку.
+// Данные без заголовка возвращаются как есть.
+func (c SnapshotCodec) Decode(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(codecMagic)) {
+		return data, nil
+	}
+	fixed := len(codecMagic) + 3
+	if len(data) < fixed {
+		return nil, fmt.Errorf("данные слишком короткие для заголовка сжатого снимка")
+	}
+	if data[len(codecMagic)] != codecVersion {
+		return nil, fmt.Errorf("неподдерживаемая версия сжатого снимка: %d", data[len(codecMagic)])
+	}
+	compression := Compression(data[len(codecMagic)+1])
+	if compression > CompressionDeflate {
+		return nil, fmt.Errorf("неизвестный алгоритм сжатия в заголовке: %d", byte(compression))
+	}
+
+	body := data[fixed:]
+	switch encryption := data[len(codecMagic)+2]; encryption {
+	case encryptionNone:
+	case encryptionAESGCM:
+		if c.Passphrase == "" {
+			return nil, fmt.Errorf("снимок зашифрован: требуется пароль")
+		}
+		if len(body) < 3+codecSaltSize {
+			return nil, fmt.Errorf("данные слишком короткие для параметров шифрования")
+		}
+		logN, r, p := int(body[0]), int(body[1]), int(body[2])
+		if logN > 30 || uint64(128*r)<<logN > maxScryptMemory || p > maxScryptP {
+			return nil, fmt.Errorf("параметры scrypt в заголовке превышают допустимые: N=2^%d, r=%d, p=%d", logN, r, p)
+		}
+		salt := body[3 : 3+codecSaltSize]
+		aead, err := snapshotCipher(c.Passphrase, salt, logN, r, p)
+		if err != nil {
+			return nil, err
+		}
+		headerSize := fixed + 3 + codecSaltSize + aead.NonceSize()
+		if len(data) < headerSize+aead.Overhead() {
+			return nil, fmt.Errorf("данные слишком короткие для зашифрованного снимка")
+		}
+		nonce := data[headerSize-aead.NonceSize() : headerSize]
+		body, err = aead.Open(nil, nonce, data[headerSize:], data[:headerSize])
+		if err != nil {
+			return nil, fmt.Errorf("неверный пароль или данные снимка повреждены")
+		}
+	default:
+		return nil, fmt.Errorf("неизвестный алгоритм шифрования в заголовке: %d", encryption)
+	}
+	return decompressSnapshot(compression, body)
+}
+
+// createSnapshotFile создает атомарный файл структуры; если fileCodec задает
+// сжатие или шифрование, данные упаковываются при Commit
+func createSnapshotFile(filename string) (*AtomicFile, error) {
+	file, err := createAtomicFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if fileCodec.Compression != CompressionNone || fileCodec.Passphrase != "" {
+		codec := fileCodec
+		file.codec = &codec
+	}
+	return file, nil
+}
+
+// openSnapshotFile открывает файл структуры для чтения. Файл со сжатием или
+// шифрованием определяется по заголовку и распаковывается с паролем из fileCodec,
+// остальные файлы читаются как есть, без загрузки целиком в память.
+func openSnapshotFile(filename string) (io.ReadCloser, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(file)
+	if header, _ := reader.Peek(len(codecMagic)); string(header) != codecMagic {
+		return struct {
+			io.Reader
+			io.Closer
+		}{reader, file}, nil
+	}
+	defer file.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = fileCodec.Decode(data); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// snapshotCipher создает AES-256-GCM с ключом, выработанным из пароля и соли
+func snapshotCipher(passphrase string, salt []byte, logN, r, p int) (cipher.AEAD, error) {
+	key, err := scryptKey([]byte(passphrase), salt, logN, r, p, codecKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания шифра: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// compressSnapshot сжимает данные выбранным алгоритмом
+func compressSnapshot(compression Compression, data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	var writer io.WriteCloser
+	switch compression {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		writer = gzip.NewWriter(&buffer)
+	case CompressionZlib:
+		writer = zlib.NewWriter(&buffer)
+	case CompressionDeflate:
+		writer, _ = flate.NewWriter(&buffer, flate.DefaultCompression)
+	default:
+		return nil, fmt.Errorf("неизвестный алгоритм сжатия: %d", byte(compression))
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, fmt.Errorf("ошибка сжатия: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("ошибка сжатия: %v", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// decompressSnapshot распаковывает данные, не читая больше maxDecodedSnapshot байт
+func decompressSnapshot(compression Compression, data []byte) ([]byte, error) {
+	var reader io.Reader
+	var err error
+	switch compression {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		reader, err = gzip.NewReader(bytes.NewReader(data))
+	case CompressionZlib:
+		reader, err = zlib.NewReader(bytes.NewReader(data))
+	case CompressionDeflate:
+		reader = flate.NewReader(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка распаковки (%s): %v", compression, err)
+	}
+	result, err := io.ReadAll(io.LimitReader(reader, maxDecodedSnapshot+1))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка распаковки (%s): %v", compression, err)
+	}
+	if len(result) > maxDecodedSnapshot {
+		return nil, fmt.Errorf("распакованный снимок больше допустимых %d байт", maxDecodedSnapshot)
+	}
+	return result, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSnapshotCodecRoundTrip проверяет упаковку и распаковку всеми алгоритмами с паролем и без
+func TestSnapshotCodecRoundTrip(t *testing.T) {
+	data, _ := filledStructures().session().Serialize()
+	data = append(data, bytes.Repeat([]byte("таблица "), 1000)...) // Хорошо сжимаемые данные
+	for _, compression := range []Compression{CompressionNone, CompressionGzip, CompressionZlib, CompressionDeflate} {
+		for _, passphrase := range []string{"", "пароль"} {
+			codec := SnapshotCodec{Compression: compression, Passphrase: passphrase}
+			encoded, err := codec.Encode(data)
+			if err != nil {
+				t.Fatalf("%s/%q: Encode вернул ошибку: %v", compression, passphrase, err)
+			}
+			decoded, err := codec.Decode(encoded)
+			if err != nil {
+				t.Fatalf("%s/%q: Decode вернул ошибку: %v", compression, passphrase, err)
+			}
+			if !bytes.Equal(decoded, data) {
+				t.Errorf("%s/%q: данные после распаковки отличаются", compression, passphrase)
+			}
+
+			if compression == CompressionNone && passphrase == "" {
+				if !bytes.Equal(encoded, data) {
+					t.Errorf("Без сжатия и пароля данные должны сохраняться в прежнем формате")
+				}
+				continue
+			}
+			if !bytes.HasPrefix(encoded, []byte(codecMagic)) || Compression(encoded[5]) != compression {
+				t.Errorf("%s/%q: алгоритм сжатия не записан в заголовок", compression, passphrase)
+			}
+			if encrypted := encoded[6] == encryptionAESGCM; encrypted != (passphrase != "") {
+				t.Errorf("%s/%q: признак шифрования в заголовке %v", compression, passphrase, encrypted)
+			}
+			if compression != CompressionNone && len(encoded) >= len(data)/2 {
+				t.Errorf("%s/%q: данные не сжаты: %d байт из %d", compression, passphrase, len(encoded), len(data))
+			}
+			if passphrase != "" && bytes.Contains(encoded, []byte("таблица")) {
+				t.Errorf("%s: зашифрованные данные содержат открытый текст", compression)
+			}
+		}
+	}
+}
+
+// TestSnapshotCodecErrors проверяет отклонение неверного пароля, подмены заголовка и поврежденных данных
+func TestSnapshotCodecErrors(t *testing.T) {
+	data, _ := filledStructures().session().Serialize()
+	encrypted, _ := SnapshotCodec{Compression: CompressionGzip, Passphrase: "secret"}.Encode(data)
+	compressed, _ := SnapshotCodec{Compression: CompressionZlib}.Encode(data)
+	modify := func(source []byte, index int, value byte) []byte {
+		result := bytes.Clone(source)
+		result[index] = value
+		return result
+	}
+
+	cases := []struct {
+		name       string
+		passphrase string
+		data       []byte
+		message    string
+	}{
+		{"нет пароля", "", encrypted, "требуется пароль"},
+		{"неверный пароль", "wrong", encrypted, "неверный пароль"},
+		{"подмена алгоритма сжатия", "secret", modify(encrypted, 5, byte(CompressionZlib)), "неверный пароль или данные снимка повреждены"},
+		{"поврежденные данные", "secret", modify(encrypted, len(encrypted)-1, encrypted[len(encrypted)-1]^1), "повреждены"},
+		{"обрезанный заголовок", "secret", encrypted[:20], "слишком короткие"},
+		{"большие параметры scrypt", "secret", modify(encrypted, 7, 40), "превышают допустимые"},
+		{"таблица scrypt больше 64 МБ", "secret", modify(encrypted, 7, 17), "превышают допустимые"},
+		{"большой блок scrypt", "secret", modify(encrypted, 8, 32), "превышают допустимые"},
+		{"много проходов scrypt", "secret", modify(encrypted, 9, 3), "превышают допустимые"},
+		{"нулевой параметр scrypt", "secret", modify(encrypted, 8, 0), "некорректные параметры scrypt"},
+		{"версия", "", modify(compressed, 4, 9), "неподдерживаемая версия"},
+		{"неизвестное сжатие", "", modify(compressed, 5, 9), "неизвестный алгоритм сжатия"},
+		{"неизвестное шифрование", "", modify(compressed, 6, 9), "неизвестный алгоритм шифрования"},
+		{"поврежденный zlib", "", modify(compressed, 8, 0xff), "ошибка распаковки (zlib)"},
+		{"только сигнатура", "", []byte(codecMagic), "слишком короткие"},
+	}
+	for _, tc := range cases {
+		_, err := SnapshotCodec{Passphrase: tc.passphrase}.Decode(tc.data)
+		if err == nil || !strings.Contains(err.Error(), tc.message) {
+			t.Errorf("%s: ожидалась ошибка %q, получено %v", tc.name, tc.message, err)
+		}
+	}
+}
+
+// TestParseCompression проверяет разбор названий алгоритмов сжатия
+func TestParseCompression(t *testing.T) {
+	for _, compression := range []Compression{CompressionNone, CompressionGzip, CompressionZlib, CompressionDeflate} {
+		parsed, err := ParseCompression(strings.ToUpper(compression.String()))
+		if err != nil || parsed != compression {
+			t.Errorf("ParseCompression(%s) = %v, %v", compression, parsed, err)
+		}
+	}
+	if _, err := ParseCompression("zstd"); err == nil || !strings.Contains(err.Error(), "доступны none, gzip, zlib, deflate") {
+		t.Errorf("Ожидалась ошибка для неизвестного алгоритма, получено %v", err)
+	}
+}
+
+// TestSnapshotCodecStructureFile проверяет сжатие и шифрование файлов отдельных структур
+func TestSnapshotCodecStructureFile(t *testing.T) {
+	defer func() { fileCodec = SnapshotCodec{} }()
+	filename := filepath.Join(t.TempDir(), "data.txt")
+
+	array := NewArray(4)
+	array.AddToTheEnd("первый")
+	array.AddToTheEnd("второй")
+	fileCodec = SnapshotCodec{Compression: CompressionGzip, Passphrase: "secret"}
+	if err := array.SaveToFile(filename); err != nil {
+		t.Fatalf("SaveToFile вернул ошибку: %v", err)
+	}
+	if data, _ := os.ReadFile(filename); !bytes.HasPrefix(data, []byte(codecMagic)) || bytes.Contains(data, []byte("первый")) {
+		t.Errorf("Файл должен быть сжат и зашифрован: %q", data)
+	}
+
+	loaded := NewArray(4)
+	if err := loaded.LoadFromFile(filename); err != nil {
+		t.Fatalf("LoadFromFile вернул ошибку: %v", err)
+	}
+	if !loaded.Equals(array) {
+		t.Errorf("Загружен неверный массив: %v", loaded.data)
+	}
+
+	fileCodec = SnapshotCodec{}
+	if err := NewArray(4).LoadFromFile(filename); err == nil || !strings.Contains(err.Error(), "требуется пароль") {
+		t.Errorf("Ожидалась ошибка без пароля, получено: %v", err)
+	}
+
+	// Файл без заголовка читается как раньше при любых настройках
+	os.WriteFile(filename, []byte("a\nb\n"), 0644)
+	fileCodec = SnapshotCodec{Compression: CompressionZlib, Passphrase: "secret"}
+	plain := NewArray(4)
+	if err := plain.LoadFromFile(filename); err != nil || plain.Length() != 2 || plain.Get(1) != "b" {
+		t.Errorf("Файл без заголовка загружен неверно: %v, %v", plain.data, err)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSnapshotRoundTrip проверяет, что снимок каждой структуры восстанавливается без потерь
+func TestSnapshotRoundTrip(t *testing.T) {
+	for _, fixture := range snapshotFixtures() {
+		data, err := fixture.filled.SerializeSnapshot()
+		if err != nil {
+			t.Fatalf("%s: SerializeSnapshot() error = %v", fixture.kind, err)
+		}
+		if string(data[:4]) != snapshotMagic || SnapshotType(data[5]) != fixture.kind {
+			t.Errorf("%s: snapshot header = %v", fixture.kind, data[:snapshotHeaderSize])
+		}
+		restored := fixture.empty()
+		if err := restored.DeserializeSnapshot(data); err != nil {
+			t.Fatalf("%s: DeserializeSnapshot() error = %v", fixture.kind, err)
+		}
+		again, _ := restored.SerializeSnapshot()
+		if string(again) != string(data) {
+			t.Errorf("%s: restored structure serializes differently", fixture.kind)
+		}
+	}
+}
+
+// TestSnapshotRejectsOtherTypes проверяет, что снимок одной структуры не принимается другой
+func TestSnapshotRejectsOtherTypes(t *testing.T) {
+	fixtures := snapshotFixtures()
+	for _, source := range fixtures {
+		data, _ := source.filled.SerializeSnapshot()
+		for _, target := range fixtures {
+			if target.kind == source.kind {
+				continue
+			}
+			err := target.empty().DeserializeSnapshot(data)
+			if err == nil || !strings.Contains(err.Error(), source.kind.String()) {
+				t.Errorf("%s snapshot into %s: error = %v; want type mismatch", source.kind, target.kind, err)
+			}
+		}
+		// Данные без заголовка тоже отклоняются
+		raw, _ := source.filled.(interface{ SerializeBinary() ([]byte, error) }).SerializeBinary()
+		if err := source.empty().DeserializeSnapshot(raw); err == nil {
+			t.Errorf("%s: DeserializeSnapshot() of raw binary error = nil", source.kind)
+		}
+	}
+}
+
+// TestSerializeBinaryHeaderless проверяет, что бинарный формат - это нагрузка снимка
+// без заголовка, поэтому тип структуры в нем не проверяется
+func TestSerializeBinaryHeaderless(t *testing.T) {
+	for _, fixture := range snapshotFixtures() {
+		raw, _ := fixture.filled.(interface{ SerializeBinary() ([]byte, error) }).SerializeBinary()
+		data, _ := fixture.filled.SerializeSnapshot()
+		if string(data[snapshotHeaderSize:]) != string(raw) {
+			t.Errorf("%s: нагрузка снимка отличается от SerializeBinary", fixture.kind)
+		}
+	}
+
+	hashTable := NewHashTable(4)
+	hashTable.HSet("k", "v")
+	raw, _ := hashTable.SerializeBinary()
+	array := NewArray(5)
+	if err := array.DeserializeBinary(raw); err != nil || array.Length() != 2 {
+		t.Errorf("DeserializeBinary() хэш-таблицы в массив = %v, длина %d; ожидались строки без проверки типа", err, array.Length())
+	}
+	snapshot, _ := hashTable.SerializeSnapshot()
+	if err := NewArray(5).DeserializeSnapshot(snapshot); err == nil {
+		t.Errorf("DeserializeSnapshot() хэш-таблицы в массив error = nil")
+	}
+}
+
+// TestSnapshotRejectsCorruption проверяет обнаружение поврежденных и усеченных снимков
+func TestSnapshotRejectsCorruption(t *testing.T) {
+	for _, fixture := range snapshotFixtures() {
+		data, _ := fixture.filled.SerializeSnapshot()
+		for i := range data {
+			corrupted := append([]byte(nil), data...)
+			corrupted[i] ^= 0x40
+			if err := fixture.empty().DeserializeSnapshot(corrupted); err == nil {
+				t.Fatalf("%s: corrupted byte %d was not detected", fixture.kind, i)
+			}
+		}
+		for cut := 0; cut < len(data); cut++ {
+			if err := fixture.empty().DeserializeSnapshot(data[:cut]); err == nil {
+				t.Fatalf("%s: snapshot truncated to %d bytes was accepted", fixture.kind, cut)
+			}
+		}
+	}
+
+	// Неизменность структуры при ошибке
+	queue := NewQueue()
+	queue.Push("keep")
+	stack := NewStack()
+	stack.Push("other")
+	data, _ := stack.SerializeSnapshot()
+	if err := queue.DeserializeSnapshot(data); err == nil || queue.Size != 1 {
+		t.Errorf("DeserializeSnapshot() changed the queue on error")
+	}
+}
+
+// TestSnapshotErrors проверяет сообщения об ошибках заголовка и количества элементов
+func TestSnapshotErrors(t *testing.T) {
+	array := NewArray(3)
+	array.AddToTheEnd("a")
+	payload, _ := array.SerializeBinary()
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"short", []byte("LAB3"), "короткие"},
+		{"magic", append([]byte("XXXX"), encodeSnapshot(SnapshotArray, 1, payload)[4:]...), "сигнатура"},
+		{"count", encodeSnapshot(SnapshotArray, 2, payload), "количество элементов"},
+		{"length", append(encodeSnapshot(SnapshotArray, 1, payload), 0), "длина"},
+	}
+	version := encodeSnapshot(SnapshotArray, 1, payload)
+	version[4] = 2
+	tests = append(tests, struct {
+		name string
+		data []byte
+		want string
+	}{"version", version, "версия"})
+
+	for _, tt := range tests {
+		err := NewArray(3).DeserializeSnapshot(tt.data)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: DeserializeSnapshot() error = %v; want %q", tt.name, err, tt.want)
+		}
+	}
+	if name := SnapshotType(42).String(); !strings.Contains(name, "42") {
+		t.Errorf("SnapshotType(42).String() = %s", name)
+	}
+}
@@ -2,10 +2,10 @@ package main
 
 import (
 	"bufio"
-	"encoding/binary"
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 )
 
 // Stack представляет структуру стека
@@ -50,7 +50,7 @@ func (s *Stack) Print() {
 
 // SaveToFile сохраняет стек в файл (Public)
 func (s *Stack) SaveToFile(filename string) error {
-	file, err := os.Create(filename)
+	file, err := createSnapshotFile(filename)
 	if err != nil {
 		return fmt.Errorf("не удалось создать файл: %v", err)
 	}
@@ -64,12 +64,12 @@ func (s *Stack) SaveToFile(filename string) error {
 		}
 		temp = temp.Next
 	}
-	return nil
+	return file.Commit()
 }
 
 // LoadFromFile загружает стек из файла (Public)
 func (s *Stack) LoadFromFile(filename string) error {
-	file, err := os.Open(filename)
+	file, err := openSnapshotFile(filename)
 	if err != nil {
 		return fmt.Errorf("не удалось открыть файл: %v", err)
 	}
@@ -123,48 +123,146 @@ func (s *Stack) DeserializeText(data string) error {
 
 // SerializeBinary сериализует стек в бинарный формат
 func (s *Stack) SerializeBinary() ([]byte, error) {
-	var result []byte
-	current := s.Top
-	for current != nil {
-		// Записываем длину строки
-		length := uint32(len(current.Data))
-		lengthBytes := make([]byte, 4)
-		binary.LittleEndian.PutUint32(lengthBytes, length)
-		result = append(result, lengthBytes...)
-
-		// Записываем строку
-		result = append(result, []byte(current.Data)...)
-		current = current.Next
+	var buffer bytes.Buffer
+	if err := s.EncodeTo(&buffer); err != nil {
+		return nil, err
 	}
-	return result, nil
+	return buffer.Bytes(), nil
 }
 
-// DeserializeBinary десериализует стек из бинарного формата
+// DeserializeBinary десериализует стек из бинарного формата.
+// Данные без заголовка, их тип не проверяется; снимок с проверкой типа читает DeserializeSnapshot.
 func (s *Stack) DeserializeBinary(data []byte) error {
-	s.Top = nil
-	s.Size = 0
-	offset := 0
+	return s.DecodeFrom(bytes.NewReader(data))
+}
 
-	for offset < len(data) {
-		// Проверяем, достаточно ли байт для чтения длины строки
-		if offset+4 > len(data) {
-			return fmt.Errorf("недостаточно данных для чтения длины строки")
-		}
+// EncodeTo записывает стек в w в бинарном формате, начиная с вершины (Public)
+func (s *Stack) EncodeTo(w io.Writer) error {
+	return encodeStrings(w, nodeValues(s.Top))
+}
 
-		// Читаем длину строки
-		length := binary.LittleEndian.Uint32(data[offset : offset+4])
-		offset += 4
+// DecodeFrom читает стек из r в бинарном формате; элементы помещаются на стек
+// в порядке чтения. При ошибке стек не меняется (Public)
+func (s *Stack) DecodeFrom(r io.Reader) error {
+	restored := NewStack()
+	if err := decodeStrings(r, func(value string) bool {
+		restored.Push(value)
+		return true
+	}); err != nil {
+		return err
+	}
+	*s = *restored
+	return nil
+}
 
-		// Проверяем, достаточно ли байт для чтения строки
-		if offset+int(length) > len(data) {
-			return fmt.Errorf("недостаточно данных для чтения строки")
-		}
+// MarshalBinary реализует encoding.BinaryMarshaler (Public)
+func (s *Stack) MarshalBinary() ([]byte, error) {
+	return s.SerializeBinary()
+}
+
+// UnmarshalBinary реализует encoding.BinaryUnmarshaler (Public)
+func (s *Stack) UnmarshalBinary(data []byte) error {
+	return s.DeserializeBinary(data)
+}
+
+// MarshalText реализует encoding.TextMarshaler; текстовый формат - JSON (Public)
+func (s *Stack) MarshalText() ([]byte, error) {
+	text, err := s.SerializeText()
+	return []byte(text), err
+}
+
+// UnmarshalText реализует encoding.TextUnmarshaler (Public)
+func (s *Stack) UnmarshalText(data []byte) error {
+	return s.DeserializeText(string(data))
+}
+
+// MarshalJSON реализует json.Marshaler (Public)
+func (s *Stack) MarshalJSON() ([]byte, error) {
+	return s.MarshalText()
+}
 
-		// Читаем строку
-		strData := string(data[offset : offset+int(length)])
-		offset += int(length)
-		s.Push(strData)
+// UnmarshalJSON реализует json.Unmarshaler (Public)
+func (s *Stack) UnmarshalJSON(data []byte) error {
+	return s.UnmarshalText(data)
+}
+
+// portable возвращает переносимое представление стека: массив строк, как в SerializeText (Private)
+func (s *Stack) portable() any {
+	return portableStrings(nodeValues(s.Top))
+}
+
+// setPortable восстанавливает стек из переносимого представления; при ошибке стек не меняется (Private)
+func (s *Stack) setPortable(value any) error {
+	values, err := stringsFromPortable(value)
+	if err != nil {
+		return err
+	}
+	restored := NewStack()
+	for i := len(values) - 1; i >= 0; i-- {
+		restored.Push(values[i])
+	}
+	*s = *restored
+	return nil
+}
+
+// SerializeMsgPack сериализует стек в MessagePack (Public)
+func (s *Stack) SerializeMsgPack() ([]byte, error) {
+	return serializeMsgPack(s)
+}
+
+// DeserializeMsgPack десериализует стек из MessagePack (Public)
+func (s *Stack) DeserializeMsgPack(data []byte) error {
+	return deserializeMsgPack(s, data)
+}
+
+// SerializeCBOR сериализует стек в CBOR (Public)
+func (s *Stack) SerializeCBOR() ([]byte, error) {
+	return serializeCBOR(s)
+}
+
+// DeserializeCBOR десериализует стек из CBOR (Public)
+func (s *Stack) DeserializeCBOR(data []byte) error {
+	return deserializeCBOR(s, data)
+}
+
+// SerializeSnapshot сериализует стек в снимок с заголовком, типом и контрольной суммой
+func (s *Stack) SerializeSnapshot() ([]byte, error) {
+	payload, err := s.SerializeBinary()
+	if err != nil {
+		return nil, err
 	}
+	return encodeSnapshot(SnapshotStack, s.Size, payload), nil
+}
 
+// DeserializeSnapshot восстанавливает стек из снимка. Снимок другой структуры,
+// другой версии или с неверной контрольной суммой отклоняется без изменения данных.
+func (s *Stack) DeserializeSnapshot(data []byte) error {
+	count, payload, err := decodeSnapshot(data, SnapshotStack)
+	if err != nil {
+		return err
+	}
+	temp := NewStack()
+	if err := temp.DeserializeBinary(payload); err != nil {
+		return err
+	}
+	// SerializeBinary пишет элементы от вершины, а DeserializeBinary кладет их
+	// на стек по порядку, поэтому для точного восстановления порядок обращается
+	temp.reverse()
+	if err := checkSnapshotCount(SnapshotStack, temp.Size, count); err != nil {
+		return err
+	}
+	*s = *temp
 	return nil
 }
+
+// reverse меняет порядок элементов стека на обратный (Private)
+func (s *Stack) reverse() {
+	var reversed *Node
+	for current := s.Top; current != nil; {
+		next := current.Next
+		current.Next = reversed
+		reversed = current
+		current = next
+	}
+	s.Top = reversed
+}
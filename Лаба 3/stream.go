@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"strings"
+)
+
+// Бинарный формат списочных структур - последовательность строк, перед каждой
+// строкой ее длина (4 байта little-endian). Функции ниже читают и пишут такие
+// строки по одной, не собирая весь результат в памяти.
+
+// maxStringLength - наибольшая допустимая длина строки в бинарном формате.
+// Длина больше этой считается повреждением данных, а не поводом выделять память.
+const maxStringLength = 64 << 20
+
+// encodeStrings записывает строки в w в бинарном формате через буфер фиксированного размера
+func encodeStrings(w io.Writer, values iter.Seq[string]) error {
+	writer := bufio.NewWriter(w)
+	var length [4]byte
+	for value := range values {
+		binary.LittleEndian.PutUint32(length[:], uint32(len(value)))
+		if _, err := writer.Write(length[:]); err != nil {
+			return fmt.Errorf("ошибка записи: %v", err)
+		}
+		if _, err := writer.WriteString(value); err != nil {
+			return fmt.Errorf("ошибка записи: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("ошибка записи: %v", err)
+	}
+	return nil
+}
+
+// decodeStrings читает строки из r до конца потока и передает их в add.
+// Если add возвращает false, чтение прекращается. Память под строку растет
+// по мере чтения, поэтому поврежденная длина не приводит к большому выделению.
+func decodeStrings(r io.Reader, add func(value string) bool) error {
+	reader := bufio.NewReader(r)
+	var length [4]byte
+	for {
+		if _, err := io.ReadFull(reader, length[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return fmt.Errorf("недостаточно данных для чтения длины строки")
+			}
+			return fmt.Errorf("ошибка чтения: %v", err)
+		}
+		size := int64(binary.LittleEndian.Uint32(length[:]))
+		if size > maxStringLength {
+			return fmt.Errorf("длина строки %d превышает допустимую %d", size, maxStringLength)
+		}
+		var value strings.Builder
+		if copied, err := io.CopyN(&value, reader, size); err != nil {
+			if copied < size && errors.Is(err, io.EOF) {
+				return fmt.Errorf("недостаточно данных для чтения строки")
+			}
+			return fmt.Errorf("ошибка чтения: %v", err)
+		}
+		if !add(value.String()) {
+			return nil
+		}
+	}
+}
+
+// Бинарный формат AVL- и красно-черного дерева - значения в порядке обхода по
+// уровням, каждое 4 байта little-endian со знаком.
+
+// encodeInt32s записывает значения в w по 4 байта через буфер фиксированного размера.
+// Значение, не помещающееся в 4 байта, возвращает ошибку, а не записывается усеченным.
+func encodeInt32s(w io.Writer, values iter.Seq[int]) error {
+	writer := bufio.NewWriter(w)
+	var buffer [4]byte
+	for value := range values {
+		if value < math.MinInt32 || value > math.MaxInt32 {
+			return fmt.Errorf("значение %d не помещается в 4 байта бинарного формата", value)
+		}
+		binary.LittleEndian.PutUint32(buffer[:], uint32(int32(value)))
+		if _, err := writer.Write(buffer[:]); err != nil {
+			return fmt.Errorf("ошибка записи: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("ошибка записи: %v", err)
+	}
+	return nil
+}
+
+// decodeInt32s читает значения по 4 байта из r до конца потока и передает их в add.
+// Ошибка add прекращает чтение и возвращается вызывающему.
+func decodeInt32s(r io.Reader, add func(value int) error) error {
+	reader := bufio.NewReader(r)
+	var buffer [4]byte
+	for {
+		if _, err := io.ReadFull(reader, buffer[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return fmt.Errorf("недостаточно данных для чтения узла")
+			}
+			return fmt.Errorf("ошибка чтения: %v", err)
+		}
+		if err := add(int(int32(binary.LittleEndian.Uint32(buffer[:])))); err != nil {
+			return err
+		}
+	}
+}
+
+// nodeValues возвращает итератор по значениям узлов, начиная с head
+func nodeValues(head *Node) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for current := head; current != nil; current = current.Next {
+			if !yield(current.Data) {
+				return
+			}
+		}
+	}
+}
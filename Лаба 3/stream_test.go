@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// streamer - структура с потоковой сериализацией
+type streamer interface {
+	EncodeTo(w io.Writer) error
+	DecodeFrom(r io.Reader) error
+	SerializeBinary() ([]byte, error)
+}
+
+// Проверка соответствия стандартным интерфейсам на этапе компиляции
+var (
+	_ encoding.BinaryMarshaler   = (*Array)(nil)
+	_ encoding.BinaryUnmarshaler = (*Stack)(nil)
+	_ encoding.TextMarshaler     = (*Queue)(nil)
+	_ encoding.TextUnmarshaler   = (*SinglyLinkedList)(nil)
+	_ json.Marshaler             = (*DoublyLinkedList)(nil)
+	_ json.Unmarshaler           = (*HashTable)(nil)
+	_ json.Marshaler             = (*BinaryTree)(nil)
+	_ encoding.BinaryMarshaler   = (*AVLTree)(nil)
+	_ encoding.TextUnmarshaler   = (*RedBlackTree)(nil)
+	_ json.Marshaler             = (*PriorityQueue)(nil)
+	_ json.Unmarshaler           = (*Cache)(nil)
+)
+
+// TestEncodeToMatchesSerializeBinary проверяет, что потоковый формат совпадает с SerializeBinary
+// и восстанавливается через DecodeFrom
+func TestEncodeToMatchesSerializeBinary(t *testing.T) {
+	for _, fixture := range snapshotFixtures() {
+		filled := fixture.filled.(streamer)
+		expected, _ := filled.SerializeBinary()
+		var buffer bytes.Buffer
+		if err := filled.EncodeTo(&buffer); err != nil {
+			t.Fatalf("%s: EncodeTo вернул ошибку: %v", fixture.kind, err)
+		}
+		if !bytes.Equal(buffer.Bytes(), expected) {
+			t.Errorf("%s: EncodeTo = %v, SerializeBinary = %v", fixture.kind, buffer.Bytes(), expected)
+		}
+
+		restored := fixture.empty().(streamer)
+		if err := restored.DecodeFrom(&buffer); err != nil {
+			t.Fatalf("%s: DecodeFrom вернул ошибку: %v", fixture.kind, err)
+		}
+		// Стек в бинарном формате записывается с вершины, а читается в порядке записи
+		if fixture.kind == SnapshotStack {
+			restored.(*Stack).reverse()
+		}
+		if actual, _ := restored.SerializeBinary(); !bytes.Equal(actual, expected) {
+			t.Errorf("%s: после DecodeFrom получено %v, ожидалось %v", fixture.kind, actual, expected)
+		}
+	}
+}
+
+// TestDecodeFromKeepsStateOnError проверяет, что при ошибке чтения структура не меняется
+func TestDecodeFromKeepsStateOnError(t *testing.T) {
+	queue := NewQueue()
+	queue.Push("keep")
+	truncated := []byte{5, 0, 0, 0, 'a', 'b'}
+	if err := queue.DecodeFrom(bytes.NewReader(truncated)); err == nil {
+		t.Errorf("Ожидалась ошибка для обрезанной строки")
+	}
+	if queue.Size != 1 || queue.Front.Data != "keep" {
+		t.Errorf("Очередь изменена при ошибке")
+	}
+
+	hashTable := NewHashTable(4)
+	hashTable.HSet("k", "v")
+	if err := hashTable.DecodeFrom(bytes.NewReader([]byte{1, 0, 0, 0, 'x'})); err == nil {
+		t.Errorf("Ожидалась ошибка для ключа без значения")
+	}
+	if hashTable.Size() != 1 {
+		t.Errorf("Хэш-таблица изменена при ошибке")
+	}
+
+	tree := NewBinaryTree()
+	tree.Insert(7)
+	if err := tree.DecodeFrom(bytes.NewReader([]byte{1, 0, 2, 2, 0})); err == nil {
+		t.Errorf("Ожидалась ошибка для неполного дерева")
+	}
+	if tree.Root == nil || tree.Root.Digit != 7 {
+		t.Errorf("Дерево изменено при ошибке")
+	}
+}
+
+// failingWriter возвращает ошибку при каждой записи
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("диск заполнен")
+}
+
+// TestEncodeToWriterError проверяет, что ошибка записи возвращается вызывающему
+func TestEncodeToWriterError(t *testing.T) {
+	for _, fixture := range snapshotFixtures() {
+		if err := fixture.filled.(streamer).EncodeTo(failingWriter{}); err == nil {
+			t.Errorf("%s: ожидалась ошибка записи", fixture.kind)
+		}
+	}
+}
+
+// TestStreamBoundedMemory проверяет, что потоковые функции не выделяют память
+// пропорционально размеру данных или заявленной длине
+func TestStreamBoundedMemory(t *testing.T) {
+	list := NewSinglyLinkedList()
+	for i := 0; i < 100000; i++ {
+		list.AddToHead(strings.Repeat("x", 20))
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	before := stats.TotalAlloc
+	counter := &countingWriter{}
+	if err := list.EncodeTo(counter); err != nil {
+		t.Fatalf("EncodeTo вернул ошибку: %v", err)
+	}
+	runtime.ReadMemStats(&stats)
+	if allocated := stats.TotalAlloc - before; allocated > 1<<16 {
+		t.Errorf("EncodeTo выделил %d байт при выводе %d байт", allocated, counter.n)
+	}
+
+	// Заявленная длина строки 4 ГБ при нескольких байтах данных
+	runtime.ReadMemStats(&stats)
+	before = stats.TotalAlloc
+	if err := NewStack().DecodeFrom(bytes.NewReader([]byte{0xFF, 0xFF, 0xFF, 0xFF, 'a'})); err == nil {
+		t.Errorf("Ожидалась ошибка для поврежденной длины")
+	}
+	runtime.ReadMemStats(&stats)
+	if allocated := stats.TotalAlloc - before; allocated > 1<<16 {
+		t.Errorf("DecodeFrom выделил %d байт для поврежденной длины", allocated)
+	}
+}
+
+// countingWriter подсчитывает записанные байты, не сохраняя их
+type countingWriter struct {
+	n int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	return len(p), nil
+}
+
+// TestMarshalers проверяет работу структур со стандартными пакетами кодирования
+func TestMarshalers(t *testing.T) {
+	type document struct {
+		Array *Array
+		Stack *Stack
+		Hash  *HashTable
+		Tree  *BinaryTree
+	}
+	source := document{Array: NewArray(3), Stack: NewStack(), Hash: NewHashTable(4), Tree: NewBinarySearchTree()}
+	source.Array.AddToTheEnd("a")
+	source.Stack.Push("s")
+	source.Hash.HSet("k", "v")
+	source.Tree.Insert(2)
+	source.Tree.Insert(1)
+
+	data, err := json.Marshal(source)
+	if err != nil {
+		t.Fatalf("json.Marshal вернул ошибку: %v", err)
+	}
+	expected := `{"Array":["a"],"Stack":["s"],"Hash":{"k":"v"},"Tree":{"Root":{"Digit":2,"Left":{"Digit":1,"Left":null,"Right":null},"Right":null},"Ordered":true}}`
+	if string(data) != expected {
+		t.Errorf("json.Marshal = %s; want %s", data, expected)
+	}
+
+	restored := document{Array: NewArray(3), Stack: NewStack(), Hash: NewHashTable(4), Tree: NewBinaryTree()}
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("json.Unmarshal вернул ошибку: %v", err)
+	}
+	if restored.Array.Get(0) != "a" || restored.Stack.Top.Data != "s" || !restored.Tree.Ordered || restored.Tree.Rank(2) != 2 {
+		t.Errorf("json.Unmarshal восстановил структуры неверно: %+v", restored)
+	}
+
+	// Нулевое значение хэш-таблицы получает таблицу при разборе
+	var zero HashTable
+	if err := zero.UnmarshalBinary([]byte{1, 0, 0, 0, 'k', 1, 0, 0, 0, 'v'}); err != nil || zero.Size() != 1 {
+		t.Errorf("UnmarshalBinary нулевой хэш-таблицы: %v, размер %d", err, zero.Size())
+	}
+
+	text, err := source.Stack.MarshalText()
+	if err != nil || string(text) != `["s"]` {
+		t.Errorf("MarshalText = %s, %v", text, err)
+	}
+	binaryData, _ := source.Tree.MarshalBinary()
+	var tree BinaryTree
+	if err := tree.UnmarshalBinary(binaryData); err != nil || tree.LevelOrderString() != "[2,1]" {
+		t.Errorf("UnmarshalBinary дерева: %v, %s", err, tree.LevelOrderString())
+	}
+}
+
+// TestStreamTreesHeapCache проверяет потоковый формат и стандартные интерфейсы
+// AVL- и красно-черного дерева, очереди с приоритетом и кэша
+func TestStreamTreesHeapCache(t *testing.T) {
+	s := filledStructures()
+	fixtures := []struct {
+		name   string
+		filled streamer
+		empty  func() streamer
+	}{
+		{"AVL", s.avlTree, func() streamer { return NewAVLTree() }},
+		{"RB", s.rbTree, func() streamer { return NewRedBlackTree() }},
+		{"PQ", s.pq, func() streamer { return NewPriorityQueue(MaxHeap) }},
+		{"Cache", s.cache, func() streamer { return NewCache(1, PolicyLRU) }},
+	}
+	for _, fixture := range fixtures {
+		expected, err := fixture.filled.SerializeBinary()
+		if err != nil {
+			t.Fatalf("%s: SerializeBinary вернул ошибку: %v", fixture.name, err)
+		}
+		var buffer bytes.Buffer
+		if err := fixture.filled.EncodeTo(&buffer); err != nil || !bytes.Equal(buffer.Bytes(), expected) {
+			t.Errorf("%s: EncodeTo = %v, %v; want %v", fixture.name, buffer.Bytes(), err, expected)
+		}
+		restored := fixture.empty()
+		if err := restored.DecodeFrom(&buffer); err != nil {
+			t.Fatalf("%s: DecodeFrom вернул ошибку: %v", fixture.name, err)
+		}
+		if actual, _ := restored.SerializeBinary(); !bytes.Equal(actual, expected) {
+			t.Errorf("%s: после DecodeFrom получено %v, ожидалось %v", fixture.name, actual, expected)
+		}
+		if err := fixture.filled.EncodeTo(failingWriter{}); err == nil {
+			t.Errorf("%s: ожидалась ошибка записи", fixture.name)
+		}
+
+		text, err := json.Marshal(fixture.filled)
+		if err != nil {
+			t.Fatalf("%s: json.Marshal вернул ошибку: %v", fixture.name, err)
+		}
+		fromJSON := fixture.empty()
+		if err := json.Unmarshal(text, fromJSON); err != nil {
+			t.Fatalf("%s: json.Unmarshal(%s) вернул ошибку: %v", fixture.name, text, err)
+		}
+		if actual, _ := fromJSON.SerializeBinary(); !bytes.Equal(actual, expected) {
+			t.Errorf("%s: после json.Unmarshal(%s) структура отличается", fixture.name, text)
+		}
+	}
+
+	// Параметры кэша и частоты восстанавливаются вместе с элементами
+	data, _ := s.cache.MarshalBinary()
+	var restored Cache
+	if err := restored.UnmarshalBinary(data); err != nil || restored.Capacity != 3 || restored.Policy != PolicyLFU || restored.Frequency("k1") != 2 || restored.Hits != 1 {
+		t.Errorf("UnmarshalBinary кэша: %v, емкость %d, стратегия %s, частота %d, попадания %d",
+			err, restored.Capacity, restored.Policy, restored.Frequency("k1"), restored.Hits)
+	}
+}
+
+// TestStreamTreesHeapCacheErrors проверяет отказ при некорректных данных без изменения структуры
+func TestStreamTreesHeapCacheErrors(t *testing.T) {
+	avl := NewAVLTree()
+	avl.Insert(1)
+	duplicate := []byte{2, 0, 0, 0, 2, 0, 0, 0}
+	if err := avl.DecodeFrom(bytes.NewReader(duplicate)); err == nil || avl.Root.Digit != 1 {
+		t.Errorf("DecodeFrom с повтором: %v, корень %d", err, avl.Root.Digit)
+	}
+	if err := NewRedBlackTree().DeserializeBinary([]byte{1, 0, 0}); err == nil {
+		t.Errorf("Ожидалась ошибка для неполного узла")
+	}
+	big := NewAVLTree()
+	big.Insert(math.MaxInt32 + 1)
+	if _, err := big.SerializeBinary(); err == nil {
+		t.Errorf("Ожидалась ошибка для значения вне int32")
+	}
+
+	pq := NewPriorityQueue(MinHeap)
+	pq.Push("keep", 1)
+	var buffer bytes.Buffer
+	encodeStrings(&buffer, slices.Values([]string{"max", "a", "x"}))
+	if err := pq.DecodeFrom(&buffer); err == nil || pq.Len() != 1 || pq.Kind != MinHeap {
+		t.Errorf("DecodeFrom с неверным приоритетом: %v, длина %d", err, pq.Len())
+	}
+	encodeStrings(&buffer, slices.Values([]string{"max", "a"}))
+	if err := pq.DecodeFrom(&buffer); err == nil {
+		t.Errorf("Ожидалась ошибка для значения без приоритета")
+	}
+
+	cache := NewCache(1, PolicyLRU)
+	cache.Set("keep", "v")
+	encodeStrings(&buffer, slices.Values([]string{"lru", "1", "0", "0", "0", "a", "1", "1", "b", "2", "1"}))
+	if err := cache.DecodeFrom(&buffer); err == nil || !strings.Contains(err.Error(), "емкость") {
+		t.Errorf("Ожидалась ошибка превышения емкости, получено %v", err)
+	}
+	if value, ok := cache.Peek("keep"); !ok || value != "v" {
+		t.Errorf("Кэш изменен при ошибке")
+	}
+	if err := cache.UnmarshalJSON([]byte(`{"Policy":"lru","Capacity":1,"Items":[{"Key":"a","Freq":1},{"Key":"b","Freq":1}]}`)); err == nil {
+		t.Errorf("Ожидалась ошибка превышения емкости в JSON")
+	}
+}
@@ -0,0 +1,720 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TransferFormat определяет формат обмена данными для EXPORT и IMPORT: табличный
+// или двоичный формат со структурой целиком (см. переносимое представление в portable.go)
+type TransferFormat int
+
+const (
+	FormatCSV     TransferFormat = iota // Значения через запятую со строкой заголовка
+	FormatTSV                           // Значения через табуляцию со строкой заголовка
+	FormatNDJSON                        // JSON-объект на каждой строке
+	FormatMsgPack                       // Структура целиком в MessagePack
+	FormatCBOR                          // Структура целиком в CBOR
+)
+
+// String возвращает название формата
+func (f TransferFormat) String() string {
+	switch f {
+	case FormatTSV:
+		return "tsv"
+	case FormatNDJSON:
+		return "ndjson"
+	case FormatMsgPack:
+		return "msgpack"
+	case FormatCBOR:
+		return "cbor"
+	}
+	return "csv"
+}
+
+// ParseTransferFormat разбирает название формата обмена
+func ParseTransferFormat(name string) (TransferFormat, error) {
+	switch strings.ToLower(name) {
+	case "csv":
+		return FormatCSV, nil
+	case "tsv":
+		return FormatTSV, nil
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	case "msgpack":
+		return FormatMsgPack, nil
+	case "cbor":
+		return FormatCBOR, nil
+	}
+	return FormatCSV, fmt.Errorf("неизвестный формат: %s (доступны csv, tsv, ndjson, msgpack, cbor)", name)
+}
+
+// transferColumn описывает столбец таблицы; числовые столбцы в NDJSON
+// записываются числами JSON, остальные - строками
+type transferColumn struct {
+	name    string
+	numeric bool
+}
+
+// transferTarget связывает структуру с табличным представлением: строки
+// выгружаются по одной, а загрузка идет в новую структуру, которая заменяет
+// текущую только после успешного чтения всего файла. Строки читаются из
+// структуры при каждом обходе, поэтому после загрузки выдают новое содержимое.
+type transferTarget struct {
+	columns []transferColumn
+	rows    iter.Seq[[]string]
+	// load создает пустую структуру и возвращает функции добавления строки и замены текущей структуры
+	load func() (add func(row []string) error, commit func())
+	// portable - структура для форматов MessagePack и CBOR; nil, если у нее нет переносимого представления
+	portable portableCodec
+	// exportErr - причина, по которой структуру нельзя выгрузить так, чтобы IMPORT ее прочитал
+	exportErr error
+}
+
+// transferStructures - имена структур для EXPORT и IMPORT (совпадают с записями SAVEALL)
+// и префиксы их команд, по которым выбирается файл из --file
+var transferStructures = map[string]string{
+	"array": "M", "stack": "S", "queue": "Q", "singly_list": "LS", "doubly_list": "LD",
+	"hash_table": "H", "binary_tree": "T", "avl_tree": "A", "rb_tree": "R",
+	"priority_queue": "PQ", "cache": "C",
+}
+
+// transferCommands - команды обмена данными с внешними файлами
+var transferCommands = map[string]bool{"EXPORT": true, "IMPORT": true}
+
+// transferTargetFor возвращает табличное представление структуры по имени
+func transferTargetFor(name string, array *Array, stack *Stack, queue *Queue, singlyList *SinglyLinkedList, doublyList *DoublyLinkedList, hashTable *HashTable, cbTree *BinaryTree, stringTree *StringTree, avlTree *AVLTree, rbTree *RedBlackTree, cache *Cache, priorityQueue *PriorityQueue) (*transferTarget, error) {
+	values := []transferColumn{{name: "value"}}
+	numbers := []transferColumn{{name: "value", numeric: true}}
+	switch name {
+	case "array":
+		return &transferTarget{
+			columns: values,
+			rows: func(yield func([]string) bool) {
+				for i := 0; i < array.size; i++ {
+					if !yield([]string{array.data[i]}) {
+						return
+					}
+				}
+			},
+			load: func() (func([]string) error, func()) {
+				temp := NewArray(array.maxCapacity)
+				add := func(row []string) error {
+					if temp.size >= temp.maxCapacity {
+						return fmt.Errorf("массив заполнен: емкость %d", temp.maxCapacity)
+					}
+					temp.AddToTheEnd(row[0])
+					return nil
+				}
+				return add, func() { *array = *temp }
+			},
+			portable: array,
+		}, nil
+	case "stack":
+		// Стек выгружается от дна к вершине, в порядке добавления элементов
+		return &transferTarget{
+			columns: values,
+			rows: func(yield func([]string) bool) {
+				var nodes []*Node
+				for current := stack.Top; current != nil; current = current.Next {
+					nodes = append(nodes, current)
+				}
+				for i := len(nodes) - 1; i >= 0; i-- {
+					if !yield([]string{nodes[i].Data}) {
+						return
+					}
+				}
+			},
+			load: func() (func([]string) error, func()) {
+				temp := NewStack()
+				add := func(row []string) error {
+					temp.Push(row[0])
+					return nil
+				}
+				return add, func() { *stack = *temp }
+			},
+			portable: stack,
+		}, nil
+	case "queue":
+		return &transferTarget{
+			columns: values,
+			rows: func(yield func([]string) bool) {
+				stringRows(nodeValues(queue.Front))(yield)
+			},
+			load: func() (func([]string) error, func()) {
+				temp := NewQueue()
+				add := func(row []string) error {
+					temp.Push(row[0])
+					return nil
+				}
+				return add, func() { *queue = *temp }
+			},
+			portable: queue,
+		}, nil
+	case "singly_list":
+		return &transferTarget{
+			columns: values,
+			rows: func(yield func([]string) bool) {
+				stringRows(nodeValues(singlyList.Head))(yield)
+			},
+			load: func() (func([]string) error, func()) {
+				temp := NewSinglyLinkedList()
+				add := func(row []string) error {
+					temp.AddToTail(row[0])
+					return nil
+				}
+				return add, func() { *singlyList = *temp }
+			},
+			portable: singlyList,
+		}, nil
+	case "doubly_list":
+		return &transferTarget{
+			columns: values,
+			rows: func(yield func([]string) bool) {
+				for current := doublyList.Head; current != nil; current = current.Next {
+					if !yield([]string{current.Data}) {
+						return
+					}
+				}
+			},
+			load: func() (func([]string) error, func()) {
+				temp := NewDoublyLinkedList()
+				add := func(row []string) error {
+					temp.AddToTail(row[0])
+					return nil
+				}
+				return add, func() { *doublyList = *temp }
+			},
+			portable: doublyList,
+		}, nil
+	case "hash_table":
+		return &transferTarget{
+			columns: []transferColumn{{name: "key"}, {name: "value"}},
+			rows: func(yield func([]string) bool) {
+				for _, head := range hashTable.Table {
+					for current := head; current != nil; current = current.Next {
+						if !yield([]string{current.Key, current.Value}) {
+							return
+						}
+					}
+				}
+			},
+			load: func() (func([]string) error, func()) {
+				temp := NewHashTable(hashTable.Capacity)
+				add := func(row []string) error {
+					if temp.findNodeByKey(row[0]) != nil {
+						return fmt.Errorf("ключ %q повторяется", row[0])
+					}
+					temp.HSet(row[0], row[1])
+					return nil
+				}
+				return add, func() { *hashTable = *temp }
+			},
+			portable: hashTable,
+		}, nil
+	case "binary_tree":
+		// Неполное дерево выгружается с пропусками null, чтобы загрузка восстановила форму
+		target := &transferTarget{
+			columns: numbers,
+			rows:    stringRows(cbTree.levelOrderLines()),
+			load: func() (func([]string) error, func()) {
+				var builder levelOrderBuilder
+				add := func(row []string) error {
+					if strings.TrimSpace(row[0]) == "null" {
+						return builder.add(nil)
+					}
+					value, err := parseTransferInt(row[0])
+					if err != nil {
+						return err
+					}
+					return builder.add(&value)
+				}
+				return add, func() {
+					*cbTree = *builder.tree(cbTree.Ordered)
+					stringTree.Clear()
+				}
+			},
+			portable: transferTreePortable{cbTree, stringTree},
+		}
+		// Загружается всегда целочисленное дерево, поэтому дерево со строковыми
+		// ключами не выгружается: файл нельзя было бы загрузить обратно
+		if stringTree.Root != nil {
+			target.exportErr = fmt.Errorf("дерево со строковыми ключами нельзя выгрузить: IMPORT загружает только целочисленное дерево")
+		}
+		return target, nil
+	case "avl_tree":
+		return &transferTarget{
+			columns: numbers,
+			rows: func(yield func([]string) bool) {
+				intRows(avlTree.levelOrder())(yield)
+			},
+			load: func() (func([]string) error, func()) {
+				temp := NewAVLTree()
+				add := func(row []string) error {
+					value, err := parseTransferInt(row[0])
+					if err != nil {
+						return err
+					}
+					if temp.FindValue(value) {
+						return fmt.Errorf("значение %d повторяется", value)
+					}
+					temp.Insert(value)
+					return nil
+				}
+				return add, func() { avlTree.Root = temp.Root }
+			},
+			portable: avlTree,
+		}, nil
+	case "rb_tree":
+		return &transferTarget{
+			columns: numbers,
+			rows: func(yield func([]string) bool) {
+				intRows(rbTree.levelOrder())(yield)
+			},
+			load: func() (func([]string) error, func()) {
+				temp := NewRedBlackTree()
+				add := func(row []string) error {
+					value, err := parseTransferInt(row[0])
+					if err != nil {
+						return err
+					}
+					if temp.FindValue(value) {
+						return fmt.Errorf("значение %d повторяется", value)
+					}
+					temp.Insert(value)
+					return nil
+				}
+				return add, func() { rbTree.Root = temp.Root }
+			},
+			portable: rbTree,
+		}, nil
+	case "priority_queue":
+		return &transferTarget{
+			columns: []transferColumn{{name: "value"}, {name: "priority", numeric: true}},
+			rows: func(yield func([]string) bool) {
+				for _, item := range priorityQueue.Items() {
+					if !yield([]string{item.Value, strconv.Itoa(item.Priority)}) {
+						return
+					}
+				}
+			},
+			load: func() (func([]string) error, func()) {
+				temp := NewPriorityQueue(priorityQueue.Kind)
+				add := func(row []string) error {
+					priority, err := parseTransferInt(row[1])
+					if err != nil {
+						return err
+					}
+					if _, ok := temp.Priority(row[0]); ok {
+						return fmt.Errorf("значение %q повторяется", row[0])
+					}
+					temp.Push(row[0], priority)
+					return nil
+				}
+				return add, func() { *priorityQueue = *temp }
+			},
+		}, nil
+	case "cache":
+		// Кэш выгружается от первого кандидата на вытеснение к последнему вместе с частотой
+		return &transferTarget{
+			columns: []transferColumn{{name: "key"}, {name: "value"}, {name: "frequency", numeric: true}},
+			rows: func(yield func([]string) bool) {
+				for _, key := range cache.Keys() {
+					value, _ := cache.Peek(key)
+					if !yield([]string{key, value, strconv.Itoa(cache.Frequency(key))}) {
+						return
+					}
+				}
+			},
+			load: func() (func([]string) error, func()) {
+				temp := NewCache(cache.Capacity, cache.Policy)
+				add := func(row []string) error {
+					if temp.Len() >= temp.Capacity {
+						return fmt.Errorf("кэш заполнен: емкость %d", temp.Capacity)
+					}
+					frequency, err := parseTransferInt(row[2])
+					if err != nil {
+						return err
+					}
+					if frequency < 1 {
+						return fmt.Errorf("частота должна быть положительной: %d", frequency)
+					}
+					if temp.values.findNodeByKey(row[0]) != nil {
+						return fmt.Errorf("ключ %q повторяется", row[0])
+					}
+					temp.restore(row[0], row[1], frequency)
+					return nil
+				}
+				return add, func() {
+					temp.Hits, temp.Misses, temp.Evictions, temp.OnEvict = cache.Hits, cache.Misses, cache.Evictions, cache.OnEvict
+					*cache = *temp
+				}
+			},
+		}, nil
+	}
+	names := make([]string, 0, len(transferStructures))
+	for structure := range transferStructures {
+		names = append(names, structure)
+	}
+	slices.Sort(names)
+	return nil, fmt.Errorf("неизвестная структура %s (доступны: %s)", name, strings.Join(names, ", "))
+}
+
+// transferTreePortable загружает бинарное дерево из MessagePack и CBOR с сохранением
+// режима дерева (см. BinaryTree.Replace) и очищает дерево со строковыми ключами
+type transferTreePortable struct {
+	tree       *BinaryTree
+	stringTree *StringTree
+}
+
+// portable и setPortable реализуют portableCodec
+func (p transferTreePortable) portable() any {
+	return p.tree.portable()
+}
+
+func (p transferTreePortable) setPortable(value any) error {
+	var temp BinaryTree
+	if err := temp.setPortable(value); err != nil {
+		return err
+	}
+	if err := p.tree.Replace(&temp); err != nil {
+		return err
+	}
+	p.stringTree.Clear()
+	return nil
+}
+
+// stringRows превращает последовательность значений в строки таблицы из одного столбца
+func stringRows(values iter.Seq[string]) iter.Seq[[]string] {
+	return func(yield func([]string) bool) {
+		for value := range values {
+			if !yield([]string{value}) {
+				return
+			}
+		}
+	}
+}
+
+// intRows превращает последовательность чисел в строки таблицы из одного столбца
+func intRows(values iter.Seq[int]) iter.Seq[[]string] {
+	return func(yield func([]string) bool) {
+		for value := range values {
+			if !yield([]string{strconv.Itoa(value)}) {
+				return
+			}
+		}
+	}
+}
+
+// parseTransferInt разбирает значение числового столбца
+func parseTransferInt(text string) (int, error) {
+	value, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil {
+		return 0, fmt.Errorf("значение %q не является целым числом", text)
+	}
+	return value, nil
+}
+
+// Export записывает строки структуры в w и возвращает их количество
+func (t *transferTarget) Export(w io.Writer, format TransferFormat) (int, error) {
+	if t.exportErr != nil {
+		return 0, t.exportErr
+	}
+	switch format {
+	case FormatNDJSON:
+		return t.exportNDJSON(w)
+	case FormatMsgPack, FormatCBOR:
+		return t.exportPortable(w, format)
+	}
+	buffered := bufio.NewWriter(w)
+	writer := csv.NewWriter(buffered)
+	if format == FormatTSV {
+		writer.Comma = '\t'
+	}
+	header := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		header[i] = column.name
+	}
+	if err := writer.Write(header); err != nil {
+		return 0, fmt.Errorf("ошибка записи: %v", err)
+	}
+	count := 0
+	for row := range t.rows {
+		var err error
+		if len(row) == 1 && row[0] == "" {
+			// csv.Writer записал бы пустую строку файла, а при чтении она пропускается
+			writer.Flush()
+			_, err = buffered.WriteString("\"\"\n")
+		} else {
+			err = writer.Write(row)
+		}
+		if err != nil {
+			return count, fmt.Errorf("ошибка записи: %v", err)
+		}
+		count++
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return count, fmt.Errorf("ошибка записи: %v", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return count, fmt.Errorf("ошибка записи: %v", err)
+	}
+	return count, nil
+}
+
+// exportNDJSON записывает каждую строку отдельным JSON-объектом со столбцами в порядке заголовка
+func (t *transferTarget) exportNDJSON(w io.Writer) (int, error) {
+	writer := bufio.NewWriter(w)
+	var line bytes.Buffer
+	count := 0
+	for row := range t.rows {
+		line.Reset()
+		line.WriteByte('{')
+		for i, column := range t.columns {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			writeJSONString(&line, column.name)
+			line.WriteByte(':')
+			if column.numeric {
+				line.WriteString(row[i])
+			} else {
+				writeJSONString(&line, row[i])
+			}
+		}
+		line.WriteString("}\n")
+		if _, err := writer.Write(line.Bytes()); err != nil {
+			return count, fmt.Errorf("ошибка записи: %v", err)
+		}
+		count++
+	}
+	if err := writer.Flush(); err != nil {
+		return count, fmt.Errorf("ошибка записи: %v", err)
+	}
+	return count, nil
+}
+
+// exportPortable записывает структуру целиком в MessagePack или CBOR
+func (t *transferTarget) exportPortable(w io.Writer, format TransferFormat) (int, error) {
+	if t.portable == nil {
+		return 0, fmt.Errorf("формат %s недоступен для этой структуры", format)
+	}
+	encode := serializeMsgPack
+	if format == FormatCBOR {
+		encode = serializeCBOR
+	}
+	data, err := encode(t.portable)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return 0, fmt.Errorf("ошибка записи: %v", err)
+	}
+	return t.count(), nil
+}
+
+// count возвращает количество строк структуры
+func (t *transferTarget) count() int {
+	count := 0
+	for range t.rows {
+		count++
+	}
+	return count
+}
+
+// writeJSONString записывает строку JSON без экранирования символов HTML
+func writeJSONString(buffer *bytes.Buffer, value string) {
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	buffer.Truncate(buffer.Len() - 1) // Encode дописывает перевод строки
+}
+
+// Import читает строки из r в новую структуру и заменяет ею текущую.
+// Строки читаются по одной; при первой некорректной строке возвращается
+// ошибка с ее номером, а структура не меняется.
+func (t *transferTarget) Import(r io.Reader, format TransferFormat) (int, error) {
+	if format == FormatMsgPack || format == FormatCBOR {
+		return t.importPortable(r, format)
+	}
+	add, commit := t.load()
+	var count int
+	var err error
+	if format == FormatNDJSON {
+		count, err = t.importNDJSON(r, add)
+	} else {
+		count, err = t.importCSV(r, format, add)
+	}
+	if err != nil {
+		return 0, err
+	}
+	commit()
+	return count, nil
+}
+
+// importPortable читает структуру целиком из MessagePack или CBOR;
+// при ошибке структура не меняется
+func (t *transferTarget) importPortable(r io.Reader, format TransferFormat) (int, error) {
+	if t.portable == nil {
+		return 0, fmt.Errorf("формат %s недоступен для этой структуры", format)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения: %v", err)
+	}
+	if format == FormatCBOR {
+		err = deserializeCBOR(t.portable, data)
+	} else {
+		err = deserializeMsgPack(t.portable, data)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return t.count(), nil
+}
+
+// importCSV читает CSV или TSV: первая строка - заголовок со столбцами структуры
+func (t *transferTarget) importCSV(r io.Reader, format TransferFormat, add func([]string) error) (int, error) {
+	reader := csv.NewReader(bufio.NewReader(r))
+	if format == FormatTSV {
+		reader.Comma = '\t'
+	}
+	reader.FieldsPerRecord = len(t.columns)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return 0, fmt.Errorf("файл не содержит строки заголовка")
+	}
+	if err != nil {
+		return 0, csvError(err)
+	}
+	for i, column := range t.columns {
+		// Табличные редакторы добавляют метку порядка байтов в начало файла
+		name := strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+		if !strings.EqualFold(name, column.name) {
+			return 0, fmt.Errorf("строка 1: ожидался столбец %s, получено %q", column.name, header[i])
+		}
+	}
+
+	count := 0
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return 0, csvError(err)
+		}
+		if err := add(row); err != nil {
+			line, _ := reader.FieldPos(0)
+			return 0, fmt.Errorf("строка %d: %v", line, err)
+		}
+		count++
+	}
+}
+
+// csvError переводит ошибку разбора CSV в сообщение с номером строки
+func csvError(err error) error {
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) {
+		return fmt.Errorf("ошибка чтения: %v", err)
+	}
+	switch {
+	case errors.Is(parseErr.Err, csv.ErrFieldCount):
+		return fmt.Errorf("строка %d: неверное количество столбцов", parseErr.Line)
+	case errors.Is(parseErr.Err, csv.ErrQuote), errors.Is(parseErr.Err, csv.ErrBareQuote):
+		return fmt.Errorf("строка %d: некорректные кавычки", parseErr.Line)
+	}
+	return fmt.Errorf("строка %d: %v", parseErr.Line, parseErr.Err)
+}
+
+// importNDJSON читает по JSON-объекту из каждой непустой строки
+func (t *transferTarget) importNDJSON(r io.Reader, add func([]string) error) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxStringLength)
+	row := make([]string, len(t.columns))
+	count := 0
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if err := t.parseNDJSONRow(scanner.Bytes(), row); err != nil {
+			return 0, fmt.Errorf("строка %d: %v", line, err)
+		}
+		if err := add(row); err != nil {
+			return 0, fmt.Errorf("строка %d: %v", line, err)
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("ошибка чтения: %v", err)
+	}
+	return count, nil
+}
+
+// parseNDJSONRow разбирает JSON-объект строки в значения столбцов
+func (t *transferTarget) parseNDJSONRow(data []byte, row []string) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return fmt.Errorf("ожидался JSON-объект")
+	}
+	for i, column := range t.columns {
+		raw, ok := fields[column.name]
+		if !ok {
+			return fmt.Errorf("нет поля %q", column.name)
+		}
+		delete(fields, column.name)
+		if column.numeric && string(raw) == "null" {
+			row[i] = "null" // Пропуск в записи дерева по уровням
+		} else if column.numeric {
+			var number json.Number
+			if raw[0] == '"' || json.Unmarshal(raw, &number) != nil {
+				return fmt.Errorf("поле %q должно быть числом", column.name)
+			}
+			row[i] = number.String()
+		} else if raw[0] != '"' || json.Unmarshal(raw, &row[i]) != nil {
+			return fmt.Errorf("поле %q должно быть строкой", column.name)
+		}
+	}
+	if len(fields) > 0 {
+		return fmt.Errorf("неизвестное поле %q", slices.Sorted(maps.Keys(fields))[0])
+	}
+	return nil
+}
+
+// ExportToFile атомарно записывает строки структуры в файл
+func (t *transferTarget) ExportToFile(filename string, format TransferFormat) (int, error) {
+	file, err := createAtomicFile(filename)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось создать файл: %v", err)
+	}
+	defer file.Close()
+	count, err := t.Export(file, format)
+	if err != nil {
+		return 0, err
+	}
+	return count, file.Commit()
+}
+
+// ImportFromFile загружает структуру из файла
+func (t *transferTarget) ImportFromFile(filename string, format TransferFormat) (int, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось открыть файл: %v", err)
+	}
+	defer file.Close()
+	return t.Import(file, format)
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func (s *testStructures) target(t *testing.T, name string) *transferTarget {
+	t.Helper()
+	target, err := transferTargetFor(name, s.array, s.stack, s.queue, s.singlyList, s.doublyList, s.hashTable, s.cbTree, s.stringTree, s.avlTree, s.rbTree, s.cache, s.pq)
+	if err != nil {
+		t.Fatalf("transferTargetFor(%s) вернул ошибку: %v", name, err)
+	}
+	return target
+}
+
+// dump описывает содержимое структуры для сравнения
+func (s *testStructures) dump(name string) string {
+	var text string
+	switch name {
+	case "array":
+		text, _ = s.array.SerializeText()
+	case "stack":
+		text, _ = s.stack.SerializeText()
+	case "queue":
+		text, _ = s.queue.SerializeText()
+	case "singly_list":
+		text, _ = s.singlyList.SerializeText()
+	case "doubly_list":
+		text, _ = s.doublyList.SerializeText()
+	case "hash_table":
+		text = hashTableState(s.hashTable)
+	case "binary_tree":
+		text = s.cbTree.LevelOrderString()
+	case "avl_tree":
+		text, _ = s.avlTree.SerializeText()
+	case "rb_tree":
+		text, _ = s.rbTree.SerializeText()
+	case "cache":
+		for _, key := range s.cache.Keys() {
+			value, _ := s.cache.Peek(key)
+			text += fmt.Sprintf("%q=%q/%d ", key, value, s.cache.Frequency(key))
+		}
+	case "priority_queue":
+		text = fmt.Sprint(s.pq.Items())
+	}
+	return text
+}
+
+// TestTransferRoundTrip проверяет выгрузку и загрузку всех структур во всех форматах
+func TestTransferRoundTrip(t *testing.T) {
+	original := filledStructures()
+	for name := range transferStructures {
+		for _, format := range []TransferFormat{FormatCSV, FormatTSV, FormatNDJSON} {
+			var buffer bytes.Buffer
+			exported, err := original.target(t, name).Export(&buffer, format)
+			if err != nil {
+				t.Fatalf("%s/%s: Export вернул ошибку: %v", name, format, err)
+			}
+			restored := emptyStructures()
+			imported, err := restored.target(t, name).Import(&buffer, format)
+			if err != nil {
+				t.Fatalf("%s/%s: Import вернул ошибку: %v", name, format, err)
+			}
+			if imported != exported {
+				t.Errorf("%s/%s: выгружено %d строк, загружено %d", name, format, exported, imported)
+			}
+			if restored.dump(name) != original.dump(name) {
+				t.Errorf("%s/%s: загружено %s, ожидалось %s", name, format, restored.dump(name), original.dump(name))
+			}
+		}
+	}
+}
+
+// TestTransferIncompleteTree проверяет, что неполное дерево сохраняет форму во всех форматах
+func TestTransferIncompleteTree(t *testing.T) {
+	for _, format := range []TransferFormat{FormatCSV, FormatTSV, FormatNDJSON, FormatMsgPack, FormatCBOR} {
+		original := emptyStructures()
+		original.cbTree, _ = ParseLevelOrder("[1,null,2,null,3]")
+		var buffer bytes.Buffer
+		if _, err := original.target(t, "binary_tree").Export(&buffer, format); err != nil {
+			t.Fatalf("%s: Export вернул ошибку: %v", format, err)
+		}
+		restored := emptyStructures()
+		restored.cbTree = NewBinaryTree()
+		if _, err := restored.target(t, "binary_tree").Import(&buffer, format); err != nil {
+			t.Fatalf("%s: Import вернул ошибку: %v", format, err)
+		}
+		if shape := restored.cbTree.LevelOrderString(); shape != "[1,null,2,null,3]" {
+			t.Errorf("%s: загружено дерево %s", format, shape)
+		}
+	}
+	s := emptyStructures()
+	s.cbTree = NewBinaryTree()
+	if _, err := s.target(t, "binary_tree").Import(strings.NewReader("value\n1\nnull\nnull\n2\n"), FormatCSV); err == nil || !strings.Contains(err.Error(), "строка 5") {
+		t.Errorf("Ожидалась ошибка для значения без родителя в строке 5, получено %v", err)
+	}
+}
+
+// TestTransferPortable проверяет выгрузку и загрузку структур в MessagePack и CBOR
+func TestTransferPortable(t *testing.T) {
+	original := filledStructures()
+	for name := range transferStructures {
+		for _, format := range []TransferFormat{FormatMsgPack, FormatCBOR} {
+			var buffer bytes.Buffer
+			exported, err := original.target(t, name).Export(&buffer, format)
+			if name == "cache" || name == "priority_queue" {
+				if err == nil {
+					t.Errorf("%s/%s: ожидалась ошибка для структуры без переносимого представления", name, format)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s/%s: Export вернул ошибку: %v", name, format, err)
+			}
+			restored := emptyStructures()
+			imported, err := restored.target(t, name).Import(&buffer, format)
+			if err != nil {
+				t.Fatalf("%s/%s: Import вернул ошибку: %v", name, format, err)
+			}
+			if imported != exported || restored.dump(name) != original.dump(name) {
+				t.Errorf("%s/%s: загружено %s (%d), ожидалось %s (%d)", name, format, restored.dump(name), imported, original.dump(name), exported)
+			}
+		}
+	}
+
+	// Файл совпадает с SerializeMsgPack структуры
+	var buffer bytes.Buffer
+	original.target(t, "hash_table").Export(&buffer, FormatMsgPack)
+	if data, _ := original.hashTable.SerializeMsgPack(); !bytes.Equal(buffer.Bytes(), data) {
+		t.Errorf("Выгрузка отличается от SerializeMsgPack")
+	}
+
+	// Дерево не из дерева поиска не загружается в режиме --tree bst, и дерево не меняется
+	cbt := NewBinaryTree()
+	for _, value := range []int{1, 2, 3} {
+		cbt.Insert(value)
+	}
+	data, _ := cbt.SerializeCBOR()
+	s := filledStructures()
+	before := s.dump("binary_tree")
+	if _, err := s.target(t, "binary_tree").Import(bytes.NewReader(data), FormatCBOR); err == nil || s.dump("binary_tree") != before {
+		t.Errorf("Ожидалась ошибка загрузки неупорядоченного дерева в дерево поиска: %v", err)
+	}
+	if _, err := s.target(t, "stack").Import(strings.NewReader("\xc1"), FormatMsgPack); err == nil {
+		t.Errorf("Ожидалась ошибка для поврежденных данных")
+	}
+
+	keyed := emptyStructures()
+	keyed.stringTree.Insert("m")
+	for _, format := range []TransferFormat{FormatCSV, FormatNDJSON, FormatCBOR} {
+		if _, err := keyed.target(t, "binary_tree").Export(&buffer, format); err == nil {
+			t.Errorf("%s: ожидалась ошибка выгрузки дерева со строковыми ключами", format)
+		}
+	}
+	if _, err := keyed.target(t, "binary_tree").Import(strings.NewReader("value\n1\n"), FormatCSV); err != nil || keyed.stringTree.Root != nil {
+		t.Errorf("Загрузка числового дерева вместо строкового: %v", err)
+	}
+}
+
+// TestTransferFormats проверяет вид выгруженных файлов
+func TestTransferFormats(t *testing.T) {
+	s := emptyStructures()
+	s.hashTable.HSet("k", "a,b")
+	s.pq.Push("job", 2)
+	expected := map[TransferFormat]string{
+		FormatCSV:    "key,value\nk,\"a,b\"\n",
+		FormatTSV:    "key\tvalue\nk\ta,b\n",
+		FormatNDJSON: `{"key":"k","value":"a,b"}` + "\n",
+	}
+	for format, want := range expected {
+		var buffer bytes.Buffer
+		s.target(t, "hash_table").Export(&buffer, format)
+		if buffer.String() != want {
+			t.Errorf("%s: получено %q, ожидалось %q", format, buffer.String(), want)
+		}
+	}
+	var buffer bytes.Buffer
+	s.target(t, "priority_queue").Export(&buffer, FormatNDJSON)
+	if buffer.String() != `{"value":"job","priority":2}`+"\n" {
+		t.Errorf("Числовой столбец записан неверно: %q", buffer.String())
+	}
+}
+
+// TestTransferMalformedRows проверяет сообщения о некорректных строках и неизменность структуры
+func TestTransferMalformedRows(t *testing.T) {
+	cases := []struct {
+		name      string
+		structure string
+		format    TransferFormat
+		data      string
+		message   string
+	}{
+		{"лишний столбец", "queue", FormatCSV, "value\na\nb,c\n", "строка 3: неверное количество столбцов"},
+		{"не тот заголовок", "hash_table", FormatCSV, "key,val\n", "строка 1: ожидался столбец value"},
+		{"пустой файл", "stack", FormatTSV, "", "заголовка"},
+		{"незакрытая кавычка", "array", FormatCSV, "value\n\"abc\n", "строка 2"},
+		{"не число", "avl_tree", FormatCSV, "value\n1\nx\n", `строка 3: значение "x" не является целым числом`},
+		{"повтор ключа", "hash_table", FormatTSV, "key\tvalue\nk\t1\nk\t2\n", `строка 3: ключ "k" повторяется`},
+		{"переполнение массива", "array", FormatCSV, "value\n" + strings.Repeat("x\n", 11), "строка 12: массив заполнен"},
+		{"переполнение кэша", "cache", FormatCSV, "key,value,frequency\na,1,1\nb,2,1\nc,3,1\nd,4,1\n", "строка 5: кэш заполнен"},
+		{"не JSON", "queue", FormatNDJSON, "{\"value\":\"a\"}\n\nnot json\n", "строка 3: ожидался JSON-объект"},
+		{"нет поля", "hash_table", FormatNDJSON, `{"key":"k"}`, `строка 1: нет поля "value"`},
+		{"лишнее поле", "queue", FormatNDJSON, `{"value":"a","extra":1}`, `строка 1: неизвестное поле "extra"`},
+		{"строка вместо числа", "rb_tree", FormatNDJSON, `{"value":"5"}`, `строка 1: поле "value" должно быть числом`},
+		{"число вместо строки", "stack", FormatNDJSON, `{"value":5}`, `строка 1: поле "value" должно быть строкой`},
+		{"дробный приоритет", "priority_queue", FormatNDJSON, `{"value":"a","priority":1.5}`, "строка 1: значение \"1.5\""},
+	}
+	for _, tc := range cases {
+		s := filledStructures()
+		before := s.dump(tc.structure)
+		_, err := s.target(t, tc.structure).Import(strings.NewReader(tc.data), tc.format)
+		if err == nil || !strings.Contains(err.Error(), tc.message) {
+			t.Errorf("%s: ожидалась ошибка %q, получено %v", tc.name, tc.message, err)
+		}
+		if s.dump(tc.structure) != before {
+			t.Errorf("%s: структура изменена при ошибке", tc.name)
+		}
+	}
+}
+
+// TestTransferSpreadsheetHeader проверяет заголовок с меткой порядка байтов и в другом регистре
+func TestTransferSpreadsheetHeader(t *testing.T) {
+	s := emptyStructures()
+	data := "\ufeffKey;Value\n"
+	if _, err := s.target(t, "hash_table").Import(strings.NewReader(data), FormatCSV); err == nil {
+		t.Errorf("Ожидалась ошибка для заголовка с другим разделителем")
+	}
+	data = "\ufeffKey,Value\r\nk,v\r\n"
+	if count, err := s.target(t, "hash_table").Import(strings.NewReader(data), FormatCSV); err != nil || count != 1 {
+		t.Errorf("Import = %d, %v", count, err)
+	}
+	if node := s.hashTable.findNodeByKey("k"); node == nil || node.Value != "v" {
+		t.Errorf("Пара из файла табличного редактора не загружена")
+	}
+}
+
+// TestTransferCommands проверяет команды EXPORT и IMPORT
+func TestTransferCommands(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "stack.csv")
+	s := filledStructures()
+	run := func(query string) string {
+		return captureStdout(func() {
+			processQuery(query, s.array, s.stack, s.queue, s.singlyList, s.doublyList, s.hashTable, s.cbTree, s.stringTree, NewPersistentTree(), s.avlTree, s.rbTree, s.cache, s.pq, "")
+		})
+	}
+
+	if output := run("EXPORT stack csv " + filename); !strings.Contains(output, "строк: 8") {
+		t.Errorf("Неожиданный вывод EXPORT: %q", output)
+	}
+	if output := run("IMPORT queue csv " + filename); !strings.Contains(output, "загружена") {
+		t.Errorf("Неожиданный вывод IMPORT: %q", output)
+	}
+	if s.queue.Size != len(transferSample) || s.queue.Front.Data != transferSample[0] {
+		t.Errorf("Очередь загружена неверно: размер %d", s.queue.Size)
+	}
+
+	os.WriteFile(filename, []byte("value\n1\nbad\n"), 0644)
+	if output := run("IMPORT binary_tree csv " + filename); !strings.Contains(output, "строка 3") {
+		t.Errorf("Нет номера строки в ошибке: %q", output)
+	}
+	if output := run("EXPORT graph csv " + filename); !strings.Contains(output, "неизвестная структура graph") {
+		t.Errorf("Нет ошибки для неизвестной структуры: %q", output)
+	}
+	if output := run("EXPORT stack xml " + filename); !strings.Contains(output, "неизвестный формат") {
+		t.Errorf("Нет ошибки для неизвестного формата: %q", output)
+	}
+	if output := run("IMPORT stack csv"); !strings.Contains(output, "требует 3 аргумента") {
+		t.Errorf("Нет ошибки для неполной команды: %q", output)
+	}
+}
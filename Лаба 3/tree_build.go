@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// NewBinaryTreeFromPreIn восстанавливает дерево по прямому и симметричному обходам.
+// Значения должны быть уникальными, иначе форма дерева неоднозначна.
+func NewBinaryTreeFromPreIn(preorder, inorder []int) (*BinaryTree, error) {
+	builder, err := newTraversalBuilder(preorder, inorder)
+	if err != nil {
+		return nil, err
+	}
+	next := 0
+	root, err := builder.build(0, len(inorder)-1, func() int {
+		value := preorder[next]
+		next++
+		return value
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+	return &BinaryTree{Root: root}, nil
+}
+
+// NewBinaryTreeFromPostIn восстанавливает дерево по обратному и симметричному обходам.
+// Значения должны быть уникальными, иначе форма дерева неоднозначна.
+func NewBinaryTreeFromPostIn(postorder, inorder []int) (*BinaryTree, error) {
+	builder, err := newTraversalBuilder(postorder, inorder)
+	if err != nil {
+		return nil, err
+	}
+	// Обратный обход, прочитанный с конца, дает корень, затем правое и левое поддеревья
+	next := len(postorder) - 1
+	root, err := builder.build(0, len(inorder)-1, func() int {
+		value := postorder[next]
+		next--
+		return value
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+	return &BinaryTree{Root: root}, nil
+}
+
+// traversalBuilder хранит позиции значений симметричного обхода
+type traversalBuilder struct {
+	positions map[int]int
+}
+
+// newTraversalBuilder проверяет, что обходы являются перестановками одних и тех же уникальных значений
+func newTraversalBuilder(order, inorder []int) (*traversalBuilder, error) {
+	if len(order) != len(inorder) {
+		return nil, fmt.Errorf("длины обходов не совпадают: %d и %d", len(order), len(inorder))
+	}
+	positions := make(map[int]int, len(inorder))
+	for i, value := range inorder {
+		if _, ok := positions[value]; ok {
+			return nil, fmt.Errorf("значение %d повторяется в симметричном обходе", value)
+		}
+		positions[value] = i
+	}
+	seen := make(map[int]bool, len(order))
+	for _, value := range order {
+		if _, ok := positions[value]; !ok || seen[value] {
+			return nil, fmt.Errorf("обходы содержат разные наборы значений")
+		}
+		seen[value] = true
+	}
+	return &traversalBuilder{positions: positions}, nil
+}
+
+// build рекурсивно строит поддерево для отрезка [low, high] симметричного обхода.
+// next возвращает очередной корень; rightFirst задает построение правого поддерева первым.
+func (b *traversalBuilder) build(low, high int, next func() int, rightFirst bool) (*TreeNode, error) {
+	if low > high {
+		return nil, nil
+	}
+	value := next()
+	index := b.positions[value]
+	if index < low || index > high {
+		return nil, fmt.Errorf("обходы несовместимы: значение %d вне своего поддерева", value)
+	}
+	node := &TreeNode{Digit: value}
+	var err error
+	if rightFirst {
+		if node.Right, err = b.build(index+1, high, next, rightFirst); err != nil {
+			return nil, err
+		}
+		node.Left, err = b.build(low, index-1, next, rightFirst)
+	} else {
+		if node.Left, err = b.build(low, index-1, next, rightFirst); err != nil {
+			return nil, err
+		}
+		node.Right, err = b.build(index+1, high, next, rightFirst)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// NewBinaryTreeFromLevelOrder строит дерево по обходу по уровням с пропусками (nil),
+// как в записи LeetCode: потомки указываются только для существующих узлов.
+func NewBinaryTreeFromLevelOrder(values []*int) (*BinaryTree, error) {
+	bt := NewBinaryTree()
+	if len(values) == 0 || values[0] == nil {
+		if len(values) > 1 {
+			return nil, fmt.Errorf("после пустого корня не может быть узлов")
+		}
+		return bt, nil
+	}
+	bt.Root = &TreeNode{Digit: *values[0]}
+	queue := NewQueueTree()
+	queue.Enqueue(bt.Root)
+	index := 1
+	for index < len(values) {
+		current := queue.Dequeue()
+		if current == nil {
+			return nil, fmt.Errorf("лишние значения начиная с позиции %d", index)
+		}
+		if values[index] != nil {
+			current.Left = &TreeNode{Digit: *values[index]}
+			queue.Enqueue(current.Left)
+		}
+		index++
+		if index < len(values) && values[index] != nil {
+			current.Right = &TreeNode{Digit: *values[index]}
+			queue.Enqueue(current.Right)
+		}
+		index++
+	}
+	return bt, nil
+}
+
+// levelOrderBuilder собирает дерево из значений обхода по уровням с пропусками (nil),
+// поступающих по одному, например из строк файла. Значение, для которого
+// не осталось свободной позиции, отклоняется сразу, поэтому ошибку можно
+// сообщить с номером строки.
+type levelOrderBuilder struct {
+	values []*int
+	slots  int // Свободные позиции для следующих значений
+}
+
+// add добавляет значение или пропуск (nil)
+func (b *levelOrderBuilder) add(value *int) error {
+	if len(b.values) == 0 {
+		b.slots = 1
+	}
+	if b.slots == 0 {
+		return fmt.Errorf("у значения нет родителя: все позиции после пропусков заняты")
+	}
+	b.slots--
+	if value != nil {
+		b.slots += 2
+	}
+	b.values = append(b.values, value)
+	return nil
+}
+
+// tree строит дерево. Дерево поиска строится вставкой значений в порядке обхода,
+// что восстанавливает его форму, а пропуски не нужны; дерево, заполняемое
+// по уровням, получает форму из записи с пропусками.
+func (b *levelOrderBuilder) tree(ordered bool) *BinaryTree {
+	if ordered {
+		bt := NewBinarySearchTree()
+		for _, value := range b.values {
+			if value != nil {
+				bt.Insert(*value)
+			}
+		}
+		return bt
+	}
+	bt, _ := NewBinaryTreeFromLevelOrder(b.values) // Позиции проверены в add
+	return bt
+}
+
+// levelOrderLines возвращает значения для файла и таблиц в порядке обхода по уровням.
+// Неполное дерево, заполняемое по уровням (например, из TBUILD), записывается
+// с пропусками null, без которых его форму не восстановить; полное дерево
+// и дерево поиска пропусков не содержат и записываются как раньше.
+func (bt *BinaryTree) levelOrderLines() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		if bt.Ordered {
+			for value := range bt.LevelOrder() {
+				if !yield(strconv.Itoa(value)) {
+					return
+				}
+			}
+			return
+		}
+		for _, value := range bt.LevelOrderWithNulls() {
+			line := "null"
+			if value != nil {
+				line = strconv.Itoa(*value)
+			}
+			if !yield(line) {
+				return
+			}
+		}
+	}
+}
+
+// ParseLevelOrder строит дерево из строки вида "[1,2,null,3]"
+func ParseLevelOrder(text string) (*BinaryTree, error) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "[") || !strings.HasSuffix(text, "]") {
+		return nil, fmt.Errorf("ожидается список в квадратных скобках: %s", text)
+	}
+	text = strings.TrimSpace(text[1 : len(text)-1])
+	var values []*int
+	if text != "" {
+		for _, part := range strings.Split(text, ",") {
+			part = strings.TrimSpace(part)
+			if part == "null" {
+				values = append(values, nil)
+				continue
+			}
+			value, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("недопустимое значение %q: %v", part, err)
+			}
+			values = append(values, &value)
+		}
+	}
+	return NewBinaryTreeFromLevelOrder(values)
+}
+
+// Replace заменяет дерево построенным по обходам, сохраняя режим дерева поиска.
+// В упорядоченном режиме построенное дерево должно быть деревом поиска.
+func (bt *BinaryTree) Replace(built *BinaryTree) error {
+	if bt.Ordered {
+		if !built.IsBST() {
+			return fmt.Errorf("построенное дерево не является деревом поиска с уникальными значениями")
+		}
+		built.updateSizes()
+	}
+	bt.Root = built.Root
+	return nil
+}
+
+// LevelOrderWithNulls возвращает обход по уровням с пропусками (nil) в записи LeetCode.
+// Завершающие пропуски отбрасываются.
+func (bt *BinaryTree) LevelOrderWithNulls() []*int {
+	var values []*int
+	if bt.Root == nil {
+		return values
+	}
+	queue := []*TreeNode{bt.Root}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == nil {
+			values = append(values, nil)
+			continue
+		}
+		value := current.Digit
+		values = append(values, &value)
+		queue = append(queue, current.Left, current.Right)
+	}
+	for len(values) > 0 && values[len(values)-1] == nil {
+		values = values[:len(values)-1]
+	}
+	return values
+}
+
+// LevelOrderString возвращает обход по уровням в виде строки "[1,2,null,3]"
+func (bt *BinaryTree) LevelOrderString() string {
+	parts := []string{}
+	for _, value := range bt.LevelOrderWithNulls() {
+		if value == nil {
+			parts = append(parts, "null")
+		} else {
+			parts = append(parts, strconv.Itoa(*value))
+		}
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+// Export возвращает обход дерева для передачи другим инструментам:
+// pre, in и post - значения через запятую, level - запись LeetCode с null
+func (bt *BinaryTree) Export(order string) (string, error) {
+	switch strings.ToLower(order) {
+	case "pre":
+		return joinInts(slices.Collect(bt.PreOrder())), nil
+	case "in":
+		return joinInts(slices.Collect(bt.InOrder())), nil
+	case "post":
+		return joinInts(slices.Collect(bt.PostOrder())), nil
+	case "level":
+		return bt.LevelOrderString(), nil
+	}
+	return "", fmt.Errorf("неизвестный обход для экспорта: %s", order)
+}
+
+// ParseIntList разбирает значения, перечисленные через запятую
+func ParseIntList(text string) ([]int, error) {
+	var values []int
+	if strings.TrimSpace(text) == "" {
+		return values, nil
+	}
+	for _, part := range strings.Split(text, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("недопустимое значение %q: %v", part, err)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// joinInts объединяет значения через запятую
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = strconv.Itoa(value)
+	}
+	return strings.Join(parts, ",")
+}
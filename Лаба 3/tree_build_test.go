@@ -0,0 +1,153 @@
+package main
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// TestNewBinaryTreeFromPreIn проверяет восстановление дерева по прямому и симметричному обходам
+func TestNewBinaryTreeFromPreIn(t *testing.T) {
+	expected := newTraversalTree()
+	bt, err := NewBinaryTreeFromPreIn(slices.Collect(expected.PreOrder()), slices.Collect(expected.InOrder()))
+	if err != nil {
+		t.Fatalf("NewBinaryTreeFromPreIn() error = %v", err)
+	}
+	if !treesEqual(bt.Root, expected.Root) {
+		t.Errorf("NewBinaryTreeFromPreIn() built a different tree")
+	}
+
+	empty, err := NewBinaryTreeFromPreIn(nil, nil)
+	if err != nil || empty.Root != nil {
+		t.Errorf("NewBinaryTreeFromPreIn(nil, nil) = %v, %v; want empty tree", empty, err)
+	}
+
+	invalid := [][2][]int{
+		{{1, 2}, {1}},          // разная длина
+		{{1, 2}, {1, 3}},       // разные значения
+		{{1, 1}, {1, 1}},       // повторы
+		{{1, 2, 3}, {3, 1, 2}}, // несовместимые обходы
+	}
+	for _, pair := range invalid {
+		if _, err := NewBinaryTreeFromPreIn(pair[0], pair[1]); err == nil {
+			t.Errorf("NewBinaryTreeFromPreIn(%v, %v) error = nil; want error", pair[0], pair[1])
+		}
+	}
+}
+
+// TestNewBinaryTreeFromPostIn проверяет восстановление дерева по обратному и симметричному обходам
+func TestNewBinaryTreeFromPostIn(t *testing.T) {
+	expected := newTraversalTree()
+	bt, err := NewBinaryTreeFromPostIn(slices.Collect(expected.PostOrder()), slices.Collect(expected.InOrder()))
+	if err != nil {
+		t.Fatalf("NewBinaryTreeFromPostIn() error = %v", err)
+	}
+	if !treesEqual(bt.Root, expected.Root) {
+		t.Errorf("NewBinaryTreeFromPostIn() built a different tree")
+	}
+	if _, err := NewBinaryTreeFromPostIn([]int{1, 2, 3}, []int{2, 3, 1}); err == nil {
+		t.Errorf("NewBinaryTreeFromPostIn() error = nil; want error for incompatible traversals")
+	}
+}
+
+// TestBuildFromTraversalsRoundTrip проверяет восстановление деревьев случайной формы
+func TestBuildFromTraversalsRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for i := 0; i < 200; i++ {
+		bt := &BinaryTree{Root: randomTree(rng, 1+rng.Intn(7))}
+		// Значения делаются уникальными
+		next := 0
+		bt.forEachNode(func(node *TreeNode) {
+			node.Digit = next*7 - 50
+			next++
+		})
+		inorder := slices.Collect(bt.InOrder())
+
+		fromPre, err := NewBinaryTreeFromPreIn(slices.Collect(bt.PreOrder()), inorder)
+		if err != nil || !treesEqual(fromPre.Root, bt.Root) {
+			t.Fatalf("pre+in round trip failed: %v", err)
+		}
+		fromPost, err := NewBinaryTreeFromPostIn(slices.Collect(bt.PostOrder()), inorder)
+		if err != nil || !treesEqual(fromPost.Root, bt.Root) {
+			t.Fatalf("post+in round trip failed: %v", err)
+		}
+		fromLevel, err := ParseLevelOrder(bt.LevelOrderString())
+		if err != nil || !treesEqual(fromLevel.Root, bt.Root) {
+			t.Fatalf("level order round trip failed for %s: %v", bt.LevelOrderString(), err)
+		}
+	}
+}
+
+// TestParseLevelOrder проверяет разбор записи LeetCode
+func TestParseLevelOrder(t *testing.T) {
+	bt, err := ParseLevelOrder("[1, 2, 3, null, 5, null, 6, 7]")
+	if err != nil {
+		t.Fatalf("ParseLevelOrder() error = %v", err)
+	}
+	if bt.Root.Left.Left != nil || bt.Root.Left.Right.Digit != 5 || bt.Root.Right.Right.Digit != 6 || bt.Root.Left.Right.Left.Digit != 7 {
+		t.Errorf("ParseLevelOrder() built an unexpected tree")
+	}
+	if output := bt.LevelOrderString(); output != "[1,2,3,null,5,null,6,7]" {
+		t.Errorf("LevelOrderString() = %s", output)
+	}
+
+	for _, text := range []string{"[]", "[null]"} {
+		if bt, err := ParseLevelOrder(text); err != nil || bt.Root != nil {
+			t.Errorf("ParseLevelOrder(%s) = %v, %v; want empty tree", text, bt, err)
+		}
+	}
+	for _, text := range []string{"1,2", "[1,x]", "[null,1]", "[1,null,null,2]"} {
+		if _, err := ParseLevelOrder(text); err == nil {
+			t.Errorf("ParseLevelOrder(%s) error = nil; want error", text)
+		}
+	}
+}
+
+// TestExport проверяет экспорт обходов
+func TestExport(t *testing.T) {
+	bt := newTraversalTree()
+	tests := map[string]string{
+		"pre":   "1,2,4,5,7,3,6",
+		"in":    "4,2,7,5,1,3,6",
+		"post":  "4,7,5,2,6,3,1",
+		"level": "[1,2,3,4,5,null,6,null,null,7]",
+	}
+	for order, expected := range tests {
+		if output, err := bt.Export(order); err != nil || output != expected {
+			t.Errorf("Export(%s) = %s, %v; want %s", order, output, err, expected)
+		}
+	}
+	if _, err := bt.Export("zigzag"); err == nil {
+		t.Errorf("Export(zigzag) error = nil; want error")
+	}
+	if values, err := ParseIntList(" 1, -2 ,3"); err != nil || !slices.Equal(values, []int{1, -2, 3}) {
+		t.Errorf("ParseIntList() = %v, %v", values, err)
+	}
+	if _, err := ParseIntList("1,,2"); err == nil {
+		t.Errorf("ParseIntList() error = nil; want error")
+	}
+}
+
+// TestReplaceKeepsOrdered проверяет, что TBUILD сохраняет режим дерева поиска
+func TestReplaceKeepsOrdered(t *testing.T) {
+	bst := NewBinarySearchTree()
+	built, _ := ParseLevelOrder("[4,2,6,1,3]")
+	if err := bst.Replace(built); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	if !bst.Ordered || bst.Root.Size != 5 {
+		t.Fatalf("Replace() Ordered = %v, Size = %d; want true, 5", bst.Ordered, bst.Root.Size)
+	}
+	bst.Insert(5)
+	if value, err := bst.Select(5); err != nil || value != 5 || bst.LevelOrderString() != "[4,2,6,1,3,5]" {
+		t.Errorf("После Insert(5): Select(5) = %d, %v, дерево %s", value, err, bst.LevelOrderString())
+	}
+
+	unordered, _ := ParseLevelOrder("[1,null,2,3]")
+	if err := bst.Replace(unordered); err == nil || bst.Root.Digit != 4 {
+		t.Errorf("Replace() неупорядоченного дерева: error = %v, корень %d; want error, 4", err, bst.Root.Digit)
+	}
+	if err := NewBinaryTree().Replace(unordered); err != nil {
+		t.Errorf("Replace() для полного дерева error = %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Height возвращает высоту дерева в уровнях (0 для пустого дерева)
+func (bt *BinaryTree) Height() int {
+	return len(bt.LevelWidths())
+}
+
+// Size возвращает количество узлов в дереве
+func (bt *BinaryTree) Size() int {
+	count := 0
+	for range bt.LevelOrder() {
+		count++
+	}
+	return count
+}
+
+// LeafCount возвращает количество листьев в дереве
+func (bt *BinaryTree) LeafCount() int {
+	count := 0
+	bt.forEachNode(func(node *TreeNode) {
+		if node.Left == nil && node.Right == nil {
+			count++
+		}
+	})
+	return count
+}
+
+// LevelWidths возвращает количество узлов на каждом уровне, начиная с корня
+func (bt *BinaryTree) LevelWidths() []int {
+	var widths []int
+	if bt.Root == nil {
+		return widths
+	}
+	queue := NewQueueTree()
+	queue.Enqueue(bt.Root)
+	for !queue.IsEmpty() {
+		width := queue.Count
+		widths = append(widths, width)
+		for i := 0; i < width; i++ {
+			current := queue.Dequeue()
+			if current.Left != nil {
+				queue.Enqueue(current.Left)
+			}
+			if current.Right != nil {
+				queue.Enqueue(current.Right)
+			}
+		}
+	}
+	return widths
+}
+
+// Diameter возвращает длину самого длинного пути между двумя узлами в ребрах
+func (bt *BinaryTree) Diameter() int {
+	diameter := 0
+	bt.subtreeHeights(func(node *TreeNode, left, right int) {
+		if left+right > diameter {
+			diameter = left + right
+		}
+	})
+	return diameter
+}
+
+// IsFull проверяет, что у каждого узла либо ноль, либо два потомка
+func (bt *BinaryTree) IsFull() bool {
+	full := true
+	bt.forEachNode(func(node *TreeNode) {
+		if (node.Left == nil) != (node.Right == nil) {
+			full = false
+		}
+	})
+	return full
+}
+
+// IsPerfect проверяет, что все внутренние узлы имеют двух потомков и все листья на одном уровне
+func (bt *BinaryTree) IsPerfect() bool {
+	widths := bt.LevelWidths()
+	for level, width := range widths {
+		if width != 1<<level {
+			return false
+		}
+	}
+	return true
+}
+
+// IsBalanced проверяет, что высоты поддеревьев каждого узла отличаются не более чем на 1
+func (bt *BinaryTree) IsBalanced() bool {
+	balanced := true
+	bt.subtreeHeights(func(node *TreeNode, left, right int) {
+		if left-right > 1 || right-left > 1 {
+			balanced = false
+		}
+	})
+	return balanced
+}
+
+// IsBST проверяет, что значения в симметричном обходе строго возрастают
+func (bt *BinaryTree) IsBST() bool {
+	first := true
+	previous := 0
+	for value := range bt.InOrder() {
+		if !first && value <= previous {
+			return false
+		}
+		first = false
+		previous = value
+	}
+	return true
+}
+
+// PathTo возвращает значения на пути от корня до первого узла с указанным значением
+func (bt *BinaryTree) PathTo(value int) ([]int, error) {
+	nodes := bt.pathToNode(value)
+	if nodes == nil {
+		return nil, fmt.Errorf("значение %d не найдено в дереве", value)
+	}
+	path := make([]int, len(nodes))
+	for i, node := range nodes {
+		path[i] = node.Digit
+	}
+	return path, nil
+}
+
+// LowestCommonAncestor возвращает значение наименьшего общего предка двух значений
+func (bt *BinaryTree) LowestCommonAncestor(a, b int) (int, error) {
+	pathA := bt.pathToNode(a)
+	if pathA == nil {
+		return 0, fmt.Errorf("значение %d не найдено в дереве", a)
+	}
+	pathB := bt.pathToNode(b)
+	if pathB == nil {
+		return 0, fmt.Errorf("значение %d не найдено в дереве", b)
+	}
+	ancestor := pathA[0]
+	for i := 0; i < len(pathA) && i < len(pathB) && pathA[i] == pathB[i]; i++ {
+		ancestor = pathA[i]
+	}
+	return ancestor.Digit, nil
+}
+
+// PrintInfo печатает структурные характеристики дерева
+func (bt *BinaryTree) PrintInfo() {
+	fmt.Printf("Высота: %d\n", bt.Height())
+	fmt.Printf("Узлов: %d\n", bt.Size())
+	fmt.Printf("Листьев: %d\n", bt.LeafCount())
+	fmt.Printf("Ширина по уровням: %v\n", bt.LevelWidths())
+	fmt.Printf("Диаметр: %d\n", bt.Diameter())
+	fmt.Printf("Строгое: %s\n", yesNo(bt.IsFull()))
+	fmt.Printf("Идеальное: %s\n", yesNo(bt.IsPerfect()))
+	fmt.Printf("Полное: %s\n", yesNo(bt.IsComplete()))
+	fmt.Printf("Сбалансированное: %s\n", yesNo(bt.IsBalanced()))
+	fmt.Printf("Дерево поиска: %s\n", yesNo(bt.IsBST()))
+}
+
+// yesNo возвращает "да" или "нет" для логического значения
+func yesNo(value bool) string {
+	if value {
+		return "да"
+	}
+	return "нет"
+}
+
+// forEachNode вызывает fn для каждого узла в порядке обхода по уровням
+func (bt *BinaryTree) forEachNode(fn func(node *TreeNode)) {
+	if bt.Root == nil {
+		return
+	}
+	queue := NewQueueTree()
+	queue.Enqueue(bt.Root)
+	for !queue.IsEmpty() {
+		current := queue.Dequeue()
+		fn(current)
+		if current.Left != nil {
+			queue.Enqueue(current.Left)
+		}
+		if current.Right != nil {
+			queue.Enqueue(current.Right)
+		}
+	}
+}
+
+// subtreeHeights итеративно обходит дерево в обратном порядке и вызывает fn
+// для каждого узла с высотами его левого и правого поддеревьев
+func (bt *BinaryTree) subtreeHeights(fn func(node *TreeNode, left, right int)) {
+	heights := make(map[*TreeNode]int)
+	stack := NewStackTree()
+	var lastVisited *TreeNode
+	current := bt.Root
+	for current != nil || !stack.IsEmpty() {
+		for current != nil {
+			stack.Push(current)
+			current = current.Left
+		}
+		top := stack.Peek()
+		if top.Right != nil && top.Right != lastVisited {
+			current = top.Right
+			continue
+		}
+		stack.Pop()
+		left, right := heights[top.Left], heights[top.Right]
+		fn(top, left, right)
+		if left > right {
+			heights[top] = left + 1
+		} else {
+			heights[top] = right + 1
+		}
+		lastVisited = top
+	}
+}
+
+// pathToNode возвращает узлы на пути от корня до первого в порядке обхода
+// по уровням узла с указанным значением или nil, если значения нет
+func (bt *BinaryTree) pathToNode(value int) []*TreeNode {
+	if bt.Ordered {
+		var path []*TreeNode
+		for current := bt.Root; current != nil; {
+			path = append(path, current)
+			switch {
+			case value < current.Digit:
+				current = current.Left
+			case value > current.Digit:
+				current = current.Right
+			default:
+				return path
+			}
+		}
+		return nil
+	}
+
+	parents := make(map[*TreeNode]*TreeNode)
+	var target *TreeNode
+	bt.forEachNode(func(node *TreeNode) {
+		if target == nil && node.Digit == value {
+			target = node
+		}
+		if node.Left != nil {
+			parents[node.Left] = node
+		}
+		if node.Right != nil {
+			parents[node.Right] = node
+		}
+	})
+	if target == nil {
+		return nil
+	}
+
+	var path []*TreeNode
+	for node := target; node != nil; node = parents[node] {
+		path = append(path, node)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
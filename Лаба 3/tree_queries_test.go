@@ -0,0 +1,130 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestStructuralCounts проверяет высоту, количество узлов и листьев, ширину уровней
+func TestStructuralCounts(t *testing.T) {
+	bt := newTraversalTree()
+	if bt.Height() != 4 || bt.Size() != 7 || bt.LeafCount() != 3 {
+		t.Errorf("Height/Size/LeafCount = %d/%d/%d; want 4/7/3", bt.Height(), bt.Size(), bt.LeafCount())
+	}
+	if widths := bt.LevelWidths(); !reflect.DeepEqual(widths, []int{1, 2, 3, 1}) {
+		t.Errorf("LevelWidths() = %v; want [1 2 3 1]", widths)
+	}
+	if bt.Diameter() != 5 { // 7 -> 5 -> 2 -> 1 -> 3 -> 6
+		t.Errorf("Diameter() = %d; want 5", bt.Diameter())
+	}
+
+	empty := NewBinaryTree()
+	if empty.Height() != 0 || empty.Size() != 0 || empty.LeafCount() != 0 || empty.Diameter() != 0 {
+		t.Errorf("empty tree structural counts should be zero")
+	}
+}
+
+// TestDiameterNotThroughRoot проверяет диаметр, не проходящий через корень
+func TestDiameterNotThroughRoot(t *testing.T) {
+	bt := NewBinaryTree()
+	bt.Root = &TreeNode{Digit: 1, Left: &TreeNode{Digit: 2,
+		Left:  &TreeNode{Digit: 3, Left: &TreeNode{Digit: 4, Left: &TreeNode{Digit: 5}}},
+		Right: &TreeNode{Digit: 6, Right: &TreeNode{Digit: 7, Right: &TreeNode{Digit: 8}}},
+	}}
+	if bt.Diameter() != 6 {
+		t.Errorf("Diameter() = %d; want 6", bt.Diameter())
+	}
+}
+
+// TestShapeChecks проверяет признаки строгого, идеального, сбалансированного дерева и дерева поиска
+func TestShapeChecks(t *testing.T) {
+	perfect := NewBinaryTree()
+	for v := 1; v <= 7; v++ {
+		perfect.Insert(v)
+	}
+	if !perfect.IsFull() || !perfect.IsPerfect() || !perfect.IsBalanced() {
+		t.Errorf("7-node level-order tree should be full, perfect and balanced")
+	}
+	if perfect.IsBST() {
+		t.Errorf("IsBST() = true for level-order tree 1..7")
+	}
+
+	perfect.Insert(8)
+	if perfect.IsFull() || perfect.IsPerfect() || !perfect.IsBalanced() {
+		t.Errorf("8-node level-order tree should be balanced, but neither full nor perfect")
+	}
+
+	chain := NewBinarySearchTree()
+	for v := 1; v <= 4; v++ {
+		chain.Insert(v)
+	}
+	if chain.IsBalanced() || !chain.IsBST() {
+		t.Errorf("sorted BST chain should be a BST but not balanced")
+	}
+
+	bst := NewBinarySearchTree()
+	for _, v := range []int{4, 2, 6, 1, 3, 5, 7} {
+		bst.Insert(v)
+	}
+	if !bst.IsBST() || !bst.IsPerfect() {
+		t.Errorf("balanced BST should be a perfect BST")
+	}
+
+	duplicates := NewBinaryTree()
+	duplicates.Root = &TreeNode{Digit: 2, Left: &TreeNode{Digit: 2}}
+	if duplicates.IsBST() {
+		t.Errorf("IsBST() = true for duplicate values")
+	}
+}
+
+// TestPathAndLCA проверяет путь до узла и наименьшего общего предка
+func TestPathAndLCA(t *testing.T) {
+	bt := newTraversalTree()
+	if path, err := bt.PathTo(7); err != nil || !reflect.DeepEqual(path, []int{1, 2, 5, 7}) {
+		t.Errorf("PathTo(7) = %v, %v; want [1 2 5 7]", path, err)
+	}
+	if _, err := bt.PathTo(42); err == nil {
+		t.Errorf("PathTo(42) error = nil; want error")
+	}
+
+	tests := []struct{ a, b, lca int }{
+		{4, 7, 2}, {7, 6, 1}, {5, 7, 5}, {1, 1, 1}, {6, 3, 3},
+	}
+	for _, tt := range tests {
+		if lca, err := bt.LowestCommonAncestor(tt.a, tt.b); err != nil || lca != tt.lca {
+			t.Errorf("LowestCommonAncestor(%d, %d) = %d, %v; want %d", tt.a, tt.b, lca, err, tt.lca)
+		}
+	}
+	if _, err := bt.LowestCommonAncestor(4, 42); err == nil {
+		t.Errorf("LowestCommonAncestor(4, 42) error = nil; want error")
+	}
+	if _, err := bt.LowestCommonAncestor(42, 4); err == nil {
+		t.Errorf("LowestCommonAncestor(42, 4) error = nil; want error")
+	}
+
+	bst := NewBinarySearchTree()
+	for _, v := range []int{8, 3, 10, 1, 6, 14, 4, 7} {
+		bst.Insert(v)
+	}
+	if path, err := bst.PathTo(7); err != nil || !reflect.DeepEqual(path, []int{8, 3, 6, 7}) {
+		t.Errorf("ordered PathTo(7) = %v, %v; want [8 3 6 7]", path, err)
+	}
+	if lca, _ := bst.LowestCommonAncestor(4, 7); lca != 6 {
+		t.Errorf("ordered LowestCommonAncestor(4, 7) = %d; want 6", lca)
+	}
+	if _, err := bst.PathTo(5); err == nil {
+		t.Errorf("ordered PathTo(5) error = nil; want error")
+	}
+}
+
+// TestPrintInfo проверяет печать структурных характеристик
+func TestPrintInfo(t *testing.T) {
+	bt := newTraversalTree()
+	output := captureStdout(bt.PrintInfo)
+	for _, line := range []string{"Высота: 4", "Узлов: 7", "Листьев: 3", "Ширина по уровням: [1 2 3 1]", "Диаметр: 5", "Строгое: нет", "Дерево поиска: нет"} {
+		if !strings.Contains(output, line) {
+			t.Errorf("PrintInfo() output is missing %q:\n%s", line, output)
+		}
+	}
+}
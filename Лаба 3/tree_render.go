@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Render возвращает текстовое представление дерева в указанном формате:
+// sideways - повернутое дерево, как в Display;
+// ascii - дерево сверху вниз с ветвями из символов псевдографики;
+// dot - описание графа для Graphviz;
+// mermaid - описание графа для Mermaid.
+func (bt *BinaryTree) Render(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "sideways":
+		var sb strings.Builder
+		bt.printCBT(&sb, bt.Root, 0)
+		return sb.String(), nil
+	case "ascii":
+		return bt.renderASCII(), nil
+	case "dot":
+		return bt.renderDOT(), nil
+	case "mermaid":
+		return bt.renderMermaid(), nil
+	}
+	return "", fmt.Errorf("неизвестный формат отображения: %s", format)
+}
+
+// renderDOT формирует описание дерева на языке DOT. Если у узла только один потомок,
+// на месте отсутствующего добавляется невидимый узел, чтобы сохранить сторону ветви.
+func (bt *BinaryTree) renderDOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph BinaryTree {\n")
+	sb.WriteString("  node [shape=circle];\n")
+	ids := bt.nodeIDs()
+	missing := 0
+	bt.forEachNode(func(node *TreeNode) {
+		fmt.Fprintf(&sb, "  %s [label=\"%d\"];\n", ids[node], node.Digit)
+	})
+	bt.forEachNode(func(node *TreeNode) {
+		if node.Left == nil && node.Right == nil {
+			return
+		}
+		for _, child := range []*TreeNode{node.Left, node.Right} {
+			if child != nil {
+				fmt.Fprintf(&sb, "  %s -> %s;\n", ids[node], ids[child])
+				continue
+			}
+			fmt.Fprintf(&sb, "  null%d [shape=point, style=invis];\n", missing)
+			fmt.Fprintf(&sb, "  %s -> null%d [style=invis];\n", ids[node], missing)
+			missing++
+		}
+	})
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// renderMermaid формирует описание дерева для Mermaid; ребра подписаны L и R
+func (bt *BinaryTree) renderMermaid() string {
+	var sb strings.Builder
+	sb.WriteString("graph TD\n")
+	ids := bt.nodeIDs()
+	bt.forEachNode(func(node *TreeNode) {
+		fmt.Fprintf(&sb, "  %s((%d))\n", ids[node], node.Digit)
+	})
+	bt.forEachNode(func(node *TreeNode) {
+		if node.Left != nil {
+			fmt.Fprintf(&sb, "  %s -->|L| %s\n", ids[node], ids[node.Left])
+		}
+		if node.Right != nil {
+			fmt.Fprintf(&sb, "  %s -->|R| %s\n", ids[node], ids[node.Right])
+		}
+	})
+	return sb.String()
+}
+
+// nodeIDs присваивает узлам идентификаторы n0, n1, ... в порядке обхода по уровням
+func (bt *BinaryTree) nodeIDs() map[*TreeNode]string {
+	ids := make(map[*TreeNode]string)
+	bt.forEachNode(func(node *TreeNode) {
+		ids[node] = "n" + strconv.Itoa(len(ids))
+	})
+	return ids
+}
+
+// asciiBlock представляет отрисованное поддерево: строки одинаковой ширины
+// и позицию столбца, над которым находится корень поддерева
+type asciiBlock struct {
+	lines  []string
+	width  int
+	middle int
+}
+
+// renderASCII рисует дерево сверху вниз, соединяя узлы ветвями ┌─ и ─┐
+func (bt *BinaryTree) renderASCII() string {
+	if bt.Root == nil {
+		return ""
+	}
+	block := renderASCIIBlock(bt.Root)
+	var sb strings.Builder
+	for _, line := range block.lines {
+		sb.WriteString(strings.TrimRight(line, " "))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// renderASCIIBlock рекурсивно отрисовывает поддерево
+func renderASCIIBlock(node *TreeNode) asciiBlock {
+	label := strconv.Itoa(node.Digit)
+	size := utf8.RuneCountInString(label)
+
+	if node.Left == nil && node.Right == nil {
+		return asciiBlock{lines: []string{label}, width: size, middle: size / 2}
+	}
+
+	if node.Right == nil {
+		left := renderASCIIBlock(node.Left)
+		first := strings.Repeat(" ", left.middle) + "┌" + strings.Repeat("─", left.width-left.middle-1) + label
+		lines := []string{first}
+		for _, line := range left.lines {
+			lines = append(lines, line+strings.Repeat(" ", size))
+		}
+		return asciiBlock{lines: lines, width: left.width + size, middle: left.width + size/2}
+	}
+
+	if node.Left == nil {
+		right := renderASCIIBlock(node.Right)
+		first := label + strings.Repeat("─", right.middle) + "┐" + strings.Repeat(" ", right.width-right.middle-1)
+		lines := []string{first}
+		for _, line := range right.lines {
+			lines = append(lines, strings.Repeat(" ", size)+line)
+		}
+		return asciiBlock{lines: lines, width: right.width + size, middle: size / 2}
+	}
+
+	left := renderASCIIBlock(node.Left)
+	right := renderASCIIBlock(node.Right)
+	first := strings.Repeat(" ", left.middle) + "┌" + strings.Repeat("─", left.width-left.middle-1) +
+		label + strings.Repeat("─", right.middle) + "┐" + strings.Repeat(" ", right.width-right.middle-1)
+	lines := []string{first}
+	for i := 0; i < len(left.lines) || i < len(right.lines); i++ {
+		leftLine := strings.Repeat(" ", left.width)
+		if i < len(left.lines) {
+			leftLine = left.lines[i]
+		}
+		rightLine := strings.Repeat(" ", right.width)
+		if i < len(right.lines) {
+			rightLine = right.lines[i]
+		}
+		lines = append(lines, leftLine+strings.Repeat(" ", size)+rightLine)
+	}
+	return asciiBlock{lines: lines, width: left.width + size + right.width, middle: left.width + size/2}
+}
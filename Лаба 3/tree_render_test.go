@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRenderASCII проверяет отрисовку дерева сверху вниз
+func TestRenderASCII(t *testing.T) {
+	bt := newTraversalTree()
+	output, err := bt.Render("ascii")
+	if err != nil {
+		t.Fatalf("Render(ascii) error = %v", err)
+	}
+	expected := " ┌──1┐\n┌2─┐ 3┐\n4 ┌5  6\n  7\n"
+	if output != expected {
+		t.Errorf("Render(ascii) =\n%s\nwant\n%s", output, expected)
+	}
+
+	single := NewBinaryTree()
+	single.Insert(42)
+	if output, _ := single.Render("ascii"); output != "42\n" {
+		t.Errorf("Render(ascii) single node = %q", output)
+	}
+	if output, _ := NewBinaryTree().Render("ascii"); output != "" {
+		t.Errorf("Render(ascii) empty tree = %q; want empty string", output)
+	}
+}
+
+// TestRenderSideways проверяет, что формат sideways совпадает с Display
+func TestRenderSideways(t *testing.T) {
+	bt := newTraversalTree()
+	output, err := bt.Render("sideways")
+	if err != nil {
+		t.Fatalf("Render(sideways) error = %v", err)
+	}
+	if displayed := captureStdout(bt.Display); output != displayed {
+		t.Errorf("Render(sideways) = %q; want Display() output %q", output, displayed)
+	}
+}
+
+// TestRenderDOT проверяет экспорт в Graphviz DOT
+func TestRenderDOT(t *testing.T) {
+	bt := NewBinaryTree()
+	bt.Insert(10)
+	bt.Insert(5)
+	bt.Insert(15)
+	bt.Insert(3)
+	output, err := bt.Render("dot")
+	if err != nil {
+		t.Fatalf("Render(dot) error = %v", err)
+	}
+	for _, line := range []string{
+		"digraph BinaryTree {",
+		`n0 [label="10"];`,
+		`n3 [label="3"];`,
+		"n0 -> n1;",
+		"n0 -> n2;",
+		"n1 -> n3;",
+		"n1 -> null0 [style=invis];",
+	} {
+		if !strings.Contains(output, line) {
+			t.Errorf("Render(dot) is missing %q:\n%s", line, output)
+		}
+	}
+	if !strings.HasSuffix(output, "}\n") {
+		t.Errorf("Render(dot) should end with a closing brace")
+	}
+}
+
+// TestRenderMermaid проверяет экспорт в Mermaid
+func TestRenderMermaid(t *testing.T) {
+	bt := newTraversalTree()
+	output, err := bt.Render("MERMAID")
+	if err != nil {
+		t.Fatalf("Render(mermaid) error = %v", err)
+	}
+	for _, line := range []string{"graph TD", "n0((1))", "n0 -->|L| n1", "n2 -->|R| n5", "n4 -->|L| n6"} {
+		if !strings.Contains(output, line) {
+			t.Errorf("Render(mermaid) is missing %q:\n%s", line, output)
+		}
+	}
+}
+
+// TestRenderUnknownFormat проверяет ошибку для неизвестного формата
+func TestRenderUnknownFormat(t *testing.T) {
+	if _, err := NewBinaryTree().Render("svg"); err == nil {
+		t.Errorf("Render(svg) error = nil; want error")
+	}
+}
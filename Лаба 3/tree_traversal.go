@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// StackNode представляет узел в стеке для узлов дерева
+type StackNode struct {
+	Tree *TreeNode
+	Next *StackNode
+}
+
+// StackTree представляет стек для узлов дерева
+type StackTree struct {
+	Top   *StackNode
+	Count int
+}
+
+// NewStackTree создает новый стек для узлов дерева
+func NewStackTree() *StackTree {
+	return &StackTree{}
+}
+
+// Push добавляет узел дерева на вершину стека
+func (s *StackTree) Push(node *TreeNode) {
+	s.Top = &StackNode{Tree: node, Next: s.Top}
+	s.Count++
+}
+
+// Pop удаляет и возвращает узел дерева с вершины стека
+func (s *StackTree) Pop() *TreeNode {
+	if s.Top == nil {
+		return nil
+	}
+	temp := s.Top
+	s.Top = s.Top.Next
+	temp.Next = nil
+	s.Count--
+	return temp.Tree
+}
+
+// Peek возвращает узел дерева с вершины стека, не удаляя его
+func (s *StackTree) Peek() *TreeNode {
+	if s.Top == nil {
+		return nil
+	}
+	return s.Top.Tree
+}
+
+// IsEmpty проверяет, пуст ли стек
+func (s *StackTree) IsEmpty() bool {
+	return s.Top == nil
+}
+
+// InOrder возвращает итератор по значениям в симметричном порядке (левое, корень, правое)
+func (bt *BinaryTree) InOrder() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		stack := NewStackTree()
+		current := bt.Root
+		for current != nil || !stack.IsEmpty() {
+			for current != nil {
+				stack.Push(current)
+				current = current.Left
+			}
+			current = stack.Pop()
+			if !yield(current.Digit) {
+				return
+			}
+			current = current.Right
+		}
+	}
+}
+
+// PreOrder возвращает итератор по значениям в прямом порядке (корень, левое, правое)
+func (bt *BinaryTree) PreOrder() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		if bt.Root == nil {
+			return
+		}
+		stack := NewStackTree()
+		stack.Push(bt.Root)
+		for !stack.IsEmpty() {
+			current := stack.Pop()
+			if !yield(current.Digit) {
+				return
+			}
+			if current.Right != nil {
+				stack.Push(current.Right)
+			}
+			if current.Left != nil {
+				stack.Push(current.Left)
+			}
+		}
+	}
+}
+
+// PostOrder возвращает итератор по значениям в обратном порядке (левое, правое, корень)
+func (bt *BinaryTree) PostOrder() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		stack := NewStackTree()
+		var lastVisited *TreeNode
+		current := bt.Root
+		for current != nil || !stack.IsEmpty() {
+			for current != nil {
+				stack.Push(current)
+				current = current.Left
+			}
+			top := stack.Peek()
+			if top.Right != nil && top.Right != lastVisited {
+				current = top.Right
+				continue
+			}
+			stack.Pop()
+			if !yield(top.Digit) {
+				return
+			}
+			lastVisited = top
+		}
+	}
+}
+
+// LevelOrder возвращает итератор по значениям в порядке обхода по уровням
+func (bt *BinaryTree) LevelOrder() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		if bt.Root == nil {
+			return
+		}
+		queue := NewQueueTree()
+		queue.Enqueue(bt.Root)
+		for !queue.IsEmpty() {
+			current := queue.Dequeue()
+			if !yield(current.Digit) {
+				return
+			}
+			if current.Left != nil {
+				queue.Enqueue(current.Left)
+			}
+			if current.Right != nil {
+				queue.Enqueue(current.Right)
+			}
+		}
+	}
+}
+
+// ZigZag возвращает итератор по уровням с чередованием направления:
+// первый уровень слева направо, второй справа налево и так далее
+func (bt *BinaryTree) ZigZag() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		if bt.Root == nil {
+			return
+		}
+		current, next := NewStackTree(), NewStackTree()
+		current.Push(bt.Root)
+		leftToRight := true
+		for !current.IsEmpty() {
+			for !current.IsEmpty() {
+				node := current.Pop()
+				if !yield(node.Digit) {
+					return
+				}
+				first, second := node.Left, node.Right
+				if !leftToRight {
+					first, second = second, first
+				}
+				if first != nil {
+					next.Push(first)
+				}
+				if second != nil {
+					next.Push(second)
+				}
+			}
+			current, next = next, current
+			leftToRight = !leftToRight
+		}
+	}
+}
+
+// Traversal возвращает итератор для обхода с указанным названием:
+// inorder, preorder, postorder, levelorder или zigzag
+func (bt *BinaryTree) Traversal(order string) (iter.Seq[int], error) {
+	switch strings.ToLower(order) {
+	case "inorder":
+		return bt.InOrder(), nil
+	case "preorder":
+		return bt.PreOrder(), nil
+	case "postorder":
+		return bt.PostOrder(), nil
+	case "levelorder":
+		return bt.LevelOrder(), nil
+	case "zigzag":
+		return bt.ZigZag(), nil
+	}
+	return nil, fmt.Errorf("неизвестный порядок обхода: %s", order)
+}
+
+// PrintTraversal печатает значения дерева в указанном порядке обхода
+func (bt *BinaryTree) PrintTraversal(order string) error {
+	seq, err := bt.Traversal(order)
+	if err != nil {
+		return err
+	}
+	for value := range seq {
+		fmt.Print(value, " ")
+	}
+	fmt.Println()
+	return nil
+}
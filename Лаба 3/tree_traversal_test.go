@@ -0,0 +1,137 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// newTraversalTree строит дерево
+//
+//	     1
+//	   /   \
+//	  2     3
+//	 / \     \
+//	4   5     6
+//	   /
+//	  7
+func newTraversalTree() *BinaryTree {
+	bt := NewBinaryTree()
+	bt.Root = &TreeNode{Digit: 1,
+		Left: &TreeNode{Digit: 2,
+			Left:  &TreeNode{Digit: 4},
+			Right: &TreeNode{Digit: 5, Left: &TreeNode{Digit: 7}},
+		},
+		Right: &TreeNode{Digit: 3, Right: &TreeNode{Digit: 6}},
+	}
+	return bt
+}
+
+// TestStackTree проверяет работу стека для узлов дерева
+func TestStackTree(t *testing.T) {
+	stack := NewStackTree()
+	if !stack.IsEmpty() || stack.Pop() != nil || stack.Peek() != nil {
+		t.Errorf("NewStackTree() = %v; want empty stack", stack)
+	}
+	stack.Push(&TreeNode{Digit: 1})
+	stack.Push(&TreeNode{Digit: 2})
+	if stack.Count != 2 || stack.Peek().Digit != 2 {
+		t.Errorf("Push() = %v; want 2 on top", stack)
+	}
+	if stack.Pop().Digit != 2 || stack.Pop().Digit != 1 || !stack.IsEmpty() {
+		t.Errorf("Pop() returned elements in wrong order")
+	}
+}
+
+// TestTraversals проверяет все порядки обхода
+func TestTraversals(t *testing.T) {
+	bt := newTraversalTree()
+	tests := map[string][]int{
+		"inorder":    {4, 2, 7, 5, 1, 3, 6},
+		"preorder":   {1, 2, 4, 5, 7, 3, 6},
+		"postorder":  {4, 7, 5, 2, 6, 3, 1},
+		"levelorder": {1, 2, 3, 4, 5, 6, 7},
+		"zigzag":     {1, 3, 2, 4, 5, 6, 7},
+	}
+	for order, expected := range tests {
+		seq, err := bt.Traversal(order)
+		if err != nil {
+			t.Fatalf("Traversal(%s) error = %v", order, err)
+		}
+		if got := slices.Collect(seq); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Traversal(%s) = %v; want %v", order, got, expected)
+		}
+
+		// Пустое дерево
+		emptySeq, _ := NewBinaryTree().Traversal(order)
+		if got := slices.Collect(emptySeq); len(got) != 0 {
+			t.Errorf("Traversal(%s) on empty tree = %v; want empty", order, got)
+		}
+
+		// Досрочное завершение итерации
+		var first []int
+		for v := range seq {
+			first = append(first, v)
+			if len(first) == 2 {
+				break
+			}
+		}
+		if !reflect.DeepEqual(first, expected[:2]) {
+			t.Errorf("Traversal(%s) with break = %v; want %v", order, first, expected[:2])
+		}
+	}
+
+	if _, err := bt.Traversal("sideways"); err == nil {
+		t.Errorf("Traversal(sideways) error = nil; want error")
+	}
+}
+
+// TestZigZagDeepLevels проверяет чередование направления на нескольких уровнях
+func TestZigZagDeepLevels(t *testing.T) {
+	bt := NewBinaryTree()
+	for v := 1; v <= 15; v++ {
+		bt.Insert(v)
+	}
+	expected := []int{1, 3, 2, 4, 5, 6, 7, 15, 14, 13, 12, 11, 10, 9, 8}
+	if got := slices.Collect(bt.ZigZag()); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ZigZag() = %v; want %v", got, expected)
+	}
+}
+
+// TestTraversalDeepTree проверяет, что обходы не используют рекурсию на вырожденном дереве
+func TestTraversalDeepTree(t *testing.T) {
+	bt := NewBinarySearchTree()
+	const n = 100000
+	// Вырожденное дерево строится напрямую, чтобы не тратить O(n^2) на вставку
+	link := &bt.Root
+	for v := 0; v < n; v++ {
+		*link = &TreeNode{Digit: v}
+		link = &(*link).Right
+	}
+	for _, order := range []string{"inorder", "preorder", "postorder", "levelorder", "zigzag"} {
+		seq, _ := bt.Traversal(order)
+		count := 0
+		for range seq {
+			count++
+		}
+		if count != n {
+			t.Errorf("Traversal(%s) visited %d nodes; want %d", order, count, n)
+		}
+	}
+}
+
+// TestPrintTraversal проверяет печать обхода
+func TestPrintTraversal(t *testing.T) {
+	bt := newTraversalTree()
+	output := captureStdout(func() {
+		if err := bt.PrintTraversal("inorder"); err != nil {
+			t.Errorf("PrintTraversal() error = %v", err)
+		}
+	})
+	if output != "4 2 7 5 1 3 6 \n" {
+		t.Errorf("PrintTraversal() = %q", output)
+	}
+	if err := bt.PrintTraversal("unknown"); err == nil {
+		t.Errorf("PrintTraversal(unknown) error = nil; want error")
+	}
+}